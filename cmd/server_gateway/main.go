@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	admin "github.com/prothegee/network-limiter-go/pkg/admin"
+	ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	cidr "github.com/prothegee/network-limiter-go/pkg/cidr"
+	config "github.com/prothegee/network-limiter-go/pkg/config"
+	gateway "github.com/prothegee/network-limiter-go/pkg/gateway"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	logging "github.com/prothegee/network-limiter-go/pkg/logging"
+	metrics "github.com/prothegee/network-limiter-go/pkg/metrics"
+	tracing "github.com/prothegee/network-limiter-go/pkg/tracing"
+)
+
+// --------------------------------------------------------- //
+
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+func main() {
+	configPath := flag.String("config", "../../config.gateway.json",
+		"config file path, format is detected from .json, .yaml, .yml or .toml extension")
+	flag.Parse()
+
+	configFormat, err := config.FormatFromPath(*configPath); if err != nil {
+		fatal("can't load config", "error", err)
+	}
+
+	cfg, err := config.ConfigServerGatewayLoad(*configPath)
+	if err != nil {
+		fatal("can't load config", "error", err,
+			"note", "try to copy config.gateway.json.template as config.gateway.json and adjust as you need")
+	}
+
+	logger, err := logging.NewLogger(os.Stderr, cfg.Log.Level, cfg.Log.Format); if err != nil {
+		fatal("can't create logger", "error", err)
+	}
+	slog.SetDefault(logger)
+	config.Logger = logger
+
+	// every background goroutine stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// called in order on shutdown, sharing drain timeout
+	shutdowns := []func(context.Context) error{}
+
+	cleanupInterval := time.Duration(cfg.Limiter.CleanupOldRequestInterval)
+
+	access, err := cidr.NewAccessList(cfg.Access.Allow, cfg.Access.Deny,
+		cfg.Access.AllowFile, cfg.Access.DenyFile); if err != nil {
+			fatal("can't load access list", "error", err)
+		}
+	if err := access.TrustProxies(cfg.Access.TrustedProxies); err != nil {
+		fatal("can't load trusted proxies", "error", err)
+	}
+	access.Logger = logger
+
+	// access list, ban box and observer is shared by every route
+	observers := []gen.DecisionObserver{logging.NewRejectionLogger(logger,
+		time.Duration(cfg.Log.RejectionSampleInterval) * time.Second,
+		cfg.Log.RejectionSampleBurst)}
+
+	var box *ban.BanBox
+	if cfg.Ban.Threshold > 0 {
+		box = ban.NewBanBox(uint(cfg.Ban.Threshold),
+			time.Duration(cfg.Ban.Window) * time.Second,
+			time.Duration(cfg.Ban.BaseDuration) * time.Second,
+			cfg.Ban.Factor,
+			time.Duration(cfg.Ban.MaxDuration) * time.Second)
+
+		go ban.CleanupExpiredBan(ctx, box,
+			time.Duration(cfg.Ban.CleanupInterval) * time.Second)
+	}
+
+	// one limiter per route, so each upstream has its own quota
+	routes := []*gateway.Route{}
+	limiters := map[string]*http_limiter.HttpRateLimiter{}
+
+	for _, rc := range cfg.Routes {
+		maxReq, maxReqInterval, err := rc.Policy(cfg.Limiter); if err != nil {
+			fatal("invalid limiter policy", "route", rc.Name, "error", err)
+		}
+
+		upstream, err := url.Parse(rc.Upstream); if err != nil {
+			fatal("invalid upstream", "route", rc.Name, "error", err)
+		}
+
+		limiter := http_limiter.NewHttpRateLimiter(maxReq, maxReqInterval)
+		limiter.Logger = logger
+		limiters[rc.Name] = limiter
+
+		route := &gateway.Route{
+			Name: rc.Name,
+			Prefix: rc.Prefix,
+			Upstream: upstream,
+			StripPrefix: rc.StripPrefix,
+			RequestHeaders: gateway.HeaderRewrite{Set: rc.RequestHeaders.Set, Remove: rc.RequestHeaders.Remove},
+			ResponseHeaders: gateway.HeaderRewrite{Set: rc.ResponseHeaders.Set, Remove: rc.ResponseHeaders.Remove},
+			Middleware: &http_limiter.HttpMiddleware{Limiter: limiter, Access: access, Ban: box},
+		}
+
+		if interval := time.Duration(rc.HealthCheck.Interval); interval > 0 {
+			timeout := time.Duration(rc.HealthCheck.Timeout)
+			if timeout <= 0 {
+				timeout = interval
+			}
+
+			// health path is absolute on upstream host
+			probe := upstream.ResolveReference(&url.URL{Path: rc.HealthCheck.Path})
+			route.Health = gateway.NewHealthCheck(probe.String(), timeout)
+			route.Health.Logger = logger
+
+			go gateway.WatchHealth(ctx, route.Health, interval)
+		}
+
+		go http_limiter.CleanupOldRequest(ctx, limiter, cleanupInterval)
+
+		routes = append(routes, route)
+		logger.Info("route registered", "route", rc.Name, "prefix", rc.Prefix,
+			"upstream", rc.Upstream, "max_request", maxReq, "duration", maxReqInterval)
+	}
+
+	if cfg.Metrics.Enabled {
+		registry := metrics.NewRegistry()
+		limiterMetrics := metrics.NewLimiterMetrics(registry)
+
+		for name, limiter := range limiters {
+			limiterMetrics.TrackKeys("http/" + name, limiter.KeyCount)
+			limiter.OnCleanup = limiterMetrics.ObserveCleanup("http/" + name)
+		}
+		if box != nil {
+			limiterMetrics.TrackBans("http", box.Count)
+		}
+		observers = append(observers, limiterMetrics)
+
+		metricsAddr := fmt.Sprintf("%s:%d", cfg.Metrics.Address, cfg.Metrics.Port)
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle(cfg.Metrics.Path, registry.Handler())
+		metricsServer := &http.Server{Addr: metricsAddr, Handler: metricsMux}
+		shutdowns = append(shutdowns, metricsServer.Shutdown)
+
+		go func() {
+			logger.Info("run metrics server", "address", metricsAddr, "path", cfg.Metrics.Path)
+			if err := metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				fatal("metrics server stopped", "error", err)
+			}
+		}()
+	}
+
+	// admin api per route, e.g. /<route>/admin/keys
+	if cfg.Admin.Enabled {
+		adminAddr := fmt.Sprintf("%s:%d", cfg.Admin.Address, cfg.Admin.Port)
+		adminMux := http.NewServeMux()
+		for name, limiter := range limiters {
+			adminMux.Handle("/" + name + "/admin/", http.StripPrefix("/" + name,
+				admin.NewAdminServer(cfg.Admin.Token, limiter, box).Handler()))
+		}
+		adminServer := &http.Server{Addr: adminAddr, Handler: adminMux}
+
+		shutdowns = append(shutdowns, adminServer.Shutdown)
+
+		go func() {
+			logger.Info("run admin server", "address", adminAddr)
+			if err := adminServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				fatal("admin server stopped", "error", err)
+			}
+		}()
+	}
+
+	gw := gateway.NewGateway(routes, logger)
+	var handler http.Handler = gw
+
+	if cfg.Tracing.Enabled {
+		tp, err := tracing.NewProvider(ctx, cfg.Tracing.Endpoint,
+			cfg.Tracing.Insecure, cfg.Tracing.SampleRatio, cfg.Tracing.ServiceName); if err != nil {
+				fatal("can't create tracer provider", "error", err)
+			}
+		shutdowns = append(shutdowns, tp.Shutdown)
+
+		observers = append(observers, tracing.NewDecisionTracer())
+		handler = tracing.HttpServerSpan(tp, gw)
+	}
+
+	for _, route := range routes {
+		route.Middleware.Observers = observers
+	}
+
+	// route policy reload on config file change or SIGHUP, adding or removing route need restart
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	go config.WatchConfig(ctx, *configPath,
+		time.Duration(cfg.Limiter.ReloadInterval), hup,
+		func(content []byte) error {
+			next, err := config.ConfigServerGatewayParse(content, configFormat); if err != nil {
+				return err
+			}
+
+			for _, rc := range next.Routes {
+				limiter, ok := limiters[rc.Name]; if !ok {
+					logger.Warn("new route need restart", "route", rc.Name)
+					continue
+				}
+
+				maxReq, duration, err := rc.Policy(next.Limiter); if err != nil {
+					return err
+				}
+
+				limiter.UpdatePolicy(maxReq, duration)
+				logger.Info("limiter policy updated", "route", rc.Name, "max_request", maxReq, "duration", duration)
+			}
+
+			return nil
+		})
+
+	if cfg.Access.ReloadInterval > 0 {
+		go cidr.WatchAccessList(ctx, access,
+			time.Duration(cfg.Access.ReloadInterval) * time.Second)
+	}
+
+	listAddr := fmt.Sprintf("%s:%d", cfg.Listener.Address, cfg.Listener.Port)
+	server := &http.Server{
+		Addr: listAddr,
+		Handler: handler,
+		IdleTimeout: time.Duration(cfg.Server.IdleTimeout) * time.Second,
+		ReadTimeout: time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+	}
+
+	// main server drain first, then the rest
+	shutdowns = append([]func(context.Context) error{server.Shutdown}, shutdowns...)
+
+	go func() {
+		logger.Info("run gateway server", "address", listAddr, "routes", len(routes))
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			fatal("gateway server stopped", "error", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+
+	shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeout) * time.Second
+	logger.Info("shutting down", "timeout", shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, shutdown := range shutdowns {
+		if err := shutdown(shutdownCtx); err != nil {
+			logger.Warn("shutdown incomplete", "error", err)
+		}
+	}
+
+	logger.Info("gateway server stopped")
+}
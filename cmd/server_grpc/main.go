@@ -2,16 +2,29 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	gen "github.com/prothegee/network-limiter-go/pkg"
+	admin "github.com/prothegee/network-limiter-go/pkg/admin"
+	ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	cidr "github.com/prothegee/network-limiter-go/pkg/cidr"
 	config "github.com/prothegee/network-limiter-go/pkg/config"
 	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	listener "github.com/prothegee/network-limiter-go/pkg/listener"
+	logging "github.com/prothegee/network-limiter-go/pkg/logging"
+	metrics "github.com/prothegee/network-limiter-go/pkg/metrics"
+	tracing "github.com/prothegee/network-limiter-go/pkg/tracing"
 	pb "github.com/prothegee/network-limiter-go/protobuf"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
@@ -56,36 +69,349 @@ func (s *Server) SendLocationAndSave(ctx context.Context,
 
 // --------------------------------------------------------- //
 
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func main() {
-	cfg, err := config.ConfigServerGrpcLoad("../../config.grpc.json")
+	configPath := flag.String("config", "../../config.grpc.json",
+		"config file path, format is detected from .json, .yaml, .yml or .toml extension")
+	flag.Parse()
+
+	configFormat, err := config.FormatFromPath(*configPath); if err != nil {
+		fatal("can't load config", "error", err)
+	}
+
+	cfg, err := config.ConfigServerGrpcLoad(*configPath)
 	if err != nil {
-		log.Fatalf("can't load config: %v\nNOTE:\n- try to copy config.grpc.json.template as config.grpc.json and adjust as you need\n", err)
-		return
+		fatal("can't load config", "error", err,
+			"note", "try to copy config.grpc.json.template as config.grpc.json and adjust as you need")
+	}
+
+	logger, err := logging.NewLogger(os.Stderr, cfg.Log.Level, cfg.Log.Format); if err != nil {
+		fatal("can't create logger", "error", err)
 	}
+	slog.SetDefault(logger)
+	config.Logger = logger
+
+	// every background goroutine stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// called in order on shutdown, sharing drain timeout
+	shutdowns := []func(context.Context) error{}
 
-	maxReqInterval := time.Duration(cfg.Limiter.MaxRequestInterval) * time.Second
-	cleanupInterval := time.Duration(cfg.Limiter.CleanupOldRequestInterval) * time.Second
+	// rate string win over max_request_per_ip & max_request_interval
+	maxReq, maxReqInterval, err := cfg.Limiter.Policy(); if err != nil {
+		fatal("invalid limiter policy", "error", err)
+	}
+	cleanupInterval := time.Duration(cfg.Limiter.CleanupOldRequestInterval)
 
-	limiter := grpc_limiter.NewGrpcRateLimiter(
-		uint(cfg.Limiter.MaxRequestPerIp), maxReqInterval)
+	limiter := grpc_limiter.NewGrpcRateLimiter(maxReq, maxReqInterval)
 	middleware := grpc_limiter.NewGrpcMiddleware(limiter)
 
-	server := grpc.NewServer(
-		grpc.UnaryInterceptor(middleware.Limit()),
-	)
+	// always set so reload can raise or lift the in-flight cap, 0 never reject
+	concurrency := gen.NewConcurrencyLimiter(cfg.Limiter.MaxInFlightPerIp, cfg.Limiter.MaxInFlight)
+	middleware.Concurrency = concurrency
+
+	queue := gen.NewWaitQueue(cfg.Limiter.QueueSize, time.Duration(cfg.Limiter.QueueMaxWait))
+	middleware.Queue = queue
+
+	costs := gen.NewCostTable(cfg.Limiter.Costs)
+	middleware.Costs = costs
+
+	// applied at start only, limit is learned at runtime anyway
+	if cfg.Adaptive.Enabled {
+		adaptive := gen.NewAdaptiveLimiter(cfg.Adaptive.MinLimit, cfg.Adaptive.MaxLimit,
+			time.Duration(cfg.Adaptive.TargetLatency))
+		adaptive.Backoff = cfg.Adaptive.Backoff
+		middleware.Adaptive = adaptive
+
+		if cfg.Adaptive.PriorityHeader != "" {
+			middleware.PriorityFunc = grpc_limiter.PriorityFromMetadata(strings.ToLower(cfg.Adaptive.PriorityHeader))
+		}
+	}
+
+	// applied at start only, tenant class win over priority header, missing tenant header use client ip
+	if cfg.FairQueue.Enabled {
+		fair := gen.NewFairQueue(cfg.FairQueue.MaxInFlight, cfg.FairQueue.QueueSize,
+			time.Duration(cfg.FairQueue.MaxWait))
+		for class, weight := range cfg.FairQueue.Weights {
+			p, _ := gen.ParsePriority(class)
+			fair.Weights[p] = weight
+		}
+		middleware.Fair = fair
+
+		// adaptive priority header, if any, unless fair queue has its own
+		fallback := middleware.PriorityFunc
+		if cfg.FairQueue.PriorityHeader != "" {
+			fallback = grpc_limiter.PriorityFromMetadata(strings.ToLower(cfg.FairQueue.PriorityHeader))
+		}
+
+		middleware.TenantFunc = grpc_limiter.TenantFromMetadata(strings.ToLower(cfg.FairQueue.TenantHeader))
+
+		classes := make(map[string]gen.Priority, len(cfg.FairQueue.Tenants))
+		for tenant, class := range cfg.FairQueue.Tenants {
+			classes[tenant], _ = gen.ParsePriority(class)
+		}
+		middleware.PriorityFunc = grpc_limiter.PriorityByTenant(middleware.TenantFunc, classes, fallback)
+	}
+
+	access, err := cidr.NewAccessList(cfg.Access.Allow, cfg.Access.Deny,
+		cfg.Access.AllowFile, cfg.Access.DenyFile); if err != nil {
+			fatal("can't load access list", "error", err)
+		}
+	if err := access.TrustProxies(cfg.Access.TrustedProxies); err != nil {
+		fatal("can't load trusted proxies", "error", err)
+	}
+	middleware.Access = access
+
+	limiter.Logger = logger
+	access.Logger = logger
+	middleware.Observers = append(middleware.Observers, logging.NewRejectionLogger(logger,
+		time.Duration(cfg.Log.RejectionSampleInterval) * time.Second,
+		cfg.Log.RejectionSampleBurst))
+
+	if cfg.Ban.Threshold > 0 {
+		middleware.Ban = ban.NewBanBox(uint(cfg.Ban.Threshold),
+			time.Duration(cfg.Ban.Window) * time.Second,
+			time.Duration(cfg.Ban.BaseDuration) * time.Second,
+			cfg.Ban.Factor,
+			time.Duration(cfg.Ban.MaxDuration) * time.Second)
+
+		go ban.CleanupExpiredBan(ctx, middleware.Ban,
+			time.Duration(cfg.Ban.CleanupInterval) * time.Second)
+	}
+
+	if cfg.Metrics.Enabled {
+		registry := metrics.NewRegistry()
+		limiterMetrics := metrics.NewLimiterMetrics(registry)
+
+		limiterMetrics.TrackKeys("grpc", limiter.KeyCount)
+		if middleware.Ban != nil {
+			limiterMetrics.TrackBans("grpc", middleware.Ban.Count)
+		}
+		limiter.OnCleanup = limiterMetrics.ObserveCleanup("grpc")
+		middleware.Observers = append(middleware.Observers, limiterMetrics)
+
+		metricsAddr := fmt.Sprintf("%s:%d", cfg.Metrics.Address, cfg.Metrics.Port)
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle(cfg.Metrics.Path, registry.Handler())
+		metricsServer := &http.Server{Addr: metricsAddr, Handler: metricsMux}
+		shutdowns = append(shutdowns, metricsServer.Shutdown)
+
+		go func() {
+			logger.Info("run metrics server", "address", metricsAddr, "path", cfg.Metrics.Path)
+			if err := metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				fatal("metrics server stopped", "error", err)
+			}
+		}()
+	}
+
+	if cfg.Admin.Enabled {
+		adminAddr := fmt.Sprintf("%s:%d", cfg.Admin.Address, cfg.Admin.Port)
+		adminServer := &http.Server{
+			Addr: adminAddr,
+			Handler: admin.NewAdminServer(cfg.Admin.Token, limiter, middleware.Ban).Handler(),
+		}
+
+		shutdowns = append(shutdowns, adminServer.Shutdown)
+
+		go func() {
+			logger.Info("run admin server", "address", adminAddr)
+			if err := adminServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				fatal("admin server stopped", "error", err)
+			}
+		}()
+	}
+
+	interceptors := []grpc.UnaryServerInterceptor{}
+
+	if cfg.Tracing.Enabled {
+		tp, err := tracing.NewProvider(ctx, cfg.Tracing.Endpoint,
+			cfg.Tracing.Insecure, cfg.Tracing.SampleRatio, cfg.Tracing.ServiceName); if err != nil {
+				fatal("can't create tracer provider", "error", err)
+			}
+		shutdowns = append(shutdowns, tp.Shutdown)
+
+		middleware.Observers = append(middleware.Observers, tracing.NewDecisionTracer())
+		interceptors = append(interceptors, tracing.UnaryServerSpan(tp))
+	}
+
+	// envoy call rls from few proxy ip, so it must not be limited by client ip
+	if cfg.Rls.Enabled {
+		middleware.Exempt = append(middleware.Exempt, pb.RATE_LIMIT_SERVICE)
+	}
+
+	// admin call is token protected, banned or limited operator must still reach unban & reset
+	if cfg.Admin.GrpcService {
+		middleware.Exempt = append(middleware.Exempt, pb.RATE_LIMITER_ADMIN_SERVICE)
+	}
+
+	interceptors = append(interceptors, middleware.Limit())
+
+	// message is paced after the call pass the limiter, nil side is unlimited
+	if cfg.Bandwidth.UploadPerIp > 0 || cfg.Bandwidth.Upload > 0 ||
+		cfg.Bandwidth.DownloadPerIp > 0 || cfg.Bandwidth.Download > 0 {
+		bandwidth := grpc_limiter.NewGrpcBandwidth(nil, nil)
+		if cfg.Bandwidth.UploadPerIp > 0 || cfg.Bandwidth.Upload > 0 {
+			bandwidth.Upload = gen.NewBandwidthLimiter(int64(cfg.Bandwidth.UploadPerIp), int64(cfg.Bandwidth.Upload))
+			go gen.CleanupIdleBandwidth(ctx, bandwidth.Upload, cleanupInterval)
+		}
+		if cfg.Bandwidth.DownloadPerIp > 0 || cfg.Bandwidth.Download > 0 {
+			bandwidth.Download = gen.NewBandwidthLimiter(int64(cfg.Bandwidth.DownloadPerIp), int64(cfg.Bandwidth.Download))
+			go gen.CleanupIdleBandwidth(ctx, bandwidth.Download, cleanupInterval)
+		}
+
+		interceptors = append(interceptors, bandwidth.Limit())
+	}
+
+	serverOpts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(interceptors...)}
+	if cfg.ConnLimit.MaxConcurrentStreams > 0 {
+		serverOpts = append(serverOpts, grpc.MaxConcurrentStreams(cfg.ConnLimit.MaxConcurrentStreams))
+	}
+
+	server := grpc.NewServer(serverOpts...)
 
 	pb.RegisterLocationServer(server, &Server{})
 
+	if cfg.Admin.GrpcService {
+		pb.RegisterRateLimiterAdminServer(server,
+			grpc_limiter.NewGrpcAdminServer(cfg.Admin.Token, limiter, middleware.Ban))
+	}
+
+	// one limiter per rls policy, descriptor is the counting key
+	rlsLimiters := map[string]*grpc_limiter.GrpcRateLimiter{}
+	if cfg.Rls.Enabled {
+		policies := []*grpc_limiter.RlsPolicy{}
+		for _, pc := range cfg.Rls.Policies {
+			maxReq, window := pc.Rate.Window()
+
+			rlsLimiter := grpc_limiter.NewGrpcRateLimiter(maxReq, window)
+			rlsLimiter.Logger = logger
+			rlsLimiters[pc.Name] = rlsLimiter
+
+			policies = append(policies, &grpc_limiter.RlsPolicy{
+				Name: pc.Name,
+				Domain: pc.Domain,
+				Match: pc.Match,
+				Limiter: rlsLimiter,
+			})
+
+			go grpc_limiter.CleanupOldRequest(ctx, rlsLimiter, cleanupInterval)
+		}
+
+		rls := grpc_limiter.NewRlsServer(policies)
+		rls.Observers = middleware.Observers
+		pb.RegisterRateLimitServiceServer(server, rls)
+	}
+
 	reflection.Register(server)
 
-	go grpc_limiter.CleanupOldRequest(limiter, cleanupInterval)
+	go grpc_limiter.CleanupOldRequest(ctx, limiter, cleanupInterval)
+
+	// limiter policy reload on config file change or SIGHUP, tracked request is kept
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	go config.WatchConfig(ctx, *configPath,
+		time.Duration(cfg.Limiter.ReloadInterval), hup,
+		func(content []byte) error {
+			next, err := config.ConfigServerGrpcParse(content, configFormat); if err != nil {
+				return err
+			}
+
+			maxReq, duration, err := next.Limiter.Policy(); if err != nil {
+				return err
+			}
+
+			limiter.UpdatePolicy(maxReq, duration)
+			concurrency.UpdatePolicy(next.Limiter.MaxInFlightPerIp, next.Limiter.MaxInFlight)
+			queue.UpdatePolicy(next.Limiter.QueueSize, time.Duration(next.Limiter.QueueMaxWait))
+			costs.Update(next.Limiter.Costs)
+			logger.Info("limiter policy updated", "max_request", maxReq, "duration", duration,
+				"max_in_flight_per_ip", next.Limiter.MaxInFlightPerIp, "max_in_flight", next.Limiter.MaxInFlight)
+
+			for _, pc := range next.Rls.Policies {
+				rlsLimiter, ok := rlsLimiters[pc.Name]; if !ok {
+					logger.Warn("new rls policy need restart", "policy", pc.Name)
+					continue
+				}
+
+				maxReq, duration := pc.Rate.Window()
+				rlsLimiter.UpdatePolicy(maxReq, duration)
+				logger.Info("rls policy updated", "policy", pc.Name, "max_request", maxReq, "duration", duration)
+			}
+
+			return nil
+		})
+
+	if cfg.Access.ReloadInterval > 0 {
+		go cidr.WatchAccessList(ctx, access,
+			time.Duration(cfg.Access.ReloadInterval) * time.Second)
+	}
 
 	listAddr, err := net.Listen("tcp",
 		fmt.Sprintf("%s:%d", cfg.Listener.Address, cfg.Listener.Port)); if err != nil {
-			log.Fatalf("error: %v\n", err)
+			fatal("can't listen", "error", err)
 		}
 
-	log.Printf("INFO: run grpc server on %s\n",
-		fmt.Sprintf("%s:%d", cfg.Listener.Address, cfg.Listener.Port))
-	log.Fatal(server.Serve(listAddr))
+	// idle connection is counted per ip before any call is read
+	if cfg.ConnLimit.MaxConn > 0 || cfg.ConnLimit.MaxConnPerIp > 0 || cfg.ConnLimit.ConnRatePerIp.IsSet() {
+		connLimited := listener.NewListener(listAddr, cfg.ConnLimit.MaxConn, cfg.ConnLimit.MaxConnPerIp, nil)
+		connLimited.Observers = middleware.Observers
+
+		if cfg.ConnLimit.ConnRatePerIp.IsSet() {
+			maxConn, window := cfg.ConnLimit.ConnRatePerIp.Window()
+			connRate := grpc_limiter.NewGrpcRateLimiter(maxConn, window)
+			connLimited.Rate = connRate.Method("conn")
+
+			go grpc_limiter.CleanupOldRequest(ctx, connRate, cleanupInterval)
+		}
+
+		listAddr = connLimited
+	}
+
+	// main server drain first, force stop when drain timeout reached
+	shutdowns = append([]func(context.Context) error{func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			server.Stop()
+			return ctx.Err()
+		}
+	}}, shutdowns...)
+
+	go func() {
+		logger.Info("run grpc server", "address", listAddr.Addr().String())
+		if err := server.Serve(listAddr); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			fatal("grpc server stopped", "error", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+
+	shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeout) * time.Second
+	logger.Info("shutting down", "timeout", shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, shutdown := range shutdowns {
+		if err := shutdown(shutdownCtx); err != nil {
+			logger.Warn("shutdown incomplete", "error", err)
+		}
+	}
+
+	logger.Info("grpc server stopped")
 }
@@ -1,16 +1,30 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	gen "github.com/prothegee/network-limiter-go/pkg"
+	admin "github.com/prothegee/network-limiter-go/pkg/admin"
+	ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	cidr "github.com/prothegee/network-limiter-go/pkg/cidr"
 	config "github.com/prothegee/network-limiter-go/pkg/config"
 	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	listener "github.com/prothegee/network-limiter-go/pkg/listener"
+	logging "github.com/prothegee/network-limiter-go/pkg/logging"
+	metrics "github.com/prothegee/network-limiter-go/pkg/metrics"
+	tracing "github.com/prothegee/network-limiter-go/pkg/tracing"
 )
 
 // --------------------------------------------------------- //
@@ -65,35 +79,294 @@ func handlerHome(w http.ResponseWriter, r *http.Request) {
 
 // --------------------------------------------------------- //
 
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 func main() {
-	cfg, err := config.ConfigServerHttpLoad("../../config.http.json")
+	configPath := flag.String("config", "../../config.http.json",
+		"config file path, format is detected from .json, .yaml, .yml or .toml extension")
+	flag.Parse()
+
+	configFormat, err := config.FormatFromPath(*configPath); if err != nil {
+		fatal("can't load config", "error", err)
+	}
+
+	cfg, err := config.ConfigServerHttpLoad(*configPath)
 	if err != nil {
-		log.Fatalf("can't load config: %v\nNOTE:\n- try to copy config.http.json.template as config.http.json and adjust as you need\n", err)
-		return
+		fatal("can't load config", "error", err,
+			"note", "try to copy config.http.json.template as config.http.json and adjust as you need")
+	}
+
+	logger, err := logging.NewLogger(os.Stderr, cfg.Log.Level, cfg.Log.Format); if err != nil {
+		fatal("can't create logger", "error", err)
 	}
+	slog.SetDefault(logger)
+	config.Logger = logger
+
+	// every background goroutine stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// called in order on shutdown, sharing drain timeout
+	shutdowns := []func(context.Context) error{}
 	listAddr := fmt.Sprintf("%s:%d", cfg.Listener.Address, cfg.Listener.Port)
 
-	maxReqInterval := time.Duration(cfg.Limiter.MaxRequestInterval) * time.Second
-	cleanupInterval := time.Duration(cfg.Limiter.CleanupOldRequestInterval) * time.Second
+	// rate string win over max_request_per_ip & max_request_interval
+	maxReq, maxReqInterval, err := cfg.Limiter.Policy(); if err != nil {
+		fatal("invalid limiter policy", "error", err)
+	}
+	cleanupInterval := time.Duration(cfg.Limiter.CleanupOldRequestInterval)
 
-	limiter := http_limiter.NewHttpRateLimiter(
-		uint(cfg.Limiter.MaxRequestPerIp), maxReqInterval)
+	limiter := http_limiter.NewHttpRateLimiter(maxReq, maxReqInterval)
 	middleware := &http_limiter.HttpMiddleware{Limiter: limiter}
 
+	// always set so reload can raise or lift the in-flight cap, 0 never reject
+	concurrency := gen.NewConcurrencyLimiter(cfg.Limiter.MaxInFlightPerIp, cfg.Limiter.MaxInFlight)
+	middleware.Concurrency = concurrency
+
+	queue := gen.NewWaitQueue(cfg.Limiter.QueueSize, time.Duration(cfg.Limiter.QueueMaxWait))
+	middleware.Queue = queue
+
+	costs := gen.NewCostTable(cfg.Limiter.Costs)
+	middleware.Costs = costs
+
+	// applied at start only, limit is learned at runtime anyway
+	if cfg.Adaptive.Enabled {
+		adaptive := gen.NewAdaptiveLimiter(cfg.Adaptive.MinLimit, cfg.Adaptive.MaxLimit,
+			time.Duration(cfg.Adaptive.TargetLatency))
+		adaptive.Backoff = cfg.Adaptive.Backoff
+		middleware.Adaptive = adaptive
+
+		if cfg.Adaptive.PriorityHeader != "" {
+			middleware.PriorityFunc = http_limiter.PriorityFromHeader(cfg.Adaptive.PriorityHeader)
+		}
+	}
+
+	// applied at start only, tenant class win over priority header, missing tenant header use client ip
+	if cfg.FairQueue.Enabled {
+		fair := gen.NewFairQueue(cfg.FairQueue.MaxInFlight, cfg.FairQueue.QueueSize,
+			time.Duration(cfg.FairQueue.MaxWait))
+		for class, weight := range cfg.FairQueue.Weights {
+			p, _ := gen.ParsePriority(class)
+			fair.Weights[p] = weight
+		}
+		middleware.Fair = fair
+
+		// adaptive priority header, if any, unless fair queue has its own
+		fallback := middleware.PriorityFunc
+		if cfg.FairQueue.PriorityHeader != "" {
+			fallback = http_limiter.PriorityFromHeader(cfg.FairQueue.PriorityHeader)
+		}
+
+		middleware.TenantFunc = http_limiter.TenantFromHeader(cfg.FairQueue.TenantHeader)
+
+		classes := make(map[string]gen.Priority, len(cfg.FairQueue.Tenants))
+		for tenant, class := range cfg.FairQueue.Tenants {
+			classes[tenant], _ = gen.ParsePriority(class)
+		}
+		middleware.PriorityFunc = http_limiter.PriorityByTenant(middleware.TenantFunc, classes, fallback)
+	}
+
+	access, err := cidr.NewAccessList(cfg.Access.Allow, cfg.Access.Deny,
+		cfg.Access.AllowFile, cfg.Access.DenyFile); if err != nil {
+			fatal("can't load access list", "error", err)
+		}
+	if err := access.TrustProxies(cfg.Access.TrustedProxies); err != nil {
+		fatal("can't load trusted proxies", "error", err)
+	}
+	middleware.Access = access
+
+	limiter.Logger = logger
+	access.Logger = logger
+	middleware.Observers = append(middleware.Observers, logging.NewRejectionLogger(logger,
+		time.Duration(cfg.Log.RejectionSampleInterval) * time.Second,
+		cfg.Log.RejectionSampleBurst))
+
+	if cfg.Ban.Threshold > 0 {
+		middleware.Ban = ban.NewBanBox(uint(cfg.Ban.Threshold),
+			time.Duration(cfg.Ban.Window) * time.Second,
+			time.Duration(cfg.Ban.BaseDuration) * time.Second,
+			cfg.Ban.Factor,
+			time.Duration(cfg.Ban.MaxDuration) * time.Second)
+
+		go ban.CleanupExpiredBan(ctx, middleware.Ban,
+			time.Duration(cfg.Ban.CleanupInterval) * time.Second)
+	}
+
+	if cfg.Metrics.Enabled {
+		registry := metrics.NewRegistry()
+		limiterMetrics := metrics.NewLimiterMetrics(registry)
+
+		limiterMetrics.TrackKeys("http", limiter.KeyCount)
+		if middleware.Ban != nil {
+			limiterMetrics.TrackBans("http", middleware.Ban.Count)
+		}
+		limiter.OnCleanup = limiterMetrics.ObserveCleanup("http")
+		middleware.Observers = append(middleware.Observers, limiterMetrics)
+
+		metricsAddr := fmt.Sprintf("%s:%d", cfg.Metrics.Address, cfg.Metrics.Port)
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle(cfg.Metrics.Path, registry.Handler())
+		metricsServer := &http.Server{Addr: metricsAddr, Handler: metricsMux}
+		shutdowns = append(shutdowns, metricsServer.Shutdown)
+
+		go func() {
+			logger.Info("run metrics server", "address", metricsAddr, "path", cfg.Metrics.Path)
+			if err := metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				fatal("metrics server stopped", "error", err)
+			}
+		}()
+	}
+
+	if cfg.Admin.Enabled {
+		adminAddr := fmt.Sprintf("%s:%d", cfg.Admin.Address, cfg.Admin.Port)
+		adminServer := &http.Server{
+			Addr: adminAddr,
+			Handler: admin.NewAdminServer(cfg.Admin.Token, limiter, middleware.Ban).Handler(),
+		}
+
+		shutdowns = append(shutdowns, adminServer.Shutdown)
+
+		go func() {
+			logger.Info("run admin server", "address", adminAddr)
+			if err := adminServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				fatal("admin server stopped", "error", err)
+			}
+		}()
+	}
+
+	// body is paced after the request pass the limiter, nil side is unlimited
+	bandwidth := http_limiter.NewBandwidthMiddleware(nil, nil)
+	if cfg.Bandwidth.UploadPerIp > 0 || cfg.Bandwidth.Upload > 0 {
+		bandwidth.Upload = gen.NewBandwidthLimiter(int64(cfg.Bandwidth.UploadPerIp), int64(cfg.Bandwidth.Upload))
+		go gen.CleanupIdleBandwidth(ctx, bandwidth.Upload, cleanupInterval)
+	}
+	if cfg.Bandwidth.DownloadPerIp > 0 || cfg.Bandwidth.Download > 0 {
+		bandwidth.Download = gen.NewBandwidthLimiter(int64(cfg.Bandwidth.DownloadPerIp), int64(cfg.Bandwidth.Download))
+		go gen.CleanupIdleBandwidth(ctx, bandwidth.Download, cleanupInterval)
+	}
+
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("/", middleware.Limit(handlerHome))
+	mux.HandleFunc("/", middleware.Limit(bandwidth.Limit(handlerHome)))
+
+	// decision only endpoint, count on the same limiter as the main handler
+	if cfg.Authz.Enabled {
+		authz := http_limiter.NewAuthzHandler(middleware, cfg.Authz.Path)
+		authz.RejectStatus = cfg.Authz.RejectStatus
+		if err := authz.SetRoutes(cfg.Authz.Routes); err != nil {
+			fatal("can't load authz routes", "error", err)
+		}
+
+		mux.Handle(cfg.Authz.Path, authz)
+		mux.Handle(cfg.Authz.Path + "/", authz)
+	}
+
+	var handler http.Handler = mux
 
-	go http_limiter.CleanupOldRequest(limiter, cleanupInterval)
+	if cfg.Tracing.Enabled {
+		tp, err := tracing.NewProvider(ctx, cfg.Tracing.Endpoint,
+			cfg.Tracing.Insecure, cfg.Tracing.SampleRatio, cfg.Tracing.ServiceName); if err != nil {
+				fatal("can't create tracer provider", "error", err)
+			}
+		shutdowns = append(shutdowns, tp.Shutdown)
+
+		middleware.Observers = append(middleware.Observers, tracing.NewDecisionTracer())
+		handler = tracing.HttpServerSpan(tp, mux)
+	}
+
+	go http_limiter.CleanupOldRequest(ctx, limiter, cleanupInterval)
+
+	// limiter policy reload on config file change or SIGHUP, tracked request is kept
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	go config.WatchConfig(ctx, *configPath,
+		time.Duration(cfg.Limiter.ReloadInterval), hup,
+		func(content []byte) error {
+			next, err := config.ConfigServerHttpParse(content, configFormat); if err != nil {
+				return err
+			}
+
+			maxReq, duration, err := next.Limiter.Policy(); if err != nil {
+				return err
+			}
+
+			limiter.UpdatePolicy(maxReq, duration)
+			concurrency.UpdatePolicy(next.Limiter.MaxInFlightPerIp, next.Limiter.MaxInFlight)
+			queue.UpdatePolicy(next.Limiter.QueueSize, time.Duration(next.Limiter.QueueMaxWait))
+			costs.Update(next.Limiter.Costs)
+			logger.Info("limiter policy updated", "max_request", maxReq, "duration", duration,
+				"max_in_flight_per_ip", next.Limiter.MaxInFlightPerIp, "max_in_flight", next.Limiter.MaxInFlight)
+
+			return nil
+		})
+
+	if cfg.Access.ReloadInterval > 0 {
+		go cidr.WatchAccessList(ctx, access,
+			time.Duration(cfg.Access.ReloadInterval) * time.Second)
+	}
 
 	server := &http.Server{
 		Addr: listAddr,
-		Handler: mux,
+		Handler: handler,
 		IdleTimeout: time.Duration(cfg.Server.IdleTimeout) * time.Second,
 		ReadTimeout: time.Duration(cfg.Server.ReadTimeout) * time.Second,
 		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
 	}
 
-	log.Printf("INFO: run http server on %s\n", listAddr)
-	log.Fatal(server.ListenAndServe())
+	if cfg.ConnLimit.MaxConcurrentStreams > 0 {
+		server.HTTP2 = &http.HTTP2Config{MaxConcurrentStreams: int(cfg.ConnLimit.MaxConcurrentStreams)}
+	}
+
+	var lis net.Listener
+	lis, err = net.Listen("tcp", listAddr); if err != nil {
+		fatal("can't listen", "error", err)
+	}
+
+	// idle connection is counted per ip before any request is read
+	if cfg.ConnLimit.MaxConn > 0 || cfg.ConnLimit.MaxConnPerIp > 0 || cfg.ConnLimit.ConnRatePerIp.IsSet() {
+		connLimited := listener.NewListener(lis, cfg.ConnLimit.MaxConn, cfg.ConnLimit.MaxConnPerIp, nil)
+		connLimited.Observers = middleware.Observers
+
+		if cfg.ConnLimit.ConnRatePerIp.IsSet() {
+			maxConn, window := cfg.ConnLimit.ConnRatePerIp.Window()
+			connRate := http_limiter.NewHttpRateLimiter(maxConn, window)
+			connLimited.Rate = connRate
+
+			go http_limiter.CleanupOldRequest(ctx, connRate, cleanupInterval)
+		}
+
+		lis = connLimited
+	}
+
+	// main server drain first, then the rest
+	shutdowns = append([]func(context.Context) error{server.Shutdown}, shutdowns...)
+
+	go func() {
+		logger.Info("run http server", "address", listAddr)
+		if err := server.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+			fatal("http server stopped", "error", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+
+	shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeout) * time.Second
+	logger.Info("shutting down", "timeout", shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, shutdown := range shutdowns {
+		if err := shutdown(shutdownCtx); err != nil {
+			logger.Warn("shutdown incomplete", "error", err)
+		}
+	}
+
+	logger.Info("http server stopped")
 }
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	admin "github.com/prothegee/network-limiter-go/pkg/admin"
+	ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	cidr "github.com/prothegee/network-limiter-go/pkg/cidr"
+	config "github.com/prothegee/network-limiter-go/pkg/config"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	logging "github.com/prothegee/network-limiter-go/pkg/logging"
+	metrics "github.com/prothegee/network-limiter-go/pkg/metrics"
+	tracing "github.com/prothegee/network-limiter-go/pkg/tracing"
+	unified "github.com/prothegee/network-limiter-go/pkg/unified"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// --------------------------------------------------------- //
+
+type responseHome struct {
+	Protocol string `json:"protocol"`
+	XRealIp string `json:"x_real_ip"`
+	XForwardedFor string `json:"x_forwarded_for"`
+}
+
+func handlerHome(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	resp := responseHome{
+		Protocol: r.Proto,
+		XRealIp: r.Header.Get("X-Real-IP"),
+		XForwardedFor: r.Header.Get("X-Forwarded-For"),
+	}
+
+	err := json.NewEncoder(w).Encode(resp); if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// --------------------------------------------------------- //
+
+type Server struct {
+	pb.UnimplementedLocationServer
+}
+
+func (s *Server) SendLocationAndSave(ctx context.Context,
+									 req *pb.LocationReq) (*pb.LocationResp, error) {
+	reqMsg := strings.ToLower(req.GetMessage())
+
+	return &pb.LocationResp{
+		Ok: reqMsg != "bad",
+		Message: fmt.Sprintf("\"%s\" grpc; long: %f; lat: %f", reqMsg, req.Long, req.Lat),
+	}, nil
+}
+
+// --------------------------------------------------------- //
+
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+func main() {
+	configPath := flag.String("config", "../../config.unified.json",
+		"config file path, format is detected from .json, .yaml, .yml or .toml extension")
+	flag.Parse()
+
+	configFormat, err := config.FormatFromPath(*configPath); if err != nil {
+		fatal("can't load config", "error", err)
+	}
+
+	cfg, err := config.ConfigServerUnifiedLoad(*configPath)
+	if err != nil {
+		fatal("can't load config", "error", err,
+			"note", "try to copy config.unified.json.template as config.unified.json and adjust as you need")
+	}
+
+	logger, err := logging.NewLogger(os.Stderr, cfg.Log.Level, cfg.Log.Format); if err != nil {
+		fatal("can't create logger", "error", err)
+	}
+	slog.SetDefault(logger)
+	config.Logger = logger
+
+	// every background goroutine stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// called in order on shutdown, sharing drain timeout
+	shutdowns := []func(context.Context) error{}
+
+	// rate string win over max_request_per_ip & max_request_interval
+	maxReq, maxReqInterval, err := cfg.Limiter.Policy(); if err != nil {
+		fatal("invalid limiter policy", "error", err)
+	}
+	cleanupInterval := time.Duration(cfg.Limiter.CleanupOldRequestInterval)
+
+	// with share_limiter, grpc count on http limiter, so one quota per ip cover both protocol
+	httpLimiter := http_limiter.NewHttpRateLimiter(maxReq, maxReqInterval)
+	httpLimiter.Logger = logger
+	httpMiddleware := &http_limiter.HttpMiddleware{Limiter: httpLimiter}
+
+	// in-flight cap follow the same sharing, always set so reload can raise or lift it
+	httpConcurrency := gen.NewConcurrencyLimiter(cfg.Limiter.MaxInFlightPerIp, cfg.Limiter.MaxInFlight)
+	httpMiddleware.Concurrency = httpConcurrency
+
+	// waiting request per ip is bounded across both protocol
+	queue := gen.NewWaitQueue(cfg.Limiter.QueueSize, time.Duration(cfg.Limiter.QueueMaxWait))
+	httpMiddleware.Queue = queue
+
+	// route pattern & full method never collide, so one table serve both protocol
+	costs := gen.NewCostTable(cfg.Limiter.Costs)
+	httpMiddleware.Costs = costs
+
+	var grpcLimiter *grpc_limiter.GrpcRateLimiter
+	var grpcConcurrency *gen.ConcurrencyLimiter
+	grpcMiddleware := grpc_limiter.NewGrpcMiddleware(nil)
+	grpcMiddleware.Queue = queue
+	grpcMiddleware.Costs = costs
+	if cfg.ShareLimiter {
+		grpcMiddleware.Shared = httpLimiter
+		grpcMiddleware.Concurrency = httpConcurrency
+	} else {
+		grpcLimiter = grpc_limiter.NewGrpcRateLimiter(maxReq, maxReqInterval)
+		grpcLimiter.Logger = logger
+		grpcMiddleware.Limiter = grpcLimiter
+
+		grpcConcurrency = gen.NewConcurrencyLimiter(cfg.Limiter.MaxInFlightPerIp, cfg.Limiter.MaxInFlight)
+		grpcMiddleware.Concurrency = grpcConcurrency
+	}
+
+	access, err := cidr.NewAccessList(cfg.Access.Allow, cfg.Access.Deny,
+		cfg.Access.AllowFile, cfg.Access.DenyFile); if err != nil {
+			fatal("can't load access list", "error", err)
+		}
+	if err := access.TrustProxies(cfg.Access.TrustedProxies); err != nil {
+		fatal("can't load trusted proxies", "error", err)
+	}
+	access.Logger = logger
+
+	// access list, ban box and observer is shared by both protocol
+	observers := []gen.DecisionObserver{logging.NewRejectionLogger(logger,
+		time.Duration(cfg.Log.RejectionSampleInterval) * time.Second,
+		cfg.Log.RejectionSampleBurst)}
+
+	var box *ban.BanBox
+	if cfg.Ban.Threshold > 0 {
+		box = ban.NewBanBox(uint(cfg.Ban.Threshold),
+			time.Duration(cfg.Ban.Window) * time.Second,
+			time.Duration(cfg.Ban.BaseDuration) * time.Second,
+			cfg.Ban.Factor,
+			time.Duration(cfg.Ban.MaxDuration) * time.Second)
+
+		go ban.CleanupExpiredBan(ctx, box,
+			time.Duration(cfg.Ban.CleanupInterval) * time.Second)
+	}
+
+	if cfg.Metrics.Enabled {
+		registry := metrics.NewRegistry()
+		limiterMetrics := metrics.NewLimiterMetrics(registry)
+
+		if cfg.ShareLimiter {
+			limiterMetrics.TrackKeys("shared", httpLimiter.KeyCount)
+			httpLimiter.OnCleanup = limiterMetrics.ObserveCleanup("shared")
+		} else {
+			limiterMetrics.TrackKeys("http", httpLimiter.KeyCount)
+			limiterMetrics.TrackKeys("grpc", grpcLimiter.KeyCount)
+			httpLimiter.OnCleanup = limiterMetrics.ObserveCleanup("http")
+			grpcLimiter.OnCleanup = limiterMetrics.ObserveCleanup("grpc")
+		}
+		if box != nil {
+			limiterMetrics.TrackBans("unified", box.Count)
+		}
+		observers = append(observers, limiterMetrics)
+
+		metricsAddr := fmt.Sprintf("%s:%d", cfg.Metrics.Address, cfg.Metrics.Port)
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle(cfg.Metrics.Path, registry.Handler())
+		metricsServer := &http.Server{Addr: metricsAddr, Handler: metricsMux}
+		shutdowns = append(shutdowns, metricsServer.Shutdown)
+
+		go func() {
+			logger.Info("run metrics server", "address", metricsAddr, "path", cfg.Metrics.Path)
+			if err := metricsServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				fatal("metrics server stopped", "error", err)
+			}
+		}()
+	}
+
+	// admin http api manage http limiter, which is the shared one with share_limiter
+	if cfg.Admin.Enabled {
+		adminAddr := fmt.Sprintf("%s:%d", cfg.Admin.Address, cfg.Admin.Port)
+		adminServer := &http.Server{
+			Addr: adminAddr,
+			Handler: admin.NewAdminServer(cfg.Admin.Token, httpLimiter, box).Handler(),
+		}
+
+		shutdowns = append(shutdowns, adminServer.Shutdown)
+
+		go func() {
+			logger.Info("run admin server", "address", adminAddr)
+			if err := adminServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				fatal("admin server stopped", "error", err)
+			}
+		}()
+	}
+
+	mux := http.NewServeMux()
+	var httpHandler http.Handler = mux
+	interceptors := []grpc.UnaryServerInterceptor{}
+
+	if cfg.Tracing.Enabled {
+		tp, err := tracing.NewProvider(ctx, cfg.Tracing.Endpoint,
+			cfg.Tracing.Insecure, cfg.Tracing.SampleRatio, cfg.Tracing.ServiceName); if err != nil {
+				fatal("can't create tracer provider", "error", err)
+			}
+		shutdowns = append(shutdowns, tp.Shutdown)
+
+		observers = append(observers, tracing.NewDecisionTracer())
+		httpHandler = tracing.HttpServerSpan(tp, mux)
+		interceptors = append(interceptors, tracing.UnaryServerSpan(tp))
+	}
+
+	httpMiddleware.Access, grpcMiddleware.Access = access, access
+	httpMiddleware.Ban, grpcMiddleware.Ban = box, box
+	httpMiddleware.Observers, grpcMiddleware.Observers = observers, observers
+
+	mux.HandleFunc("/", httpMiddleware.Limit(handlerHome))
+
+	// admin call is token protected, banned or limited operator must still reach unban & reset
+	if cfg.Admin.GrpcService {
+		grpcMiddleware.Exempt = append(grpcMiddleware.Exempt, pb.RATE_LIMITER_ADMIN_SERVICE)
+	}
+
+	interceptors = append(interceptors, grpcMiddleware.Limit())
+
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(interceptors...),
+	)
+
+	pb.RegisterLocationServer(grpcServer, &Server{})
+
+	if cfg.Admin.GrpcService {
+		pb.RegisterRateLimiterAdminServer(grpcServer,
+			grpc_limiter.NewGrpcAdminServer(cfg.Admin.Token, grpcLimiter, box))
+	}
+
+	reflection.Register(grpcServer)
+
+	go http_limiter.CleanupOldRequest(ctx, httpLimiter, cleanupInterval)
+	if grpcLimiter != nil {
+		go grpc_limiter.CleanupOldRequest(ctx, grpcLimiter, cleanupInterval)
+	}
+
+	// limiter policy reload on config file change or SIGHUP, tracked request is kept
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	go config.WatchConfig(ctx, *configPath,
+		time.Duration(cfg.Limiter.ReloadInterval), hup,
+		func(content []byte) error {
+			next, err := config.ConfigServerUnifiedParse(content, configFormat); if err != nil {
+				return err
+			}
+
+			maxReq, duration, err := next.Limiter.Policy(); if err != nil {
+				return err
+			}
+
+			httpLimiter.UpdatePolicy(maxReq, duration)
+			httpConcurrency.UpdatePolicy(next.Limiter.MaxInFlightPerIp, next.Limiter.MaxInFlight)
+			queue.UpdatePolicy(next.Limiter.QueueSize, time.Duration(next.Limiter.QueueMaxWait))
+			costs.Update(next.Limiter.Costs)
+			if grpcLimiter != nil {
+				grpcLimiter.UpdatePolicy(maxReq, duration)
+				grpcConcurrency.UpdatePolicy(next.Limiter.MaxInFlightPerIp, next.Limiter.MaxInFlight)
+			}
+			logger.Info("limiter policy updated", "max_request", maxReq, "duration", duration,
+				"max_in_flight_per_ip", next.Limiter.MaxInFlightPerIp, "max_in_flight", next.Limiter.MaxInFlight)
+
+			return nil
+		})
+
+	if cfg.Access.ReloadInterval > 0 {
+		go cidr.WatchAccessList(ctx, access,
+			time.Duration(cfg.Access.ReloadInterval) * time.Second)
+	}
+
+	listAddr := fmt.Sprintf("%s:%d", cfg.Listener.Address, cfg.Listener.Port)
+	server := &http.Server{
+		Addr: listAddr,
+		Handler: httpHandler,
+		IdleTimeout: time.Duration(cfg.Server.IdleTimeout) * time.Second,
+		ReadTimeout: time.Duration(cfg.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cfg.Server.WriteTimeout) * time.Second,
+	}
+
+	if cfg.Multiplexed() {
+		// grpc ride on http port as h2c, routed by content-type
+		server.Handler = unified.Handler(grpcServer, httpHandler)
+		server.Protocols = unified.Protocols()
+	} else {
+		grpcAddr, err := net.Listen("tcp",
+			fmt.Sprintf("%s:%d", cfg.GrpcListener.Address, cfg.GrpcListener.Port)); if err != nil {
+				fatal("can't listen", "error", err)
+			}
+
+		// force stop when drain timeout reached
+		shutdowns = append([]func(context.Context) error{func(ctx context.Context) error {
+			stopped := make(chan struct{})
+			go func() {
+				grpcServer.GracefulStop()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				grpcServer.Stop()
+				return ctx.Err()
+			}
+		}}, shutdowns...)
+
+		go func() {
+			logger.Info("run grpc server", "address", grpcAddr.Addr().String())
+			if err := grpcServer.Serve(grpcAddr); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				fatal("grpc server stopped", "error", err)
+			}
+		}()
+	}
+
+	// main server drain first, then the rest
+	shutdowns = append([]func(context.Context) error{server.Shutdown}, shutdowns...)
+
+	go func() {
+		logger.Info("run unified server", "address", listAddr,
+			"grpc_multiplexed", cfg.Multiplexed(), "share_limiter", cfg.ShareLimiter)
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			fatal("unified server stopped", "error", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+
+	shutdownTimeout := time.Duration(cfg.Server.ShutdownTimeout) * time.Second
+	logger.Info("shutting down", "timeout", shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	for _, shutdown := range shutdowns {
+		if err := shutdown(shutdownCtx); err != nil {
+			logger.Warn("shutdown incomplete", "error", err)
+		}
+	}
+
+	logger.Info("unified server stopped")
+}
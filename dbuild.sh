@@ -11,6 +11,10 @@ export SERVER_GRPC_SOURCE="$(pwd)/cmd/server_grpc";
 export SERVER_GRPC_TARGET="$TARGET_DIR/server_grpc/main";
 export SERVER_NETHTTP_SOURCE="$(pwd)/cmd/server_nethttp";
 export SERVER_NETHTTP_TARGET="$TARGET_DIR/server_nethttp/main";
+export SERVER_UNIFIED_SOURCE="$(pwd)/cmd/server_unified";
+export SERVER_UNIFIED_TARGET="$TARGET_DIR/server_unified/main";
+export SERVER_GATEWAY_SOURCE="$(pwd)/cmd/server_gateway";
+export SERVER_GATEWAY_TARGET="$TARGET_DIR/server_gateway/main";
 
 echo "building: $SERVER_GRPC_SOURCE";
 echo "- target: $SERVER_GRPC_TARGET"
@@ -20,3 +24,11 @@ echo "building: $SERVER_NETHTTP_SOURCE";
 echo "- target: $SERVER_NETHTTP_TARGET";
 go build -o $SERVER_NETHTTP_TARGET $SERVER_NETHTTP_SOURCE;
 
+echo "building: $SERVER_UNIFIED_SOURCE";
+echo "- target: $SERVER_UNIFIED_TARGET";
+go build -o $SERVER_UNIFIED_TARGET $SERVER_UNIFIED_SOURCE;
+
+echo "building: $SERVER_GATEWAY_SOURCE";
+echo "- target: $SERVER_GATEWAY_TARGET";
+go build -o $SERVER_GATEWAY_TARGET $SERVER_GATEWAY_SOURCE;
+
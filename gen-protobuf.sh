@@ -10,3 +10,14 @@ protoc "$PROTO_DIR/location.proto" \
     --go-grpc_out="$PWD" \
     --proto_path="$PROTO_DIR";
 
+# admin.proto
+protoc "$PROTO_DIR/admin.proto" \
+    --go_out="$PWD" \
+    --go-grpc_out="$PWD" \
+    --proto_path="$PROTO_DIR";
+
+# ratelimit.proto
+protoc "$PROTO_DIR/ratelimit.proto" \
+    --go_out="$PWD" \
+    --go-grpc_out="$PWD" \
+    --proto_path="$PROTO_DIR";
@@ -0,0 +1,204 @@
+package pkg_admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	pkg_ban "github.com/prothegee/network-limiter-go/pkg/ban"
+)
+
+// @brief limiter state operation needed by admin server
+//
+// @note both HttpRateLimiter and GrpcRateLimiter satisfy this
+type Limiter interface {
+	ListKeys() []gen.KeyState
+	GetKeyState(key string) (gen.KeyState, bool)
+	ResetIP(key string)
+	ResetPrefix(prefix string) int
+}
+
+type AdminServer struct {
+	Token string
+	Limiter Limiter
+	Ban *pkg_ban.BanBox // optional, nil when ban is disabled
+}
+
+type banReq struct {
+	Key string `json:"key"`
+	Duration int `json:"duration"` // second, 0 to use escalating duration
+}
+
+type banResp struct {
+	Key string `json:"key"`
+	Until time.Time `json:"until"`
+	Offence int `json:"offence"`
+}
+
+type resetResp struct {
+	Reset int `json:"reset"`
+}
+
+// @brief create new admin server for inspecting and resetting limiter state
+//
+// @param token string - required bearer token, empty token reject every request
+//
+// @param limiter Limiter
+//
+// @param box *pkg_ban.BanBox - nil when ban is disabled
+//
+// @return *AdminServer
+func NewAdminServer(token string, limiter Limiter, box *pkg_ban.BanBox) *AdminServer {
+	return &AdminServer{
+		Token: token,
+		Limiter: limiter,
+		Ban: box,
+	}
+}
+
+// @brief admin endpoint, mountable into any mux
+//
+// @note endpoints:
+//
+// - GET /admin/keys?prefix= - list tracked key
+//
+// - GET /admin/keys/{key} - get one key state
+//
+// - DELETE /admin/keys/{key} - reset one key
+//
+// - DELETE /admin/keys?prefix= - reset every key starting with prefix
+//
+// - GET /admin/bans - list active ban
+//
+// - POST /admin/bans - ban key, body {"key": "", "duration": 0}
+//
+// - DELETE /admin/bans/{key} - lift ban
+func (a *AdminServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /admin/keys", a.handlerListKeys)
+	mux.HandleFunc("GET /admin/keys/{key}", a.handlerGetKey)
+	mux.HandleFunc("DELETE /admin/keys", a.handlerResetPrefix)
+	mux.HandleFunc("DELETE /admin/keys/{key}", a.handlerResetKey)
+	mux.HandleFunc("GET /admin/bans", a.handlerListBans)
+	mux.HandleFunc("POST /admin/bans", a.handlerBan)
+	mux.HandleFunc("DELETE /admin/bans/{key}", a.handlerUnban)
+
+	return a.auth(mux)
+}
+
+func (a *AdminServer) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		if !ok || len(a.Token) <= 0 ||
+			subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (a *AdminServer) handlerListKeys(w http.ResponseWriter, r *http.Request) {
+	prefix := r.URL.Query().Get("prefix")
+
+	states := []gen.KeyState{}
+	for _, state := range a.Limiter.ListKeys() {
+		if strings.HasPrefix(state.Key, prefix) {
+			states = append(states, state)
+		}
+	}
+
+	writeJson(w, http.StatusOK, states)
+}
+
+func (a *AdminServer) handlerGetKey(w http.ResponseWriter, r *http.Request) {
+	state, ok := a.Limiter.GetKeyState(r.PathValue("key")); if !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	writeJson(w, http.StatusOK, state)
+}
+
+func (a *AdminServer) handlerResetKey(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+
+	if _, ok := a.Limiter.GetKeyState(key); !ok {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	a.Limiter.ResetIP(key)
+
+	writeJson(w, http.StatusOK, resetResp{Reset: 1})
+}
+
+func (a *AdminServer) handlerResetPrefix(w http.ResponseWriter, r *http.Request) {
+	// empty prefix would reset everything, make it explicit
+	if !r.URL.Query().Has("prefix") {
+		http.Error(w, "Bad Request; prefix Query Required", http.StatusBadRequest)
+		return
+	}
+
+	writeJson(w, http.StatusOK, resetResp{
+		Reset: a.Limiter.ResetPrefix(r.URL.Query().Get("prefix")),
+	})
+}
+
+func (a *AdminServer) handlerListBans(w http.ResponseWriter, r *http.Request) {
+	if a.Ban == nil {
+		http.Error(w, "Not Implemented; Ban Disabled", http.StatusNotImplemented)
+		return
+	}
+
+	bans := []banResp{}
+	for _, ban := range a.Ban.List() {
+		bans = append(bans, banResp(ban))
+	}
+
+	writeJson(w, http.StatusOK, bans)
+}
+
+func (a *AdminServer) handlerBan(w http.ResponseWriter, r *http.Request) {
+	if a.Ban == nil {
+		http.Error(w, "Not Implemented; Ban Disabled", http.StatusNotImplemented)
+		return
+	}
+
+	var req banReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Key) <= 0 || req.Duration < 0 {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	ban := a.Ban.Ban(req.Key, time.Duration(req.Duration) * time.Second)
+
+	writeJson(w, http.StatusCreated, banResp(ban))
+}
+
+func (a *AdminServer) handlerUnban(w http.ResponseWriter, r *http.Request) {
+	if a.Ban == nil {
+		http.Error(w, "Not Implemented; Ban Disabled", http.StatusNotImplemented)
+		return
+	}
+
+	if !a.Ban.Lift(r.PathValue("key")) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJson(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
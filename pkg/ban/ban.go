@@ -0,0 +1,288 @@
+package pkg_ban
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+)
+
+type Ban struct {
+	Key string
+	Until time.Time
+	Offence int // how many times key got banned, 1 is first
+}
+
+type offence struct {
+	Count int
+	LastUntil time.Time
+}
+
+type BanBox struct {
+	Mtx sync.RWMutex
+	Bans map[string]Ban
+	Strikes map[string][]time.Time // rejection timestamp per key
+	Offences map[string]offence
+	Threshold uint
+	Window time.Duration
+	BaseDuration time.Duration
+	Factor float64
+	MaxDuration time.Duration
+	Clock gen.Clock // optional, nil use gen.SystemClock
+}
+
+// @brief create new fail2ban like ban box
+//
+// @param threshold uint - rejection number inside window before key get banned
+//
+// @param window time.Duration - window time duration for counting rejection
+//
+// @param base time.Duration - first ban duration
+//
+// @param factor float64 - multiplier for each repeated offence, e.g. 10 for 1m, 10m, 100m
+//
+// @param max time.Duration - cap of ban duration
+//
+// @param opts ...gen.Option - optional setting, e.g. gen.WithClock
+//
+// @return *BanBox
+func NewBanBox(threshold uint, window, base time.Duration, factor float64, max time.Duration, opts ...gen.Option) *BanBox {
+	o := gen.ApplyOptions(opts...)
+
+	if factor < 1 {
+		factor = 1
+	}
+	if max < base {
+		max = base
+	}
+
+	return &BanBox{
+		Bans: make(map[string]Ban),
+		Strikes: make(map[string][]time.Time),
+		Offences: make(map[string]offence),
+		Threshold: threshold,
+		Window: window,
+		BaseDuration: base,
+		Factor: factor,
+		MaxDuration: max,
+		Clock: o.Clock,
+	}
+}
+
+// @return time.Time - current time from ban box clock
+func (b *BanBox) Now() time.Time {
+	if b == nil {
+		return time.Now()
+	}
+
+	return gen.OrSystemClock(b.Clock).Now()
+}
+
+// @brief check whether key is currently banned
+//
+// @note nil ban box never ban
+//
+// @return time.Time, bool - ban expiry and banned state
+func (b *BanBox) IsBanned(key string) (time.Time, bool) {
+	if b == nil {
+		return time.Time{}, false
+	}
+
+	b.Mtx.RLock()
+	ban, ok := b.Bans[key]
+	b.Mtx.RUnlock()
+
+	if !ok || !b.Now().Before(ban.Until) {
+		return time.Time{}, false
+	}
+
+	return ban.Until, true
+}
+
+// @brief record limiter rejection for key, ban it when threshold reached
+//
+// @return Ban, bool - new ban and whether key just got banned
+func (b *BanBox) RecordRejection(key string) (Ban, bool) {
+	if b == nil || b.Threshold == 0 {
+		return Ban{}, false
+	}
+
+	b.Mtx.Lock()
+	defer b.Mtx.Unlock()
+
+	now := b.Now()
+
+	validStrikes := []time.Time{now}
+	for _, t := range b.Strikes[key] {
+		if now.Sub(t) <= b.Window {
+			validStrikes = append(validStrikes, t)
+		}
+	}
+
+	if len(validStrikes) < int(b.Threshold) {
+		b.Strikes[key] = validStrikes
+		return Ban{}, false
+	}
+
+	delete(b.Strikes, key)
+
+	return b.ban(key, now, 0), true
+}
+
+// @brief ban key manually
+//
+// @param d time.Duration - 0 to use escalating duration
+func (b *BanBox) Ban(key string, d time.Duration) Ban {
+	b.Mtx.Lock()
+	defer b.Mtx.Unlock()
+
+	return b.ban(key, b.Now(), d)
+}
+
+// @brief lift ban and forget offence history of key
+//
+// @return bool - false if key wasn't banned
+func (b *BanBox) Lift(key string) bool {
+	b.Mtx.Lock()
+	defer b.Mtx.Unlock()
+
+	_, ok := b.Bans[key]
+
+	delete(b.Bans, key)
+	delete(b.Strikes, key)
+	delete(b.Offences, key)
+
+	return ok
+}
+
+// @brief list active ban sorted by key
+func (b *BanBox) List() []Ban {
+	b.Mtx.RLock()
+	defer b.Mtx.RUnlock()
+
+	now := b.Now()
+	bans := []Ban{}
+	for _, ban := range b.Bans {
+		if now.Before(ban.Until) {
+			bans = append(bans, ban)
+		}
+	}
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].Key < bans[j].Key
+	})
+
+	return bans
+}
+
+// @return int - number of active ban
+func (b *BanBox) Count() int {
+	b.Mtx.RLock()
+	defer b.Mtx.RUnlock()
+
+	now := b.Now()
+	count := 0
+	for _, ban := range b.Bans {
+		if now.Before(ban.Until) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// @brief ban duration for n-th offence, 1 is first
+func (b *BanBox) Duration(n int) time.Duration {
+	if n < 1 {
+		n = 1
+	}
+
+	d := float64(b.BaseDuration) * math.Pow(b.Factor, float64(n-1))
+	if d >= float64(b.MaxDuration) {
+		return b.MaxDuration
+	}
+
+	return time.Duration(d)
+}
+
+func (b *BanBox) ban(key string, now time.Time, d time.Duration) Ban {
+	off := b.Offences[key]
+	off.Count++
+
+	if d <= 0 {
+		d = b.Duration(off.Count)
+	}
+
+	ban := Ban{
+		Key: key,
+		Until: now.Add(d),
+		Offence: off.Count,
+	}
+
+	off.LastUntil = ban.Until
+	b.Offences[key] = off
+	b.Bans[key] = ban
+
+	return ban
+}
+
+// @brief remove expired ban, old strike and forgotten offence
+//
+// @note offence is forgotten after max duration passed since last ban expiry
+//
+// @param ctx context.Context - cancel to stop the loop
+//
+// @param b *BanBox
+//
+// @param d time.Duration
+//
+// @param opts ...gen.Option - optional setting, clock default to ban box clock
+func CleanupExpiredBan(ctx context.Context, b *BanBox, d time.Duration, opts ...gen.Option) {
+	clock := gen.ApplyOptions(opts...).ClockOr(b.Clock)
+
+	ticker := clock.NewTicker(d)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+
+		b.Mtx.Lock()
+		now := clock.Now()
+
+		for key, ban := range b.Bans {
+			if !now.Before(ban.Until) {
+				delete(b.Bans, key)
+			}
+		}
+
+		for key, strikes := range b.Strikes {
+			validStrikes := []time.Time{}
+			for _, t := range strikes {
+				if now.Sub(t) <= b.Window {
+					validStrikes = append(validStrikes, t)
+				}
+			}
+
+			if len(validStrikes) == 0 {
+				delete(b.Strikes, key)
+			} else {
+				b.Strikes[key] = validStrikes
+			}
+		}
+
+		for key, off := range b.Offences {
+			if now.Sub(off.LastUntil) > b.MaxDuration {
+				delete(b.Offences, key)
+			}
+		}
+
+		b.Mtx.Unlock()
+	}
+}
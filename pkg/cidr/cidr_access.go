@@ -0,0 +1,337 @@
+package pkg_cidr
+
+import (
+	"context"
+	"bufio"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	pkg_logging "github.com/prothegee/network-limiter-go/pkg/logging"
+)
+
+type Decision int
+
+const (
+	DecisionNone Decision = iota // not listed, go through limiter
+	DecisionAllow // exempt from limiter
+	DecisionDeny // reject outright
+)
+
+type AccessList struct {
+	Mtx sync.RWMutex
+	Allow *Trie
+	Deny *Trie
+	AllowList []string // inline entries, kept on reload
+	DenyList []string // inline entries, kept on reload
+	AllowFile string
+	DenyFile string
+	Trusted *Trie // proxy allowed to set forwarding header, nil trust none
+	TrustedList []string // inline entries, kept on reload
+	Logger *slog.Logger // optional, nil use slog.Default
+	modTimes map[string]time.Time
+	reloadMtx sync.Mutex // one rebuild at a time, so older list never overwrite newer one
+}
+
+// @brief create new allow/deny access list
+//
+// @param allow []string - cidr or plain ip exempt from limiter
+//
+// @param deny []string - cidr or plain ip rejected outright
+//
+// @param allowFile string - optional file, one cidr per line, "" to skip
+//
+// @param denyFile string - optional file, one cidr per line, "" to skip
+//
+// @return *AccessList, error
+func NewAccessList(allow, deny []string, allowFile, denyFile string) (*AccessList, error) {
+	list := &AccessList{
+		AllowList: allow,
+		DenyList: deny,
+		AllowFile: allowFile,
+		DenyFile: denyFile,
+		modTimes: make(map[string]time.Time),
+	}
+
+	if err := list.Reload(); err != nil {
+		return nil, err
+	}
+
+	// remember current version, so watcher only reload on change
+	for _, fp := range []string{allowFile, denyFile} {
+		if info, err := os.Stat(fp); err == nil {
+			list.modTimes[fp] = info.ModTime()
+		}
+	}
+
+	return list, nil
+}
+
+// @brief re-read allow/deny file and swap tries at once
+//
+// @note on error old tries stay in place
+func (a *AccessList) Reload() error {
+	a.reloadMtx.Lock()
+	defer a.reloadMtx.Unlock()
+
+	a.Mtx.RLock()
+	allowList, allowFile := a.AllowList, a.AllowFile
+	denyList, denyFile := a.DenyList, a.DenyFile
+	trustedList := a.TrustedList
+	a.Mtx.RUnlock()
+
+	allow, err := buildTrie(allowList, allowFile); if err != nil {
+		return err
+	}
+
+	deny, err := buildTrie(denyList, denyFile); if err != nil {
+		return err
+	}
+
+	trusted, err := buildTrie(trustedList, ""); if err != nil {
+		return err
+	}
+
+	a.Mtx.Lock()
+	a.Allow = allow
+	a.Deny = deny
+	a.Trusted = trusted
+	a.Mtx.Unlock()
+
+	return nil
+}
+
+// @brief decide what to do with client ip
+//
+// @note the most specific network win, deny win on same length
+//
+// @param ip string - client ip, "ip:port" or "ip, proxy" also accepted
+//
+// @return Decision - DecisionNone if nil list or ip can't be parsed
+func (a *AccessList) Check(ip string) Decision {
+	if a == nil {
+		return DecisionNone
+	}
+
+	addr, ok := ParseClientIP(ip); if !ok {
+		return DecisionNone
+	}
+
+	a.Mtx.RLock()
+	allowBits := a.Allow.Lookup(addr)
+	denyBits := a.Deny.Lookup(addr)
+	a.Mtx.RUnlock()
+
+	if denyBits >= 0 && denyBits >= allowBits {
+		return DecisionDeny
+	}
+	if allowBits >= 0 {
+		return DecisionAllow
+	}
+
+	return DecisionNone
+}
+
+// @brief set proxy network trusted to forward client ip
+//
+// @param entries []string - cidr or plain ip, empty trust none
+//
+// @return error - on invalid cidr old list stay in place
+func (a *AccessList) TrustProxies(entries []string) error {
+	a.reloadMtx.Lock()
+	defer a.reloadMtx.Unlock()
+
+	trusted, err := buildTrie(entries, ""); if err != nil {
+		return err
+	}
+
+	a.Mtx.Lock()
+	a.TrustedList = entries
+	a.Trusted = trusted
+	a.Mtx.Unlock()
+
+	return nil
+}
+
+// @brief client ip that allow/deny decision is made on
+//
+// @note forwarding header is only believed from trusted proxy peer, otherwise any client could claim an allowed ip
+//
+// @return string - peer host, or ClientIP of the header when peer is a trusted proxy
+func (a *AccessList) PeerIP(realIp, forwardedFor, remoteAddr string) string {
+	peer := ClientIP("", "", remoteAddr)
+	if a == nil {
+		return peer
+	}
+
+	addr, ok := ParseClientIP(peer); if !ok {
+		return peer
+	}
+
+	a.Mtx.RLock()
+	trusted := a.Trusted.Contains(addr)
+	a.Mtx.RUnlock()
+
+	if !trusted {
+		return peer
+	}
+
+	return ClientIP(realIp, forwardedFor, remoteAddr)
+}
+
+// @brief canonical client ip shared by http & grpc, so one client is one key on both
+//
+// @param realIp string - X-Real-IP header or x-real-ip metadata, "" when missing
+//
+// @param forwardedFor string - X-Forwarded-For header or metadata, first hop is used, "" when missing
+//
+// @param remoteAddr string - transport peer address, port is dropped
+//
+// @return string - first non empty of them, parsed ip is normalized, e.g. "::ffff:10.0.0.1" is "10.0.0.1"
+func ClientIP(realIp, forwardedFor, remoteAddr string) string {
+	for _, s := range []string{realIp, forwardedFor, remoteAddr} {
+		first := strings.TrimSpace(strings.Split(s, ",")[0])
+		if len(first) <= 0 {
+			continue
+		}
+
+		if addr, ok := ParseClientIP(first); ok {
+			return addr.String()
+		}
+		if host, _, err := net.SplitHostPort(first); err == nil {
+			return host
+		}
+
+		return first
+	}
+
+	return ""
+}
+
+// @brief parse client ip from header or remote address
+//
+// @note accept "ip", "ip:port", "[ipv6]:port" and "ip, proxy1, proxy2" (first only)
+func ParseClientIP(s string) (netip.Addr, bool) {
+	s = strings.TrimSpace(strings.Split(s, ",")[0])
+
+	if addrPort, err := netip.ParseAddrPort(s); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(s, "[]")); if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
+
+// @brief parse cidr or plain ip as network prefix
+func ParsePrefix(s string) (netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.Contains(s, "/") {
+		return netip.ParsePrefix(s)
+	}
+
+	addr, err := netip.ParseAddr(s); if err != nil {
+		return netip.Prefix{}, err
+	}
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func buildTrie(entries []string, fp string) (*Trie, error) {
+	trie := NewTrie()
+
+	for _, entry := range entries {
+		prefix, err := ParsePrefix(entry); if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q: %w", entry, err)
+		}
+		trie.Insert(prefix)
+	}
+
+	if len(fp) <= 0 {
+		return trie, nil
+	}
+
+	file, err := os.Open(fp); if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		// allow comment & empty line
+		entry := strings.TrimSpace(strings.Split(scanner.Text(), "#")[0])
+		if len(entry) <= 0 {
+			continue
+		}
+
+		prefix, err := ParsePrefix(entry); if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid cidr %q: %w", fp, line, entry, err)
+		}
+		trie.Insert(prefix)
+	}
+
+	return trie, scanner.Err()
+}
+
+// @brief reload access list when allow/deny file modification time change
+//
+// @param ctx context.Context - cancel to stop the loop
+//
+// @param list *AccessList
+//
+// @param d time.Duration - polling interval
+//
+// @param opts ...gen.Option - e.g. gen.WithClock, default to SystemClock
+func WatchAccessList(ctx context.Context, list *AccessList, d time.Duration, opts ...gen.Option) {
+	ticker := gen.ApplyOptions(opts...).ClockOr(nil).NewTicker(d)
+	defer ticker.Stop()
+
+	logger := pkg_logging.OrDefault(list.Logger)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+
+		changed := false
+
+		for _, fp := range []string{list.AllowFile, list.DenyFile} {
+			if len(fp) <= 0 {
+				continue
+			}
+
+			info, err := os.Stat(fp); if err != nil {
+				logger.Warn("can't stat access list file", "path", fp, "error", err)
+				continue
+			}
+
+			if !info.ModTime().Equal(list.modTimes[fp]) {
+				list.modTimes[fp] = info.ModTime()
+				changed = true
+			}
+		}
+
+		if !changed {
+			continue
+		}
+
+		if err := list.Reload(); err != nil {
+			logger.Warn("access list reload failed, keep old list", "error", err)
+			continue
+		}
+
+		logger.Info("access list reloaded",
+			"allow_file", list.AllowFile, "deny_file", list.DenyFile)
+	}
+}
@@ -0,0 +1,109 @@
+package pkg_cidr
+
+import (
+	"net/netip"
+)
+
+type trieNode struct {
+	Children [2]*trieNode
+	Terminal bool
+}
+
+// @brief binary prefix trie (radix 2) of ip networks
+//
+// @note ipv4 and ipv6 live in their own root, ipv4-mapped ipv6 is unmapped first
+type Trie struct {
+	Root4 *trieNode
+	Root6 *trieNode
+	Size int
+}
+
+// @brief create new empty prefix trie
+//
+// @return *Trie
+func NewTrie() *Trie {
+	return &Trie{
+		Root4: &trieNode{},
+		Root6: &trieNode{},
+	}
+}
+
+func (t *Trie) root(addr netip.Addr) *trieNode {
+	if addr.Is4() {
+		return t.Root4
+	}
+	return t.Root6
+}
+
+// @brief insert network prefix into trie
+//
+// @param prefix netip.Prefix - network, host bits are masked
+func (t *Trie) Insert(prefix netip.Prefix) {
+	prefix = normalizePrefix(prefix)
+
+	addr := prefix.Addr()
+	raw := addr.AsSlice()
+	node := t.root(addr)
+
+	for i := 0; i < prefix.Bits(); i++ {
+		bit := (raw[i/8] >> (7 - uint(i%8))) & 1
+
+		if node.Children[bit] == nil {
+			node.Children[bit] = &trieNode{}
+		}
+		node = node.Children[bit]
+	}
+
+	if !node.Terminal {
+		node.Terminal = true
+		t.Size++
+	}
+}
+
+// @brief longest prefix match of ip address
+//
+// @return int - matched prefix length, -1 when nothing match
+func (t *Trie) Lookup(addr netip.Addr) int {
+	if t == nil || !addr.IsValid() {
+		return -1
+	}
+
+	addr = addr.Unmap()
+	raw := addr.AsSlice()
+	node := t.root(addr)
+
+	longest := -1
+	if node.Terminal {
+		longest = 0
+	}
+
+	for i := 0; i < addr.BitLen(); i++ {
+		bit := (raw[i/8] >> (7 - uint(i%8))) & 1
+
+		node = node.Children[bit]
+		if node == nil {
+			break
+		}
+		if node.Terminal {
+			longest = i + 1
+		}
+	}
+
+	return longest
+}
+
+// @brief check whether ip address is covered by any network in trie
+func (t *Trie) Contains(addr netip.Addr) bool {
+	return t.Lookup(addr) >= 0
+}
+
+func normalizePrefix(prefix netip.Prefix) netip.Prefix {
+	if prefix.Addr().Is4In6() {
+		bits := prefix.Bits() - 96
+		if bits < 0 {
+			bits = 0
+		}
+		prefix = netip.PrefixFrom(prefix.Addr().Unmap(), bits)
+	}
+	return prefix.Masked()
+}
@@ -1,72 +1,462 @@
 package pkg_config
 
 import (
-  "encoding/json"
-  "log"
+  "fmt"
+  "log/slog"
   "os"
+  "time"
 )
 
+// injectable logger for config watching, nil use slog.Default
+var Logger *slog.Logger
+
+// --------------------------------------------------------- //
+
+// main server listener
+type ConfigListener struct {
+  Address string `json:"address"`
+  Port uint16 `json:"port"`
+}
+
+// optional connection level limit on main listener, 0 is unlimited
+type ConfigConnLimit struct {
+  MaxConn int `json:"max_conn"` // global concurrent connection, excess wait in accept
+  MaxConnPerIp int `json:"max_conn_per_ip"` // concurrent connection per ip, excess is closed
+  ConnRatePerIp Rate `json:"conn_rate_per_ip,omitempty"` // new connection per ip, e.g. "20/s", excess is closed
+  MaxConcurrentStreams uint32 `json:"max_concurrent_streams"` // http/2 stream per connection
+}
+
+// optional throughput cap in byte per second, over budget stream is paced, 0 is unlimited
+type ConfigBandwidth struct {
+  UploadPerIp int `json:"upload_per_ip"`
+  DownloadPerIp int `json:"download_per_ip"`
+  Upload int `json:"upload"` // all ip together
+  Download int `json:"download"` // all ip together
+}
+
+// optional global load shedding, admitted concurrency follow handler latency
+type ConfigAdaptive struct {
+  Enabled bool `json:"enabled"`
+  MinLimit int `json:"min_limit"`
+  MaxLimit int `json:"max_limit"`
+  TargetLatency Duration `json:"target_latency"` // handler latency above it shrink the limit
+  Backoff float64 `json:"backoff"` // multiplicative decrease, between 0 and 1
+  PriorityHeader string `json:"priority_header"` // http header or grpc metadata, "low", "normal" or "high", missing is normal
+}
+
+// optional weighted fair queuing between tenant in front of the handler
+type ConfigFairQueue struct {
+  Enabled bool `json:"enabled"`
+  MaxInFlight int `json:"max_in_flight"` // running request of all tenant
+  QueueSize int `json:"queue_size"` // waiting request of all tenant, lowest priority is rejected first when full
+  MaxWait Duration `json:"max_wait"` // 0 wait until client give up
+  TenantHeader string `json:"tenant_header"` // http header or grpc metadata, missing use client ip
+  PriorityHeader string `json:"priority_header"` // "low", "normal" or "high" for tenant not in tenants, missing is normal
+  Tenants map[string]string `json:"tenants,omitempty"` // tenant / "low", "normal" or "high"
+  Weights map[string]float64 `json:"weights,omitempty"` // "low", "normal" or "high" / service share of each tenant
+}
+
+// limiter policy, the only part applied again on config reload
+type ConfigLimiter struct {
+  MaxRequestPerIp int `json:"max_request_per_ip"`
+  MaxRequestInterval Duration `json:"max_request_interval"` // "250ms", "1m" or integer second
+  Rate Rate `json:"rate,omitempty"` // e.g. "100/min" or "5/s burst 20", when set it win over max_request_per_ip & max_request_interval
+  CleanupOldRequestInterval Duration `json:"cleanup_old_request_interval"`
+  ReloadInterval Duration `json:"reload_interval"` // config file polling, 0 only reload on SIGHUP
+  MaxInFlightPerIp int `json:"max_in_flight_per_ip,omitempty"` // running request per ip, 0 unlimited
+  MaxInFlight int `json:"max_in_flight,omitempty"` // running request of all ip, 0 unlimited
+  QueueSize int `json:"queue_size,omitempty"` // over limit request waiting per ip, 0 reject at once
+  QueueMaxWait Duration `json:"queue_max_wait,omitempty"` // longest wait, 0 only bounded by request deadline
+  Costs map[string]int `json:"costs,omitempty"` // unit per http route pattern or grpc full method, missing one cost 1
+}
+
+// @return uint, time.Duration, error - max request and window duration, *ValidationError if limiter block is invalid
+func (c ConfigLimiter) Policy() (uint, time.Duration, error) {
+  v := &validator{}
+  c.validate(v, "limiter")
+
+  if err := v.err(); err != nil {
+    return 0, 0, err
+  }
+
+  if c.Rate.IsSet() {
+    maxReq, window := c.Rate.Window()
+    return maxReq, window, nil
+  }
+
+  return uint(c.MaxRequestPerIp), time.Duration(c.MaxRequestInterval), nil
+}
+
+// log level & format, plus sampled rejection log
+type ConfigLog struct {
+  Level string `json:"level"` // debug, info, warn, error
+  Format string `json:"format"` // text, json
+  RejectionSampleInterval int `json:"rejection_sample_interval"`
+  RejectionSampleBurst int `json:"rejection_sample_burst"` // 0 log every rejection
+}
+
+// allow/deny network list, evaluated before any limiter counting
+type ConfigAccess struct {
+  Allow []string `json:"allow"`
+  Deny []string `json:"deny"`
+  AllowFile string `json:"allow_file"`
+  DenyFile string `json:"deny_file"`
+  TrustedProxies []string `json:"trusted_proxies"` // peer allowed to forward client ip for allow/deny, empty trust none
+  ReloadInterval int `json:"reload_interval"`
+}
+
+// temporary ban after repeated rejection, threshold 0 to disable
+type ConfigBan struct {
+  Threshold int `json:"threshold"`
+  Window int `json:"window"`
+  BaseDuration int `json:"base_duration"`
+  Factor float64 `json:"factor"`
+  MaxDuration int `json:"max_duration"`
+  CleanupInterval int `json:"cleanup_interval"`
+}
+
+// optional admin server on its own listener
+type ConfigAdmin struct {
+  Enabled bool `json:"enabled"`
+  Address string `json:"address"`
+  Port uint16 `json:"port"`
+  Token string `json:"token"`
+  GrpcService bool `json:"grpc_service"` // grpc server only, register RateLimiterAdmin on main server
+}
+
+// optional prometheus /metrics endpoint on its own listener
+type ConfigMetrics struct {
+  Enabled bool `json:"enabled"`
+  Address string `json:"address"`
+  Port uint16 `json:"port"`
+  Path string `json:"path"`
+}
+
+// optional opentelemetry tracing exported over otlp/grpc
+type ConfigTracing struct {
+  Enabled bool `json:"enabled"`
+  Endpoint string `json:"endpoint"`
+  Insecure bool `json:"insecure"`
+  SampleRatio float64 `json:"sample_ratio"`
+  ServiceName string `json:"service_name"`
+}
+
+// optional decision only endpoint for nginx auth_request, traefik forwardAuth & envoy ext_authz, http server only
+type ConfigAuthz struct {
+  Enabled bool `json:"enabled"`
+  Path string `json:"path"` // mount path on main listener, also envoy ext_authz path_prefix
+  RejectStatus int `json:"reject_status"` // 429, or 403 for nginx auth_request
+  Routes []string `json:"routes"` // route pattern reported & costed, e.g. "GET /users/{id}", unmatched request is reported as "authz"
+}
+
+// descriptor match for envoy rate limit service, first match win
+type ConfigRlsPolicy struct {
+  Name string `json:"name"`
+  Domain string `json:"domain"` // empty match every domain
+  Match map[string]string `json:"match"` // descriptor entry key to value, empty value match any value
+  Rate Rate `json:"rate"` // e.g. "100/min"
+}
+
+// optional envoy.service.ratelimit.v3.RateLimitService, grpc server only
+type ConfigRls struct {
+  Enabled bool `json:"enabled"`
+  Policies []ConfigRlsPolicy `json:"policies"`
+}
+
 // --------------------------------------------------------- //
 
 type ConfigServerHttp struct {
-  Listener struct {
-    Address string `json:"address"`
-    Port int16 `json:"port"`
-  } `json:"listener"`
-  Limiter struct {
-    MaxRequestPerIp int `json:"max_request_per_ip"`
-    MaxRequestInterval int `json:"max_request_interval"`
-    CleanupOldRequestInterval int `json:"cleanup_old_request_interval"`
-  } `json:"limiter"`
+  Listener ConfigListener `json:"listener"`
+  Limiter ConfigLimiter `json:"limiter"`
+  Access ConfigAccess `json:"access"`
+  Ban ConfigBan `json:"ban"`
+  Admin ConfigAdmin `json:"admin"`
+  Metrics ConfigMetrics `json:"metrics"`
+  Tracing ConfigTracing `json:"tracing"`
+  Log ConfigLog `json:"log"`
+  Authz ConfigAuthz `json:"authz"`
+  ConnLimit ConfigConnLimit `json:"conn_limit"`
+  Bandwidth ConfigBandwidth `json:"bandwidth"`
+  Adaptive ConfigAdaptive `json:"adaptive"`
+  FairQueue ConfigFairQueue `json:"fair_queue"`
   Server struct {
     IdleTimeout int `json:"idle_timeout"`
     ReadTimeout int `json:"read_timeout"`
     WriteTimeout int `json:"write_timeout"`
+    ShutdownTimeout int `json:"shutdown_timeout"` // drain timeout on SIGINT/SIGTERM
   } `json:"server"`
 }
 
+// @brief read json, yaml or toml config file by its extension, then parse it
+//
+// @return ConfigServerHttp, error - error wrap *ValidationError for invalid field
 func ConfigServerHttpLoad(fp string) (ConfigServerHttp, error) {
-  var cfg ConfigServerHttp
+  format, err := FormatFromPath(fp); if err != nil {
+    return ConfigServerHttp{}, err
+  }
 
   content, err := os.ReadFile(fp); if err != nil {
-    log.Fatalf("error: %v\nconfig file is required\n", err)
+    return ConfigServerHttp{}, fmt.Errorf("can't read config file: %w", err)
+  }
+
+  cfg, err := ConfigServerHttpParse(content, format); if err != nil {
+    return cfg, fmt.Errorf("%s: %w", fp, err)
+  }
+
+  return cfg, nil
+}
+
+// @brief strictly decode content over default, apply NLG_ environment override, then validate
+//
+// @note precedence is env > file > default, also used on reload
+//
+// @param format string - FormatJson, FormatYaml or FormatToml
+func ConfigServerHttpParse(content []byte, format string) (ConfigServerHttp, error) {
+  cfg := DefaultConfigServerHttp()
+
+  content, err := toJson(content, format); if err != nil {
     return cfg, err
   }
 
-  err = json.Unmarshal(content, &cfg); if err != nil {
-    log.Fatalf("error: %v\n", err)
+  err = decodeStrict(content, &cfg); if err != nil {
     return cfg, err
   }
 
-  return cfg, nil
+  err = ApplyEnv(&cfg); if err != nil {
+    return cfg, err
+  }
+
+  return cfg, cfg.Validate()
 }
 
 // --------------------------------------------------------- //
 
 type ConfigServerGrpc struct {
-  Listener struct {
-    Address string `json:"address"`
-    Port int16 `json:"port"`
-  } `json:"listener"`
-  Limiter struct {
-    MaxRequestPerIp int `json:"max_request_per_ip"`
-    MaxRequestInterval int `json:"max_request_interval"`
-    CleanupOldRequestInterval int `json:"cleanup_old_request_interval"`
-  } `json:"limiter"`
+  Listener ConfigListener `json:"listener"`
+  Limiter ConfigLimiter `json:"limiter"`
+  Access ConfigAccess `json:"access"`
+  Ban ConfigBan `json:"ban"`
+  Admin ConfigAdmin `json:"admin"`
+  Metrics ConfigMetrics `json:"metrics"`
+  Tracing ConfigTracing `json:"tracing"`
+  Log ConfigLog `json:"log"`
+  Rls ConfigRls `json:"rls"`
+  ConnLimit ConfigConnLimit `json:"conn_limit"`
+  Bandwidth ConfigBandwidth `json:"bandwidth"`
+  Adaptive ConfigAdaptive `json:"adaptive"`
+  FairQueue ConfigFairQueue `json:"fair_queue"`
+  Server struct {
+    ShutdownTimeout int `json:"shutdown_timeout"` // drain timeout on SIGINT/SIGTERM
+  } `json:"server"`
 }
 
+// @brief read json, yaml or toml config file by its extension, then parse it
+//
+// @return ConfigServerGrpc, error - error wrap *ValidationError for invalid field
 func ConfigServerGrpcLoad(fp string) (ConfigServerGrpc, error) {
-  var cfg ConfigServerGrpc
+  format, err := FormatFromPath(fp); if err != nil {
+    return ConfigServerGrpc{}, err
+  }
+
+  content, err := os.ReadFile(fp); if err != nil {
+    return ConfigServerGrpc{}, fmt.Errorf("can't read config file: %w", err)
+  }
+
+  cfg, err := ConfigServerGrpcParse(content, format); if err != nil {
+    return cfg, fmt.Errorf("%s: %w", fp, err)
+  }
+
+  return cfg, nil
+}
+
+// @brief strictly decode content over default, apply NLG_ environment override, then validate
+//
+// @note precedence is env > file > default, also used on reload
+//
+// @param format string - FormatJson, FormatYaml or FormatToml
+func ConfigServerGrpcParse(content []byte, format string) (ConfigServerGrpc, error) {
+  cfg := DefaultConfigServerGrpc()
+
+  content, err := toJson(content, format); if err != nil {
+    return cfg, err
+  }
+
+  err = decodeStrict(content, &cfg); if err != nil {
+    return cfg, err
+  }
+
+  err = ApplyEnv(&cfg); if err != nil {
+    return cfg, err
+  }
+
+  return cfg, cfg.Validate()
+}
+
+// --------------------------------------------------------- //
+
+// @brief http & grpc served from one process
+type ConfigServerUnified struct {
+  Listener ConfigListener `json:"listener"` // http, also grpc when grpc_listener.port is 0
+  GrpcListener ConfigListener `json:"grpc_listener"` // port 0 multiplex grpc on listener by content-type
+  Limiter ConfigLimiter `json:"limiter"`
+  ShareLimiter bool `json:"share_limiter"` // one quota per ip across http & grpc, instead of one limiter per protocol
+  Access ConfigAccess `json:"access"`
+  Ban ConfigBan `json:"ban"`
+  Admin ConfigAdmin `json:"admin"`
+  Metrics ConfigMetrics `json:"metrics"`
+  Tracing ConfigTracing `json:"tracing"`
+  Log ConfigLog `json:"log"`
+  Server struct {
+    IdleTimeout int `json:"idle_timeout"`
+    ReadTimeout int `json:"read_timeout"`
+    WriteTimeout int `json:"write_timeout"`
+    ShutdownTimeout int `json:"shutdown_timeout"` // drain timeout on SIGINT/SIGTERM
+  } `json:"server"`
+}
+
+// @return bool - whether grpc is multiplexed on http listener
+func (cfg ConfigServerUnified) Multiplexed() bool {
+  return cfg.GrpcListener.Port == 0
+}
+
+// @brief read json, yaml or toml config file by its extension, then parse it
+//
+// @return ConfigServerUnified, error - error wrap *ValidationError for invalid field
+func ConfigServerUnifiedLoad(fp string) (ConfigServerUnified, error) {
+  format, err := FormatFromPath(fp); if err != nil {
+    return ConfigServerUnified{}, err
+  }
 
   content, err := os.ReadFile(fp); if err != nil {
-    log.Fatalf("error: %v\nconfig file is required\n", err)
+    return ConfigServerUnified{}, fmt.Errorf("can't read config file: %w", err)
+  }
+
+  cfg, err := ConfigServerUnifiedParse(content, format); if err != nil {
+    return cfg, fmt.Errorf("%s: %w", fp, err)
+  }
+
+  return cfg, nil
+}
+
+// @brief strictly decode content over default, apply NLG_ environment override, then validate
+//
+// @note precedence is env > file > default, also used on reload
+//
+// @param format string - FormatJson, FormatYaml or FormatToml
+func ConfigServerUnifiedParse(content []byte, format string) (ConfigServerUnified, error) {
+  cfg := DefaultConfigServerUnified()
+
+  content, err := toJson(content, format); if err != nil {
     return cfg, err
   }
 
-  err = json.Unmarshal(content, &cfg); if err != nil {
-    log.Fatalf("error: %v\n", err)
+  err = decodeStrict(content, &cfg); if err != nil {
     return cfg, err
   }
 
+  err = ApplyEnv(&cfg); if err != nil {
+    return cfg, err
+  }
+
+  return cfg, cfg.Validate()
+}
+
+// --------------------------------------------------------- //
+
+// header rewrite, remove is applied before set
+type ConfigHeaderRewrite struct {
+  Set map[string]string `json:"set"`
+  Remove []string `json:"remove"`
+}
+
+// active upstream health check, interval 0 to disable
+type ConfigHealthCheck struct {
+  Path string `json:"path"`
+  Interval Duration `json:"interval"`
+  Timeout Duration `json:"timeout"` // 0 use interval
+}
+
+// one upstream behind gateway, matched by longest path prefix
+type ConfigRoute struct {
+  Name string `json:"name"` // policy name reported to log, metrics & tracing
+  Prefix string `json:"prefix"`
+  Upstream string `json:"upstream"` // e.g. "http://127.0.0.1:8080/base"
+  StripPrefix bool `json:"strip_prefix"`
+  Rate Rate `json:"rate,omitempty"` // route own policy, unset use limiter block
+  RequestHeaders ConfigHeaderRewrite `json:"request_headers"`
+  ResponseHeaders ConfigHeaderRewrite `json:"response_headers"`
+  HealthCheck ConfigHealthCheck `json:"health_check"`
+}
+
+// @param fallback ConfigLimiter - used when route rate isn't set
+//
+// @return uint, time.Duration, error - max request and window duration
+func (c ConfigRoute) Policy(fallback ConfigLimiter) (uint, time.Duration, error) {
+  if c.Rate.IsSet() {
+    maxReq, window := c.Rate.Window()
+    return maxReq, window, nil
+  }
+
+  return fallback.Policy()
+}
+
+// @brief reverse proxy gateway, each route has its own limiter
+type ConfigServerGateway struct {
+  Listener ConfigListener `json:"listener"`
+  Limiter ConfigLimiter `json:"limiter"` // default policy, cleanup & reload interval for every route
+  Routes []ConfigRoute `json:"routes"`
+  Access ConfigAccess `json:"access"`
+  Ban ConfigBan `json:"ban"`
+  Admin ConfigAdmin `json:"admin"`
+  Metrics ConfigMetrics `json:"metrics"`
+  Tracing ConfigTracing `json:"tracing"`
+  Log ConfigLog `json:"log"`
+  Server struct {
+    IdleTimeout int `json:"idle_timeout"`
+    ReadTimeout int `json:"read_timeout"`
+    WriteTimeout int `json:"write_timeout"`
+    ShutdownTimeout int `json:"shutdown_timeout"` // drain timeout on SIGINT/SIGTERM
+  } `json:"server"`
+}
+
+// @brief read json, yaml or toml config file by its extension, then parse it
+//
+// @return ConfigServerGateway, error - error wrap *ValidationError for invalid field
+func ConfigServerGatewayLoad(fp string) (ConfigServerGateway, error) {
+  format, err := FormatFromPath(fp); if err != nil {
+    return ConfigServerGateway{}, err
+  }
+
+  content, err := os.ReadFile(fp); if err != nil {
+    return ConfigServerGateway{}, fmt.Errorf("can't read config file: %w", err)
+  }
+
+  cfg, err := ConfigServerGatewayParse(content, format); if err != nil {
+    return cfg, fmt.Errorf("%s: %w", fp, err)
+  }
+
   return cfg, nil
 }
+
+// @brief strictly decode content over default, apply NLG_ environment override, then validate
+//
+// @note precedence is env > file > default, also used on reload
+//
+// @param format string - FormatJson, FormatYaml or FormatToml
+func ConfigServerGatewayParse(content []byte, format string) (ConfigServerGateway, error) {
+  cfg := DefaultConfigServerGateway()
+
+  content, err := toJson(content, format); if err != nil {
+    return cfg, err
+  }
+
+  err = decodeStrict(content, &cfg); if err != nil {
+    return cfg, err
+  }
+
+  err = ApplyEnv(&cfg); if err != nil {
+    return cfg, err
+  }
+
+  return cfg, cfg.Validate()
+}
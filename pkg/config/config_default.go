@@ -0,0 +1,169 @@
+package pkg_config
+
+import (
+  "time"
+)
+
+// @brief value used for every key missing from config file, optional server is disabled
+func DefaultConfigServerHttp() ConfigServerHttp {
+  var cfg ConfigServerHttp
+
+  cfg.Listener = ConfigListener{Address: "0.0.0.0", Port: 7676}
+  cfg.Limiter = defaultLimiter(3)
+  cfg.Access = defaultAccess()
+  cfg.Ban = defaultBan()
+  cfg.Admin = ConfigAdmin{Address: "127.0.0.1", Port: 7677}
+  cfg.Metrics = defaultMetrics(9676)
+  cfg.Tracing = defaultTracing("server_nethttp")
+  cfg.Log = defaultLog()
+  cfg.Authz = ConfigAuthz{Path: "/authz", RejectStatus: 429, Routes: []string{}}
+  cfg.Adaptive = defaultAdaptive()
+  cfg.FairQueue = defaultFairQueue()
+
+  cfg.Server.IdleTimeout = 60
+  cfg.Server.ReadTimeout = 75
+  cfg.Server.WriteTimeout = 75
+  cfg.Server.ShutdownTimeout = 30
+
+  return cfg
+}
+
+// @brief value used for every key missing from config file, optional server is disabled
+func DefaultConfigServerGrpc() ConfigServerGrpc {
+  var cfg ConfigServerGrpc
+
+  cfg.Listener = ConfigListener{Address: "0.0.0.0", Port: 10101}
+  cfg.Limiter = defaultLimiter(6)
+  cfg.Access = defaultAccess()
+  cfg.Ban = defaultBan()
+  cfg.Admin = ConfigAdmin{Address: "127.0.0.1", Port: 10102}
+  cfg.Metrics = defaultMetrics(9101)
+  cfg.Tracing = defaultTracing("server_grpc")
+  cfg.Log = defaultLog()
+  cfg.Rls = ConfigRls{Policies: []ConfigRlsPolicy{}}
+  cfg.Adaptive = defaultAdaptive()
+  cfg.FairQueue = defaultFairQueue()
+
+  cfg.Server.ShutdownTimeout = 30
+
+  return cfg
+}
+
+// @brief value used for every key missing from config file, grpc is multiplexed on http port
+func DefaultConfigServerUnified() ConfigServerUnified {
+  var cfg ConfigServerUnified
+
+  cfg.Listener = ConfigListener{Address: "0.0.0.0", Port: 8686}
+  cfg.GrpcListener = ConfigListener{Address: "0.0.0.0"}
+  cfg.Limiter = defaultLimiter(6)
+  cfg.Access = defaultAccess()
+  cfg.Ban = defaultBan()
+  cfg.Admin = ConfigAdmin{Address: "127.0.0.1", Port: 8687}
+  cfg.Metrics = defaultMetrics(9686)
+  cfg.Tracing = defaultTracing("server_unified")
+  cfg.Log = defaultLog()
+
+  cfg.Server.IdleTimeout = 60
+  cfg.Server.ReadTimeout = 75
+  cfg.Server.WriteTimeout = 75
+  cfg.Server.ShutdownTimeout = 30
+
+  return cfg
+}
+
+// @brief value used for every key missing from config file, route list is empty
+func DefaultConfigServerGateway() ConfigServerGateway {
+  var cfg ConfigServerGateway
+
+  cfg.Listener = ConfigListener{Address: "0.0.0.0", Port: 8080}
+  cfg.Limiter = defaultLimiter(60)
+  cfg.Routes = []ConfigRoute{}
+  cfg.Access = defaultAccess()
+  cfg.Ban = defaultBan()
+  cfg.Admin = ConfigAdmin{Address: "127.0.0.1", Port: 8081}
+  cfg.Metrics = defaultMetrics(9080)
+  cfg.Tracing = defaultTracing("server_gateway")
+  cfg.Log = defaultLog()
+
+  cfg.Server.IdleTimeout = 60
+  cfg.Server.ReadTimeout = 75
+  cfg.Server.WriteTimeout = 75
+  cfg.Server.ShutdownTimeout = 30
+
+  return cfg
+}
+
+// --------------------------------------------------------- //
+
+func defaultLimiter(maxReq int) ConfigLimiter {
+  return ConfigLimiter{
+    MaxRequestPerIp: maxReq,
+    MaxRequestInterval: Duration(time.Minute),
+    CleanupOldRequestInterval: Duration(2 * time.Minute),
+    ReloadInterval: Duration(10 * time.Second),
+  }
+}
+
+func defaultAdaptive() ConfigAdaptive {
+  return ConfigAdaptive{
+    MinLimit: 10,
+    MaxLimit: 500,
+    TargetLatency: Duration(10 * time.Second),
+    Backoff: 0.9,
+    PriorityHeader: "X-Priority",
+  }
+}
+
+func defaultFairQueue() ConfigFairQueue {
+  return ConfigFairQueue{
+    MaxInFlight: 100,
+    QueueSize: 1000,
+    MaxWait: Duration(5 * time.Second),
+    TenantHeader: "X-Tenant",
+    PriorityHeader: "X-Priority",
+    Tenants: map[string]string{},
+    Weights: map[string]float64{"low": 1, "normal": 4, "high": 16},
+  }
+}
+
+func defaultAccess() ConfigAccess {
+  return ConfigAccess{
+    Allow: []string{},
+    Deny: []string{},
+    TrustedProxies: []string{},
+    ReloadInterval: 30,
+  }
+}
+
+func defaultBan() ConfigBan {
+  return ConfigBan{
+    Threshold: 10,
+    Window: 60,
+    BaseDuration: 60,
+    Factor: 10,
+    MaxDuration: 86400,
+    CleanupInterval: 120,
+  }
+}
+
+func defaultMetrics(port uint16) ConfigMetrics {
+  return ConfigMetrics{Address: "0.0.0.0", Port: port, Path: "/metrics"}
+}
+
+func defaultTracing(serviceName string) ConfigTracing {
+  return ConfigTracing{
+    Endpoint: "localhost:4317",
+    Insecure: true,
+    SampleRatio: 1.0,
+    ServiceName: serviceName,
+  }
+}
+
+func defaultLog() ConfigLog {
+  return ConfigLog{
+    Level: "info",
+    Format: "text",
+    RejectionSampleInterval: 60,
+    RejectionSampleBurst: 5,
+  }
+}
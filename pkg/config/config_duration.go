@@ -0,0 +1,168 @@
+package pkg_config
+
+import (
+  "bytes"
+  "encoding/json"
+  "fmt"
+  "strconv"
+  "strings"
+  "time"
+)
+
+// @brief duration accepting go duration string, e.g. "250ms", "1h", or integer second for backward compatibility
+type Duration time.Duration
+
+func (d Duration) String() string {
+  return time.Duration(d).String()
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+  return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+  if bytes.Equal(b, []byte("null")) {
+    return nil
+  }
+
+  var s string
+  if err := json.Unmarshal(b, &s); err != nil {
+    // not a string, must be integer second
+    var n int64
+    if err := json.Unmarshal(b, &n); err != nil {
+      return fmt.Errorf("expected duration like \"250ms\" or integer second, got %s", b)
+    }
+
+    *d = Duration(time.Duration(n) * time.Second)
+    return nil
+  }
+
+  if err := d.UnmarshalText([]byte(s)); err != nil {
+    return fmt.Errorf("expected duration like \"250ms\" or integer second, got %q", s)
+  }
+
+  return nil
+}
+
+// @note used for NLG_ environment override as well
+func (d *Duration) UnmarshalText(b []byte) error {
+  s := strings.TrimSpace(string(b))
+
+  if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+    *d = Duration(time.Duration(n) * time.Second)
+    return nil
+  }
+
+  parsed, err := time.ParseDuration(s); if err != nil {
+    return err
+  }
+
+  *d = Duration(parsed)
+  return nil
+}
+
+// --------------------------------------------------------- //
+
+var rateUnits = map[string]time.Duration{
+  "s": time.Second, "sec": time.Second, "second": time.Second,
+  "m": time.Minute, "min": time.Minute, "minute": time.Minute,
+  "h": time.Hour, "hr": time.Hour, "hour": time.Hour,
+  "d": 24 * time.Hour, "day": 24 * time.Hour,
+}
+
+// @brief request rate, e.g. "100/min", "10/500ms" or "5/s burst 20"
+//
+// @note zero Rate is unset
+type Rate struct {
+  Count uint
+  Per time.Duration
+  Burst uint // 0 is same as Count
+}
+
+// @brief sliding window equivalent of rate
+//
+// @note burst allow up to Burst request inside a window stretched to keep the same average, e.g. "5/s burst 20" is 20 request per 4s
+//
+// @return uint, time.Duration - max request and window duration
+func (r Rate) Window() (uint, time.Duration) {
+  if r.Burst <= r.Count {
+    return r.Count, r.Per
+  }
+
+  return r.Burst, time.Duration(float64(r.Per) * float64(r.Burst) / float64(r.Count))
+}
+
+// @return bool - false when rate isn't set
+func (r Rate) IsSet() bool {
+  return r.Count > 0
+}
+
+func (r Rate) String() string {
+  if !r.IsSet() {
+    return ""
+  }
+
+  s := fmt.Sprintf("%d/%s", r.Count, r.Per)
+  if r.Burst > 0 {
+    s += fmt.Sprintf(" burst %d", r.Burst)
+  }
+
+  return s
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+  return json.Marshal(r.String())
+}
+
+func (r *Rate) UnmarshalJSON(b []byte) error {
+  if bytes.Equal(b, []byte("null")) {
+    return nil
+  }
+
+  var s string
+  if err := json.Unmarshal(b, &s); err != nil {
+    return fmt.Errorf("expected rate like \"100/min\" or \"5/s burst 20\", got %s", b)
+  }
+
+  return r.UnmarshalText([]byte(s))
+}
+
+// @note used for NLG_ environment override as well, empty string unset rate
+func (r *Rate) UnmarshalText(b []byte) error {
+  s := strings.ToLower(strings.TrimSpace(string(b)))
+  if len(s) <= 0 {
+    *r = Rate{}
+    return nil
+  }
+
+  count, rest, ok := strings.Cut(s, "/"); if !ok {
+    return fmt.Errorf("missing \"/\" in rate %q", s)
+  }
+
+  n, err := strconv.ParseUint(strings.TrimSpace(count), 10, 32); if err != nil || n == 0 {
+    return fmt.Errorf("invalid rate count %q", count)
+  }
+
+  fields := strings.Fields(rest)
+  if len(fields) != 1 && (len(fields) != 3 || fields[1] != "burst") {
+    return fmt.Errorf("expected \"<count>/<unit>\" or \"<count>/<unit> burst <n>\", got %q", s)
+  }
+
+  per, ok := rateUnits[fields[0]]; if !ok {
+    per, err = time.ParseDuration(fields[0]); if err != nil || per <= 0 {
+      return fmt.Errorf("invalid rate unit %q", fields[0])
+    }
+  }
+
+  rate := Rate{Count: uint(n), Per: per}
+
+  if len(fields) == 3 {
+    burst, err := strconv.ParseUint(fields[2], 10, 32); if err != nil || burst < n {
+      return fmt.Errorf("burst must be a number not less than %d, got %q", n, fields[2])
+    }
+    rate.Burst = uint(burst)
+  }
+
+  *r = rate
+  return nil
+}
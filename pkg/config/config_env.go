@@ -0,0 +1,110 @@
+package pkg_config
+
+import (
+  "encoding"
+  "fmt"
+  "os"
+  "reflect"
+  "strconv"
+  "strings"
+)
+
+// environment variable prefix, e.g. NLG_LIMITER_MAX_REQUEST_PER_IP
+const EnvPrefix = "NLG_"
+
+// @brief override config field from NLG_ environment variable
+//
+// @note variable name is json path upper cased, "." replaced by "_", e.g. limiter.max_request_per_ip -> NLG_LIMITER_MAX_REQUEST_PER_IP
+//
+// @note list value is comma separated, e.g. NLG_ACCESS_DENY=10.0.0.0/8,192.168.0.0/16
+//
+// @param cfg any - pointer to config struct
+//
+// @return error - *ValidationError for unparsable value
+func ApplyEnv(cfg any) error {
+  v := &validator{}
+  applyEnv(reflect.ValueOf(cfg).Elem(), "", v)
+
+  return v.err()
+}
+
+func applyEnv(rv reflect.Value, path string, v *validator) {
+  rt := rv.Type()
+
+  for i := 0; i < rt.NumField(); i++ {
+    tag := strings.Split(rt.Field(i).Tag.Get("json"), ",")[0]
+    if len(tag) <= 0 || tag == "-" {
+      continue
+    }
+
+    field := tag
+    if len(path) > 0 {
+      field = path + "." + tag
+    }
+
+    fv := rv.Field(i)
+    _, isText := fv.Addr().Interface().(encoding.TextUnmarshaler)
+
+    if fv.Kind() == reflect.Struct && !isText {
+      applyEnv(fv, field, v)
+      continue
+    }
+
+    name := EnvPrefix + strings.ToUpper(strings.ReplaceAll(field, ".", "_"))
+    value, ok := os.LookupEnv(name); if !ok {
+      continue
+    }
+
+    if err := setEnvValue(fv, strings.TrimSpace(value)); err != nil {
+      v.check(false, field, "invalid %s %q: %v", name, value, err)
+    }
+  }
+}
+
+func setEnvValue(fv reflect.Value, value string) error {
+  // Duration & Rate
+  if u, ok := fv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+    return u.UnmarshalText([]byte(value))
+  }
+
+  switch fv.Kind() {
+  case reflect.String:
+    fv.SetString(value)
+  case reflect.Bool:
+    b, err := strconv.ParseBool(value); if err != nil {
+      return err
+    }
+    fv.SetBool(b)
+  case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+    n, err := strconv.ParseInt(value, 10, fv.Type().Bits()); if err != nil {
+      return err
+    }
+    fv.SetInt(n)
+  case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+    n, err := strconv.ParseUint(value, 10, fv.Type().Bits()); if err != nil {
+      return err
+    }
+    fv.SetUint(n)
+  case reflect.Float32, reflect.Float64:
+    f, err := strconv.ParseFloat(value, fv.Type().Bits()); if err != nil {
+      return err
+    }
+    fv.SetFloat(f)
+  case reflect.Slice:
+    if fv.Type().Elem().Kind() != reflect.String {
+      return fmt.Errorf("unsupported list type %s", fv.Type())
+    }
+
+    list := []string{}
+    for _, s := range strings.Split(value, ",") {
+      if s = strings.TrimSpace(s); len(s) > 0 {
+        list = append(list, s)
+      }
+    }
+    fv.Set(reflect.ValueOf(list))
+  default:
+    return fmt.Errorf("unsupported type %s", fv.Type())
+  }
+
+  return nil
+}
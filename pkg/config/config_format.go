@@ -0,0 +1,63 @@
+package pkg_config
+
+import (
+  "encoding/json"
+  "fmt"
+  "path/filepath"
+  "strings"
+
+  "github.com/BurntSushi/toml"
+  "gopkg.in/yaml.v3"
+)
+
+const (
+  FormatJson = "json"
+  FormatYaml = "yaml"
+  FormatToml = "toml"
+)
+
+// @brief detect config format from file extension
+//
+// @note trailing ".template" is ignored, e.g. config.http.json.template is json
+//
+// @return string, error - FormatJson, FormatYaml or FormatToml
+func FormatFromPath(fp string) (string, error) {
+  ext := strings.ToLower(filepath.Ext(strings.TrimSuffix(fp, ".template")))
+
+  switch ext {
+  case ".json":
+    return FormatJson, nil
+  case ".yaml", ".yml":
+    return FormatYaml, nil
+  case ".toml":
+    return FormatToml, nil
+  }
+
+  return "", fmt.Errorf("unsupported config file extension %q, expected .json, .yaml, .yml or .toml", ext)
+}
+
+// @brief convert yaml/toml content to json, so every format share strict decoding & field path error
+func toJson(content []byte, format string) ([]byte, error) {
+  var doc any
+
+  switch format {
+  case FormatJson:
+    return content, nil
+  case FormatYaml:
+    if err := yaml.Unmarshal(content, &doc); err != nil {
+      return nil, fmt.Errorf("invalid yaml: %w", err)
+    }
+  case FormatToml:
+    if _, err := toml.Decode(string(content), &doc); err != nil {
+      return nil, fmt.Errorf("invalid toml: %w", err)
+    }
+  default:
+    return nil, fmt.Errorf("unsupported config format %q", format)
+  }
+
+  content, err := json.Marshal(doc); if err != nil {
+    return nil, fmt.Errorf("invalid %s document: %w", format, err)
+  }
+
+  return content, nil
+}
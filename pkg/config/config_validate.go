@@ -0,0 +1,536 @@
+package pkg_config
+
+import (
+  "bytes"
+  "encoding/json"
+  "errors"
+  "fmt"
+  "io"
+  "net/url"
+  "reflect"
+  "strings"
+
+  pkg_cidr "github.com/prothegee/network-limiter-go/pkg/cidr"
+  pkg_http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+)
+
+// @brief single invalid config field
+type FieldError struct {
+  Field string // json path, e.g. "limiter.max_request_per_ip" or "access.allow[1]"
+  Message string
+}
+
+func (e *FieldError) Error() string {
+  return e.Field + ": " + e.Message
+}
+
+// @brief every invalid field found in one config
+type ValidationError struct {
+  Errors []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+  msgs := make([]string, 0, len(e.Errors))
+  for _, fe := range e.Errors {
+    msgs = append(msgs, fe.Error())
+  }
+
+  return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Unwrap() []error {
+  errs := make([]error, 0, len(e.Errors))
+  for _, fe := range e.Errors {
+    errs = append(errs, fe)
+  }
+
+  return errs
+}
+
+type validator struct {
+  errs []*FieldError
+}
+
+// @brief record field error when ok is false
+func (v *validator) check(ok bool, field, format string, args ...any) {
+  if !ok {
+    v.errs = append(v.errs, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+  }
+}
+
+func (v *validator) positive(n int, field string) {
+  v.check(n > 0, field, "must be positive, got %d", n)
+}
+
+func (v *validator) nonNegative(n int, field string) {
+  v.check(n >= 0, field, "must not be negative, got %d", n)
+}
+
+func (v *validator) positiveDuration(d Duration, field string) {
+  v.check(d > 0, field, "must be positive, got %s", d)
+}
+
+func (v *validator) nonNegativeDuration(d Duration, field string) {
+  v.check(d >= 0, field, "must not be negative, got %s", d)
+}
+
+func (v *validator) err() error {
+  if len(v.errs) <= 0 {
+    return nil
+  }
+
+  return &ValidationError{Errors: v.errs}
+}
+
+// --------------------------------------------------------- //
+
+// @brief strict json decode, unknown key and trailing data is rejected
+//
+// @note type mismatch is reported as *ValidationError with field path, e.g. port above 65535
+func decodeStrict(content []byte, cfg any) error {
+  if err := checkUnmarshalers(content, reflect.TypeOf(cfg).Elem()); err != nil {
+    return err
+  }
+
+  dec := json.NewDecoder(bytes.NewReader(content))
+  dec.DisallowUnknownFields()
+
+  err := dec.Decode(cfg); if err != nil {
+    var typeErr *json.UnmarshalTypeError
+    if errors.As(err, &typeErr) {
+      return &ValidationError{Errors: []*FieldError{{
+        Field: typeErr.Field,
+        Message: fmt.Sprintf("expected %s, got %s", typeErr.Type, typeErr.Value),
+      }}}
+    }
+
+    var syntaxErr *json.SyntaxError
+    if errors.As(err, &syntaxErr) {
+      line, col := position(content, syntaxErr.Offset)
+      return fmt.Errorf("invalid json at line %d, column %d: %w", line, col, err)
+    }
+
+    return err
+  }
+
+  if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+    return fmt.Errorf("unexpected data after top-level config object")
+  }
+
+  return nil
+}
+
+// @brief run custom unmarshaler, e.g. Duration & Rate, on each value with its field path
+//
+// @note encoding/json return their error without field path
+func checkUnmarshalers(content []byte, rt reflect.Type) error {
+  var doc any
+  if err := json.Unmarshal(content, &doc); err != nil {
+    return nil // reported by strict decode with position
+  }
+
+  v := &validator{}
+  walkUnmarshalers(doc, rt, "", v)
+
+  return v.err()
+}
+
+func walkUnmarshalers(doc any, rt reflect.Type, path string, v *validator) {
+  // e.g. routes[1].rate
+  if list, ok := doc.([]any); ok && rt.Kind() == reflect.Slice {
+    for i, item := range list {
+      walkUnmarshalers(item, rt.Elem(), fmt.Sprintf("%s[%d]", path, i), v)
+    }
+    return
+  }
+
+  obj, ok := doc.(map[string]any); if !ok || rt.Kind() != reflect.Struct {
+    return
+  }
+
+  for i := 0; i < rt.NumField(); i++ {
+    f := rt.Field(i)
+
+    tag := strings.Split(f.Tag.Get("json"), ",")[0]
+    value, ok := obj[tag]; if !ok || len(tag) <= 0 {
+      continue
+    }
+
+    field := tag
+    if len(path) > 0 {
+      field = path + "." + tag
+    }
+
+    u, ok := reflect.New(f.Type).Interface().(json.Unmarshaler); if !ok {
+      walkUnmarshalers(value, f.Type, field, v)
+      continue
+    }
+
+    raw, err := json.Marshal(value); if err == nil {
+      err = u.UnmarshalJSON(raw)
+    }
+    v.check(err == nil, field, "%v", err)
+  }
+}
+
+func position(content []byte, offset int64) (int, int) {
+  if offset > int64(len(content)) {
+    offset = int64(len(content))
+  }
+
+  before := content[:offset]
+  line := bytes.Count(before, []byte("\n")) + 1
+  col := len(before) - bytes.LastIndexByte(before, '\n')
+
+  return line, col
+}
+
+// --------------------------------------------------------- //
+
+func (c ConfigLimiter) validate(v *validator, path string) {
+  v.positive(c.MaxRequestPerIp, path + ".max_request_per_ip")
+  v.positiveDuration(c.MaxRequestInterval, path + ".max_request_interval")
+  v.positiveDuration(c.CleanupOldRequestInterval, path + ".cleanup_old_request_interval")
+  v.nonNegativeDuration(c.ReloadInterval, path + ".reload_interval")
+  v.nonNegative(c.MaxInFlightPerIp, path + ".max_in_flight_per_ip")
+  v.nonNegative(c.MaxInFlight, path + ".max_in_flight")
+  v.nonNegative(c.QueueSize, path + ".queue_size")
+  v.nonNegativeDuration(c.QueueMaxWait, path + ".queue_max_wait")
+  maxReq := c.MaxRequestPerIp
+  if c.Rate.IsSet() {
+    rateMax, _ := c.Rate.Window()
+    maxReq = int(rateMax)
+  }
+  for name, cost := range c.Costs {
+    field := fmt.Sprintf("%s.costs[%q]", path, name)
+    v.positive(cost, field)
+    // over budget cost is always rejected, no point in allowing it
+    v.check(cost <= maxReq, field, "must not be greater than max request %d, got %d", maxReq, cost)
+  }
+  if c.MaxInFlight > 0 {
+    v.check(c.MaxInFlightPerIp <= c.MaxInFlight, path + ".max_in_flight_per_ip",
+      "must not be greater than max_in_flight %d, got %d", c.MaxInFlight, c.MaxInFlightPerIp)
+  }
+}
+
+func (c ConfigAccess) validate(v *validator, path string) {
+  for i, s := range c.Allow {
+    _, err := pkg_cidr.ParsePrefix(s)
+    v.check(err == nil, fmt.Sprintf("%s.allow[%d]", path, i), "invalid network %q", s)
+  }
+  for i, s := range c.Deny {
+    _, err := pkg_cidr.ParsePrefix(s)
+    v.check(err == nil, fmt.Sprintf("%s.deny[%d]", path, i), "invalid network %q", s)
+  }
+  for i, s := range c.TrustedProxies {
+    _, err := pkg_cidr.ParsePrefix(s)
+    v.check(err == nil, fmt.Sprintf("%s.trusted_proxies[%d]", path, i), "invalid network %q", s)
+  }
+
+  v.nonNegative(c.ReloadInterval, path + ".reload_interval")
+}
+
+func (c ConfigBan) validate(v *validator, path string) {
+  v.nonNegative(c.Threshold, path + ".threshold")
+  if c.Threshold <= 0 {
+    return
+  }
+
+  v.positive(c.Window, path + ".window")
+  v.positive(c.BaseDuration, path + ".base_duration")
+  v.check(c.Factor >= 1, path + ".factor", "must be at least 1, got %v", c.Factor)
+  v.check(c.MaxDuration >= c.BaseDuration, path + ".max_duration",
+    "must not be less than base_duration %d, got %d", c.BaseDuration, c.MaxDuration)
+  v.positive(c.CleanupInterval, path + ".cleanup_interval")
+}
+
+func (c ConfigAdmin) validate(v *validator, path string) {
+  if c.Enabled {
+    v.check(c.Port > 0, path + ".port", "must be set when admin is enabled")
+  }
+  if c.Enabled || c.GrpcService {
+    v.check(len(c.Token) > 0, path + ".token", "must be set, empty token reject every request")
+  }
+}
+
+func (c ConfigMetrics) validate(v *validator, path string) {
+  if !c.Enabled {
+    return
+  }
+
+  v.check(c.Port > 0, path + ".port", "must be set when metrics is enabled")
+  v.check(strings.HasPrefix(c.Path, "/"), path + ".path", "must start with \"/\", got %q", c.Path)
+}
+
+func (c ConfigTracing) validate(v *validator, path string) {
+  if !c.Enabled {
+    return
+  }
+
+  v.check(len(c.Endpoint) > 0, path + ".endpoint", "must be set when tracing is enabled")
+  v.check(c.SampleRatio >= 0 && c.SampleRatio <= 1, path + ".sample_ratio",
+    "must be between 0 and 1, got %v", c.SampleRatio)
+}
+
+func (c ConfigLog) validate(v *validator, path string) {
+  switch strings.ToLower(c.Level) {
+  case "", "debug", "info", "warn", "error":
+  default:
+    v.check(false, path + ".level", "expected debug, info, warn or error, got %q", c.Level)
+  }
+
+  switch strings.ToLower(c.Format) {
+  case "", "text", "json":
+  default:
+    v.check(false, path + ".format", "expected text or json, got %q", c.Format)
+  }
+
+  v.nonNegative(c.RejectionSampleInterval, path + ".rejection_sample_interval")
+  v.nonNegative(c.RejectionSampleBurst, path + ".rejection_sample_burst")
+}
+
+func (c ConfigListener) validate(v *validator, path string) {
+  v.check(c.Port > 0, path + ".port", "must be set")
+}
+
+func (c ConfigConnLimit) validate(v *validator, path string) {
+  v.nonNegative(c.MaxConn, path + ".max_conn")
+  v.nonNegative(c.MaxConnPerIp, path + ".max_conn_per_ip")
+  if c.MaxConn > 0 && c.MaxConnPerIp > 0 {
+    v.check(c.MaxConnPerIp <= c.MaxConn, path + ".max_conn_per_ip",
+      "must not be greater than max_conn %d, got %d", c.MaxConn, c.MaxConnPerIp)
+  }
+}
+
+func (c ConfigBandwidth) validate(v *validator, path string) {
+  v.nonNegative(c.UploadPerIp, path + ".upload_per_ip")
+  v.nonNegative(c.DownloadPerIp, path + ".download_per_ip")
+  v.nonNegative(c.Upload, path + ".upload")
+  v.nonNegative(c.Download, path + ".download")
+}
+
+func (c ConfigAdaptive) validate(v *validator, path string) {
+  if !c.Enabled {
+    return
+  }
+
+  v.positive(c.MinLimit, path + ".min_limit")
+  v.check(c.MaxLimit >= c.MinLimit, path + ".max_limit",
+    "must not be less than min_limit %d, got %d", c.MinLimit, c.MaxLimit)
+  v.positiveDuration(c.TargetLatency, path + ".target_latency")
+  v.check(c.Backoff > 0 && c.Backoff < 1, path + ".backoff", "must be between 0 and 1, got %v", c.Backoff)
+}
+
+func (c ConfigFairQueue) validate(v *validator, path string) {
+  if !c.Enabled {
+    return
+  }
+
+  v.positive(c.MaxInFlight, path + ".max_in_flight")
+  v.nonNegative(c.QueueSize, path + ".queue_size")
+  v.nonNegativeDuration(c.MaxWait, path + ".max_wait")
+  for tenant, class := range c.Tenants {
+    v.check(isPriority(class), fmt.Sprintf("%s.tenants[%q]", path, tenant),
+      "must be \"low\", \"normal\" or \"high\", got %q", class)
+  }
+  for class, weight := range c.Weights {
+    field := fmt.Sprintf("%s.weights[%q]", path, class)
+    v.check(isPriority(class), field, "must be keyed by \"low\", \"normal\" or \"high\"")
+    v.check(weight > 0, field, "must be positive, got %v", weight)
+  }
+}
+
+// @return bool - whether name is "low", "normal" or "high", same as pkg.ParsePriority
+func isPriority(name string) bool {
+  switch strings.ToLower(strings.TrimSpace(name)) {
+  case "low", "normal", "high":
+    return true
+  }
+
+  return false
+}
+
+func (c ConfigAuthz) validate(v *validator, path string) {
+  if !c.Enabled {
+    return
+  }
+
+  v.check(strings.HasPrefix(c.Path, "/") && !strings.HasSuffix(c.Path, "/"), path + ".path",
+    "must start and not end with \"/\", got %q", c.Path)
+  v.check(c.RejectStatus >= 400 && c.RejectStatus <= 499, path + ".reject_status",
+    "must be a 4xx status, got %d", c.RejectStatus)
+
+  _, err := pkg_http_limiter.ParseRoutes(c.Routes)
+  v.check(err == nil, path + ".routes", "%v", err)
+}
+
+func (c ConfigRls) validate(v *validator, path string) {
+  if !c.Enabled {
+    return
+  }
+
+  v.check(len(c.Policies) > 0, path + ".policies", "at least one policy must be set when rls is enabled")
+  names := map[string]bool{}
+  for i, p := range c.Policies {
+    field := fmt.Sprintf("%s.policies[%d]", path, i)
+
+    v.check(len(p.Name) > 0, field + ".name", "must be set")
+    v.check(!names[p.Name], field + ".name", "duplicate policy name %q", p.Name)
+    v.check(p.Rate.IsSet(), field + ".rate", "must be set, e.g. \"100/min\"")
+    for key := range p.Match {
+      v.check(len(key) > 0, field + ".match", "descriptor key must not be empty")
+    }
+    names[p.Name] = true
+  }
+}
+
+func (c ConfigHeaderRewrite) validate(v *validator, path string) {
+  for name := range c.Set {
+    v.check(len(strings.TrimSpace(name)) > 0, path + ".set", "header name must not be empty")
+  }
+  for i, name := range c.Remove {
+    v.check(len(strings.TrimSpace(name)) > 0, fmt.Sprintf("%s.remove[%d]", path, i), "header name must not be empty")
+  }
+}
+
+func (c ConfigHealthCheck) validate(v *validator, path string) {
+  v.nonNegativeDuration(c.Interval, path + ".interval")
+  v.nonNegativeDuration(c.Timeout, path + ".timeout")
+  if c.Interval > 0 {
+    v.check(strings.HasPrefix(c.Path, "/"), path + ".path", "must start with \"/\", got %q", c.Path)
+  }
+}
+
+func (c ConfigRoute) validate(v *validator, path string) {
+  v.check(len(c.Name) > 0, path + ".name", "must be set")
+  v.check(strings.HasPrefix(c.Prefix, "/"), path + ".prefix", "must start with \"/\", got %q", c.Prefix)
+
+  u, err := url.Parse(c.Upstream)
+  v.check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && len(u.Host) > 0,
+    path + ".upstream", "expected absolute http or https url, got %q", c.Upstream)
+
+  c.RequestHeaders.validate(v, path + ".request_headers")
+  c.ResponseHeaders.validate(v, path + ".response_headers")
+  c.HealthCheck.validate(v, path + ".health_check")
+}
+
+// --------------------------------------------------------- //
+
+// @brief check every field, not only the first invalid one
+//
+// @return error - *ValidationError or nil
+func (cfg ConfigServerHttp) Validate() error {
+  v := &validator{}
+
+  cfg.Listener.validate(v, "listener")
+  cfg.Limiter.validate(v, "limiter")
+  cfg.Access.validate(v, "access")
+  cfg.Ban.validate(v, "ban")
+  cfg.Admin.validate(v, "admin")
+  cfg.Metrics.validate(v, "metrics")
+  cfg.Tracing.validate(v, "tracing")
+  cfg.Log.validate(v, "log")
+  cfg.Authz.validate(v, "authz")
+  cfg.ConnLimit.validate(v, "conn_limit")
+  cfg.Bandwidth.validate(v, "bandwidth")
+  cfg.Adaptive.validate(v, "adaptive")
+  cfg.FairQueue.validate(v, "fair_queue")
+
+  v.nonNegative(cfg.Server.IdleTimeout, "server.idle_timeout")
+  v.nonNegative(cfg.Server.ReadTimeout, "server.read_timeout")
+  v.nonNegative(cfg.Server.WriteTimeout, "server.write_timeout")
+  v.nonNegative(cfg.Server.ShutdownTimeout, "server.shutdown_timeout")
+
+  return v.err()
+}
+
+// @brief check every field, not only the first invalid one
+//
+// @return error - *ValidationError or nil
+func (cfg ConfigServerGrpc) Validate() error {
+  v := &validator{}
+
+  cfg.Listener.validate(v, "listener")
+  cfg.Limiter.validate(v, "limiter")
+  cfg.Access.validate(v, "access")
+  cfg.Ban.validate(v, "ban")
+  cfg.Admin.validate(v, "admin")
+  cfg.Metrics.validate(v, "metrics")
+  cfg.Tracing.validate(v, "tracing")
+  cfg.Log.validate(v, "log")
+  cfg.Rls.validate(v, "rls")
+  cfg.ConnLimit.validate(v, "conn_limit")
+  cfg.Bandwidth.validate(v, "bandwidth")
+  cfg.Adaptive.validate(v, "adaptive")
+  cfg.FairQueue.validate(v, "fair_queue")
+
+  v.nonNegative(cfg.Server.ShutdownTimeout, "server.shutdown_timeout")
+
+  return v.err()
+}
+
+// @brief check every field, not only the first invalid one
+//
+// @return error - *ValidationError or nil
+func (cfg ConfigServerUnified) Validate() error {
+  v := &validator{}
+
+  cfg.Listener.validate(v, "listener")
+  if !cfg.Multiplexed() {
+    v.check(cfg.GrpcListener.Port != cfg.Listener.Port || cfg.GrpcListener.Address != cfg.Listener.Address,
+      "grpc_listener.port", "must differ from listener.port, use 0 to multiplex on listener")
+  }
+  cfg.Limiter.validate(v, "limiter")
+  cfg.Access.validate(v, "access")
+  cfg.Ban.validate(v, "ban")
+  cfg.Admin.validate(v, "admin")
+  v.check(!(cfg.ShareLimiter && cfg.Admin.GrpcService), "admin.grpc_service",
+    "not supported with share_limiter, use admin http api instead")
+  cfg.Metrics.validate(v, "metrics")
+  cfg.Tracing.validate(v, "tracing")
+  cfg.Log.validate(v, "log")
+
+  v.nonNegative(cfg.Server.IdleTimeout, "server.idle_timeout")
+  v.nonNegative(cfg.Server.ReadTimeout, "server.read_timeout")
+  v.nonNegative(cfg.Server.WriteTimeout, "server.write_timeout")
+  v.nonNegative(cfg.Server.ShutdownTimeout, "server.shutdown_timeout")
+
+  return v.err()
+}
+
+// @brief check every field, not only the first invalid one
+//
+// @return error - *ValidationError or nil
+func (cfg ConfigServerGateway) Validate() error {
+  v := &validator{}
+
+  cfg.Listener.validate(v, "listener")
+  cfg.Limiter.validate(v, "limiter")
+
+  v.check(len(cfg.Routes) > 0, "routes", "at least one route must be set")
+  names := map[string]bool{}
+  prefixes := map[string]bool{}
+  for i, route := range cfg.Routes {
+    path := fmt.Sprintf("routes[%d]", i)
+    route.validate(v, path)
+
+    v.check(!names[route.Name], path + ".name", "duplicate route name %q", route.Name)
+    v.check(!prefixes[route.Prefix], path + ".prefix", "duplicate route prefix %q", route.Prefix)
+    names[route.Name], prefixes[route.Prefix] = true, true
+  }
+
+  cfg.Access.validate(v, "access")
+  cfg.Ban.validate(v, "ban")
+  cfg.Admin.validate(v, "admin")
+  v.check(!cfg.Admin.GrpcService, "admin.grpc_service", "not supported by gateway, use admin http api instead")
+  cfg.Metrics.validate(v, "metrics")
+  cfg.Tracing.validate(v, "tracing")
+  cfg.Log.validate(v, "log")
+
+  v.nonNegative(cfg.Server.IdleTimeout, "server.idle_timeout")
+  v.nonNegative(cfg.Server.ReadTimeout, "server.read_timeout")
+  v.nonNegative(cfg.Server.WriteTimeout, "server.write_timeout")
+  v.nonNegative(cfg.Server.ShutdownTimeout, "server.shutdown_timeout")
+
+  return v.err()
+}
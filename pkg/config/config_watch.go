@@ -0,0 +1,73 @@
+package pkg_config
+
+import (
+  "context"
+  "os"
+  "time"
+
+  pkg_logging "github.com/prothegee/network-limiter-go/pkg/logging"
+)
+
+// @brief reload config file when its modification time or size change, or on reload signal
+//
+// @note apply error keep the old config in place, file is read again on next change or signal
+//
+// @param ctx context.Context - cancel to stop the loop
+//
+// @param fp string - config file path
+//
+// @param d time.Duration - polling interval, 0 only reload on signal
+//
+// @param reload <-chan os.Signal - e.g. SIGHUP from signal.Notify, nil to skip
+//
+// @param apply func(content []byte) error - parse, validate and apply new config
+func WatchConfig(ctx context.Context, fp string, d time.Duration, reload <-chan os.Signal, apply func(content []byte) error) {
+  logger := pkg_logging.OrDefault(Logger)
+
+  var tick <-chan time.Time
+  if d > 0 {
+    ticker := time.NewTicker(d)
+    defer ticker.Stop()
+    tick = ticker.C
+  }
+
+  var modTime time.Time
+  var size int64
+  if info, err := os.Stat(fp); err == nil {
+    modTime, size = info.ModTime(), info.Size()
+  }
+
+  for {
+    select {
+    case <-ctx.Done():
+      return
+    case <-reload:
+      // so polling won't apply the same change again
+      if info, err := os.Stat(fp); err == nil {
+        modTime, size = info.ModTime(), info.Size()
+      }
+    case <-tick:
+      info, err := os.Stat(fp); if err != nil {
+        logger.Warn("can't stat config file", "path", fp, "error", err)
+        continue
+      }
+
+      if info.ModTime().Equal(modTime) && info.Size() == size {
+        continue
+      }
+      modTime, size = info.ModTime(), info.Size()
+    }
+
+    content, err := os.ReadFile(fp); if err != nil {
+      logger.Warn("can't read config file, keep old config", "path", fp, "error", err)
+      continue
+    }
+
+    if err := apply(content); err != nil {
+      logger.Warn("config reload rejected, keep old config", "path", fp, "error", err)
+      continue
+    }
+
+    logger.Info("config reloaded", "path", fp)
+  }
+}
@@ -0,0 +1,163 @@
+package pkg_gateway
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strings"
+
+	pkg_http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	pkg_logging "github.com/prothegee/network-limiter-go/pkg/logging"
+)
+
+// @brief header rewrite, Remove is applied before Set
+type HeaderRewrite struct {
+	Set map[string]string
+	Remove []string
+}
+
+func (h HeaderRewrite) apply(header http.Header) {
+	for _, name := range h.Remove {
+		header.Del(name)
+	}
+	for name, value := range h.Set {
+		header.Set(name, value)
+	}
+}
+
+// @brief one upstream behind gateway
+type Route struct {
+	Name string
+	Prefix string // matched on path segment, "/api" match "/api" and "/api/x" but not "/apix"
+	Upstream *url.URL
+	StripPrefix bool // remove Prefix before joining Upstream path
+	RequestHeaders HeaderRewrite
+	ResponseHeaders HeaderRewrite
+	Middleware *pkg_http_limiter.HttpMiddleware // optional limiter in front of upstream, nil to skip
+	Health *HealthCheck // optional, nil is always healthy
+	handler http.Handler
+}
+
+// @brief whether path fall under route prefix
+func (rt *Route) Match(path string) bool {
+	prefix := strings.TrimSuffix(rt.Prefix, "/")
+	if len(prefix) <= 0 {
+		return true
+	}
+
+	return path == prefix || strings.HasPrefix(path, prefix + "/")
+}
+
+// @brief reverse proxy to upstream, keep incoming X-Forwarded-For chain
+func (rt *Route) proxy(logger *slog.Logger) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if rt.StripPrefix {
+				pr.Out.URL.Path = rt.strip(pr.Out.URL.Path)
+				pr.Out.URL.RawPath = rt.strip(pr.Out.URL.RawPath)
+			}
+
+			pr.Out.Header["X-Forwarded-For"] = pr.In.Header["X-Forwarded-For"]
+			pr.SetXForwarded()
+			pr.SetURL(rt.Upstream)
+
+			rt.RequestHeaders.apply(pr.Out.Header)
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			rt.ResponseHeaders.apply(resp.Header)
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			logger.Warn("upstream request failed", "route", rt.Name,
+				"upstream", rt.Upstream.String(), "error", err)
+			http.Error(w, "Bad Gateway", http.StatusBadGateway)
+		},
+	}
+}
+
+func (rt *Route) strip(path string) string {
+	if len(path) <= 0 {
+		return path
+	}
+
+	path = strings.TrimPrefix(path, strings.TrimSuffix(rt.Prefix, "/"))
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return path
+}
+
+// --------------------------------------------------------- //
+
+// @brief route request by longest prefix to its upstream, each route behind its own limiter
+type Gateway struct {
+	Routes []*Route // sorted by longest prefix first
+	Logger *slog.Logger // optional, nil use slog.Default
+}
+
+// @brief create gateway, build reverse proxy for each route
+//
+// @note route Middleware.Policy is set to route name when empty
+//
+// @param routes []*Route
+//
+// @param logger *slog.Logger - nil use slog.Default
+//
+// @return *Gateway
+func NewGateway(routes []*Route, logger *slog.Logger) *Gateway {
+	g := &Gateway{
+		Routes: append([]*Route{}, routes...),
+		Logger: logger,
+	}
+
+	sort.SliceStable(g.Routes, func(i, j int) bool {
+		return len(g.Routes[i].Prefix) > len(g.Routes[j].Prefix)
+	})
+
+	for _, rt := range g.Routes {
+		var handler http.HandlerFunc = rt.proxy(pkg_logging.OrDefault(logger)).ServeHTTP
+		if rt.Middleware != nil {
+			if len(rt.Middleware.Policy) <= 0 {
+				rt.Middleware.Policy = rt.Name
+			}
+			handler = rt.Middleware.Limit(handler)
+		}
+
+		rt.handler = handler
+	}
+
+	return g
+}
+
+// @return *Route - route by name, nil when not found
+func (g *Gateway) Route(name string) *Route {
+	for _, rt := range g.Routes {
+		if rt.Name == name {
+			return rt
+		}
+	}
+
+	return nil
+}
+
+// @note unmatched path is 404, unhealthy upstream is 503 without counting toward limit
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, rt := range g.Routes {
+		if !rt.Match(r.URL.Path) {
+			continue
+		}
+
+		if !rt.Health.Healthy() {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		rt.handler.ServeHTTP(w, r)
+		return
+	}
+
+	http.NotFound(w, r)
+}
@@ -0,0 +1,101 @@
+package pkg_gateway
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	pkg_logging "github.com/prothegee/network-limiter-go/pkg/logging"
+)
+
+// @brief active upstream health, healthy until the first failed probe
+type HealthCheck struct {
+	URL string // full probe url, e.g. "http://127.0.0.1:8080/healthz"
+	Timeout time.Duration
+	Client *http.Client // optional, nil use http.DefaultClient
+	Logger *slog.Logger // optional, nil use slog.Default
+	unhealthy atomic.Bool
+}
+
+// @brief create new health check
+//
+// @param url string - probe url, 2xx and 3xx response is healthy
+//
+// @param timeout time.Duration - per probe timeout
+//
+// @return *HealthCheck
+func NewHealthCheck(url string, timeout time.Duration) *HealthCheck {
+	return &HealthCheck{
+		URL: url,
+		Timeout: timeout,
+	}
+}
+
+// @note nil HealthCheck is always healthy
+func (h *HealthCheck) Healthy() bool {
+	return h == nil || !h.unhealthy.Load()
+}
+
+// @brief probe upstream once and store the result
+//
+// @return bool - healthy
+func (h *HealthCheck) Check(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	healthy := false
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil); if err == nil {
+		var resp *http.Response
+		resp, err = client.Do(req); if err == nil {
+			resp.Body.Close()
+			healthy = resp.StatusCode >= 200 && resp.StatusCode < 400
+			if !healthy {
+				err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+			}
+		}
+	}
+
+	logger := pkg_logging.OrDefault(h.Logger)
+	if h.unhealthy.Swap(!healthy) == healthy {
+		if healthy {
+			logger.Info("upstream healthy again", "url", h.URL)
+		} else {
+			logger.Warn("upstream unhealthy", "url", h.URL, "error", err)
+		}
+	}
+
+	return healthy
+}
+
+// @brief probe upstream on each tick, first probe run immediately
+//
+// @param ctx context.Context - cancel to stop the loop
+//
+// @param h *HealthCheck
+//
+// @param d time.Duration - probe interval
+//
+// @param opts ...gen.Option - optional setting, e.g. gen.WithClock
+func WatchHealth(ctx context.Context, h *HealthCheck, d time.Duration, opts ...gen.Option) {
+	ticker := gen.ApplyOptions(opts...).ClockOr(nil).NewTicker(d)
+	defer ticker.Stop()
+
+	for {
+		h.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package pkg_grpc_limiter
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	pkg_ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type GrpcAdminServer struct {
+	pb.UnimplementedRateLimiterAdminServer
+	Token string
+	Limiter *GrpcRateLimiter
+	BanBox *pkg_ban.BanBox // optional, nil when ban is disabled
+}
+
+// @brief create new RateLimiterAdmin service implementation
+//
+// @param token string - required "authorization: Bearer <token>" metadata, empty token reject every call
+//
+// @param limiter *GrpcRateLimiter
+//
+// @param box *pkg_ban.BanBox - nil when ban is disabled
+//
+// @return *GrpcAdminServer
+func NewGrpcAdminServer(token string, limiter *GrpcRateLimiter, box *pkg_ban.BanBox) *GrpcAdminServer {
+	return &GrpcAdminServer{
+		Token: token,
+		Limiter: limiter,
+		BanBox: box,
+	}
+}
+
+func (s *GrpcAdminServer) authorize(ctx context.Context) error {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(s.Token) > 0 {
+		if auth := md.Get("authorization"); len(auth) > 0 {
+			token, ok := strings.CutPrefix(auth[0], "Bearer ")
+			if ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1 {
+				return nil
+			}
+		}
+	}
+
+	return status.Error(codes.Unauthenticated, "Unauthenticated; Valid Admin Token Required")
+}
+
+func (s *GrpcAdminServer) ListKeys(ctx context.Context,
+								  req *pb.ListKeysReq) (*pb.ListKeysResp, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListKeysResp{}
+	for _, state := range s.Limiter.ListKeys() {
+		if strings.HasPrefix(state.Key, req.GetPrefix()) {
+			resp.Keys = append(resp.Keys, keyStateToPb(state))
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *GrpcAdminServer) GetKeyState(ctx context.Context,
+									 req *pb.GetKeyStateReq) (*pb.KeyState, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	state, ok := s.Limiter.GetKeyState(req.GetKey()); if !ok {
+		return nil, status.Errorf(codes.NotFound, "key %q is not tracked", req.GetKey())
+	}
+
+	return keyStateToPb(state), nil
+}
+
+func (s *GrpcAdminServer) ResetKey(ctx context.Context,
+								  req *pb.ResetKeyReq) (*pb.ResetKeyResp, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.GetPrefix() {
+		return &pb.ResetKeyResp{ResetCount: int64(s.Limiter.ResetPrefix(req.GetKey()))}, nil
+	}
+
+	if _, ok := s.Limiter.GetKeyState(req.GetKey()); !ok {
+		return nil, status.Errorf(codes.NotFound, "key %q is not tracked", req.GetKey())
+	}
+	s.Limiter.ResetIP(req.GetKey())
+
+	return &pb.ResetKeyResp{ResetCount: 1}, nil
+}
+
+func (s *GrpcAdminServer) ListBans(ctx context.Context,
+								  req *pb.ListBansReq) (*pb.ListBansResp, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.BanBox == nil {
+		return nil, status.Error(codes.Unimplemented, "ban is disabled")
+	}
+
+	resp := &pb.ListBansResp{}
+	for _, ban := range s.BanBox.List() {
+		resp.Bans = append(resp.Bans, banToPb(ban))
+	}
+
+	return resp, nil
+}
+
+func (s *GrpcAdminServer) Ban(ctx context.Context,
+							 req *pb.BanReq) (*pb.BanEntry, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.BanBox == nil {
+		return nil, status.Error(codes.Unimplemented, "ban is disabled")
+	}
+	if len(req.GetKey()) <= 0 || req.GetDuration().AsDuration() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "key is required and duration can't be negative")
+	}
+
+	return banToPb(s.BanBox.Ban(req.GetKey(), req.GetDuration().AsDuration())), nil
+}
+
+func (s *GrpcAdminServer) Unban(ctx context.Context,
+							   req *pb.UnbanReq) (*pb.UnbanResp, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.BanBox == nil {
+		return nil, status.Error(codes.Unimplemented, "ban is disabled")
+	}
+
+	return &pb.UnbanResp{Ok: s.BanBox.Lift(req.GetKey())}, nil
+}
+
+func (s *GrpcAdminServer) UpdatePolicy(ctx context.Context,
+									  req *pb.Policy) (*pb.Policy, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if req.GetMaxRequests() <= 0 || req.GetDuration().AsDuration() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "max_requests and duration must be positive")
+	}
+
+	s.Limiter.UpdatePolicy(uint(req.GetMaxRequests()), req.GetDuration().AsDuration())
+
+	maxReq, duration := s.Limiter.Policy()
+
+	return &pb.Policy{
+		MaxRequests: uint64(maxReq),
+		Duration: durationpb.New(duration),
+	}, nil
+}
+
+func keyStateToPb(state gen.KeyState) *pb.KeyState {
+	methods := make(map[string]int64, len(state.Methods))
+	for method, count := range state.Methods {
+		methods[method] = int64(count)
+	}
+
+	return &pb.KeyState{
+		Key: state.Key,
+		Count: int64(state.Count),
+		Limit: uint64(state.Limit),
+		Methods: methods,
+		ResetAt: timestamppb.New(state.ResetAt),
+	}
+}
+
+func banToPb(ban pkg_ban.Ban) *pb.BanEntry {
+	return &pb.BanEntry{
+		Key: ban.Key,
+		Until: timestamppb.New(ban.Until),
+		Offence: int64(ban.Offence),
+	}
+}
@@ -0,0 +1,69 @@
+package pkg_grpc_limiter
+
+import (
+	"context"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// @brief request & response message throughput cap per client ip, counted by proto.Size
+//
+// @note over budget call is delayed, never refused, only client deadline can end the wait
+type GrpcBandwidth struct {
+	Upload *gen.BandwidthLimiter // request message, nil unlimited
+	Download *gen.BandwidthLimiter // response message, nil unlimited
+}
+
+// @brief create new message bandwidth interceptor
+//
+// @param upload *gen.BandwidthLimiter - request message, nil unlimited
+//
+// @param download *gen.BandwidthLimiter - response message, nil unlimited
+//
+// @return *GrpcBandwidth
+func NewGrpcBandwidth(upload, download *gen.BandwidthLimiter) *GrpcBandwidth {
+	return &GrpcBandwidth{
+		Upload: upload,
+		Download: download,
+	}
+}
+
+func (m *GrpcBandwidth) Limit() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ip := clientIp(ctx)
+
+		if err := pace(ctx, m.Upload, ip, req); err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req); if err != nil {
+			return resp, err
+		}
+
+		if err := pace(ctx, m.Download, ip, resp); err != nil {
+			return nil, err
+		}
+
+		return resp, nil
+	}
+}
+
+// @brief wait for message size on limiter, non proto message and nil limiter is free
+func pace(ctx context.Context, l *gen.BandwidthLimiter, ip string, msg any) error {
+	if l == nil {
+		return nil
+	}
+
+	m, ok := msg.(proto.Message); if !ok {
+		return nil
+	}
+
+	if err := l.WaitN(ctx, ip, proto.Size(m)); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	return nil
+}
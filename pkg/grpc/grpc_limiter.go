@@ -2,17 +2,25 @@ package pkg_grpc_limiter
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net"
+	"log/slog"
+	"math"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	pkg_ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	pkg_cidr "github.com/prothegee/network-limiter-go/pkg/cidr"
+	pkg_logging "github.com/prothegee/network-limiter-go/pkg/logging"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 type GrpcRateLimiter struct {
@@ -20,6 +28,9 @@ type GrpcRateLimiter struct {
 	Requests map[string]map[string][]time.Time // ip / function/method name / timestamp
 	MaxRequests uint
 	Duration time.Duration
+	OnCleanup func(elapsed time.Duration) // optional, called after each cleanup round
+	Logger *slog.Logger // optional, nil use slog.Default
+	Clock gen.Clock // optional, nil use gen.SystemClock
 }
 
 // @brief create new internal grpc limiter
@@ -28,20 +39,45 @@ type GrpcRateLimiter struct {
 //
 // @param duration time.Duration - wind time duration limiter
 //
+// @param opts ...gen.Option - optional setting, e.g. gen.WithClock
+//
 // @return *NewGrpcRateLimiter
-func NewGrpcRateLimiter(maxReq uint, duration time.Duration) *GrpcRateLimiter {
+func NewGrpcRateLimiter(maxReq uint, duration time.Duration, opts ...gen.Option) *GrpcRateLimiter {
+	o := gen.ApplyOptions(opts...)
+
 	return &GrpcRateLimiter{
 		Requests: make(map[string]map[string][]time.Time),
 		MaxRequests: maxReq,
 		Duration: duration,
+		Clock: o.Clock,
 	}
 }
 
+// @return time.Time - current time from limiter clock
+func (lmtr *GrpcRateLimiter) Now() time.Time {
+	return gen.OrSystemClock(lmtr.Clock).Now()
+}
+
 func (lmtr *GrpcRateLimiter) CheckRequestLimit(ip, method string) bool {
+	_, ok := lmtr.CheckRequestLimitState(ip, method)
+	return ok
+}
+
+// @brief same as CheckRequestLimit, also give ip & method state right after the check
+//
+// @return gen.KeyState, bool - state and whether request is allowed
+func (lmtr *GrpcRateLimiter) CheckRequestLimitState(ip, method string) (gen.KeyState, bool) {
+	return lmtr.CheckRequestLimitStateN(ip, method, 1)
+}
+
+// @brief same as CheckRequestLimitState, call count as n unit, all or nothing
+//
+// @note n over max request is always rejected, same as AllowN
+func (lmtr *GrpcRateLimiter) CheckRequestLimitStateN(ip, method string, n int) (gen.KeyState, bool) {
 	lmtr.Mtx.Lock()
 	defer lmtr.Mtx.Unlock()
 
-	now := time.Now()
+	now := lmtr.Now()
 
 	// // #1st attempt
 	// // crash:
@@ -75,26 +111,52 @@ func (lmtr *GrpcRateLimiter) CheckRequestLimit(ip, method string) bool {
 		}
 	}
 
-	if len(validRequests) >= int(lmtr.MaxRequests) {
-		return false
+	n = max(n, 1)
+	if len(validRequests) + n > int(lmtr.MaxRequests) {
+		return lmtr.keyState(ip, map[string][]time.Time{method: validRequests}, now), false
 	}
 
-	validRequests = append(validRequests, now)
+	for i := 0; i < n; i++ {
+		validRequests = append(validRequests, now)
+	}
 	lmtr.Requests[ip][method] = validRequests
 
-	return true
+	return lmtr.keyState(ip, map[string][]time.Time{method: validRequests}, now), true
+}
+
+// @return int - number of tracked ip
+func (lmtr *GrpcRateLimiter) KeyCount() int {
+	lmtr.Mtx.RLock()
+	defer lmtr.Mtx.RUnlock()
+
+	return len(lmtr.Requests)
 }
 
+// @param ctx context.Context - cancel to stop the loop
+//
 // @param lmtr *GrpcRateLimiter
 //
 // @param d time.Duration
-func CleanupOldRequest(lmtr *GrpcRateLimiter, d time.Duration) {
-	ticker := time.NewTicker(d)
+//
+// @param opts ...gen.Option - optional setting, clock default to limiter clock
+func CleanupOldRequest(ctx context.Context, lmtr *GrpcRateLimiter, d time.Duration, opts ...gen.Option) {
+	clock := gen.ApplyOptions(opts...).ClockOr(lmtr.Clock)
+
+	ticker := clock.NewTicker(d)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+
+		start := time.Now()
+
 		lmtr.Mtx.Lock()
-		now := time.Now()
+		now := clock.Now()
+		removed := 0
 
 		for ip, methods := range lmtr.Requests {
 			for method, timestamps := range methods {
@@ -114,13 +176,53 @@ func CleanupOldRequest(lmtr *GrpcRateLimiter, d time.Duration) {
 
 			if len(methods) == 0 {
 				delete(lmtr.Requests, ip)
+				removed++
 			}
 		}
 
+		tracked := len(lmtr.Requests)
 		lmtr.Mtx.Unlock()
+
+		pkg_logging.OrDefault(lmtr.Logger).Debug("grpc limiter cleanup",
+			"removed", removed, "tracked", tracked, "elapsed", time.Since(start))
+
+		if lmtr.OnCleanup != nil {
+			lmtr.OnCleanup(time.Since(start))
+		}
 	}
 }
 
+// @brief block until ip is allowed to call method or ctx is done
+//
+// @return gen.KeyState, error - state of the allowed check, or last rejected state with ctx error
+func (lmtr *GrpcRateLimiter) Wait(ctx context.Context, ip, method string) (gen.KeyState, error) {
+	return gen.WaitKey(ctx, lmtr.Method(method), ip, lmtr.Clock)
+}
+
+// @brief same as Wait for n unit at once
+func (lmtr *GrpcRateLimiter) WaitN(ctx context.Context, ip, method string, n int) (gen.KeyState, error) {
+	return gen.WaitKeyN(ctx, lmtr.Method(method), ip, n, lmtr.Clock)
+}
+
+// @brief change max request and window duration at once
+//
+// @note tracked request is kept, new policy apply from next check
+func (lmtr *GrpcRateLimiter) UpdatePolicy(maxReq uint, duration time.Duration) {
+	lmtr.Mtx.Lock()
+	defer lmtr.Mtx.Unlock()
+
+	lmtr.MaxRequests = maxReq
+	lmtr.Duration = duration
+}
+
+// @return uint, time.Duration - current max request and window duration
+func (lmtr *GrpcRateLimiter) Policy() (uint, time.Duration) {
+	lmtr.Mtx.RLock()
+	defer lmtr.Mtx.RUnlock()
+
+	return lmtr.MaxRequests, lmtr.Duration
+}
+
 func (rl *GrpcRateLimiter) GetRequestCount(ip, method string) int {
 	rl.Mtx.RLock()
 	defer rl.Mtx.RUnlock()
@@ -128,7 +230,7 @@ func (rl *GrpcRateLimiter) GetRequestCount(ip, method string) int {
 	if methods, ok := rl.Requests[ip]; ok {
 		if timestamps, ok := methods[method]; ok {
 			// count request in duration
-			now := time.Now()
+			now := rl.Now()
 			count := 0
 			for _, t := range timestamps {
 				if now.Sub(t) <= rl.Duration {
@@ -141,55 +243,296 @@ func (rl *GrpcRateLimiter) GetRequestCount(ip, method string) int {
 	return 0
 }
 
+// @brief snapshot of tracked ip state, count is summed over methods
+//
+// @return gen.KeyState, bool - false if ip isn't tracked
+func (rl *GrpcRateLimiter) GetKeyState(ip string) (gen.KeyState, bool) {
+	rl.Mtx.RLock()
+	defer rl.Mtx.RUnlock()
+
+	methods, ok := rl.Requests[ip]; if !ok {
+		return gen.KeyState{}, false
+	}
+
+	return rl.keyState(ip, methods, rl.Now()), true
+}
+
+// @brief snapshot of all tracked ip sorted by ip
+func (rl *GrpcRateLimiter) ListKeys() []gen.KeyState {
+	rl.Mtx.RLock()
+	defer rl.Mtx.RUnlock()
+
+	now := rl.Now()
+	states := make([]gen.KeyState, 0, len(rl.Requests))
+	for ip, methods := range rl.Requests {
+		states = append(states, rl.keyState(ip, methods, now))
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Key < states[j].Key
+	})
+
+	return states
+}
+
+func (rl *GrpcRateLimiter) keyState(ip string, methods map[string][]time.Time, now time.Time) gen.KeyState {
+	state := gen.KeyState{
+		Key: ip,
+		Limit: rl.MaxRequests,
+		Methods: make(map[string]int),
+	}
+
+	for method, timestamps := range methods {
+		for _, t := range timestamps {
+			if now.Sub(t) > rl.Duration {
+				continue
+			}
+			if state.Count == 0 || t.Add(rl.Duration).Before(state.ResetAt) {
+				state.ResetAt = t.Add(rl.Duration)
+			}
+			state.Count++
+			state.Methods[method]++
+		}
+	}
+
+	return state
+}
+
 // --------------------------------------------------------- //
 
 type GrpcMiddleware struct {
-	Limiter *GrpcRateLimiter
+	Limiter *GrpcRateLimiter // nil with nil Shared to skip, e.g. concurrency only
+	Concurrency *gen.ConcurrencyLimiter // optional in-flight cap per ip, slot is held until handler return but not while waiting in Queue, nil to skip
+	Queue *gen.WaitQueue // optional, over limit call wait for capacity before rejection, nil to reject at once
+	Costs *gen.CostTable // optional unit per full method, nil cost 1
+	CostFunc func(ctx context.Context, method string, req any) int // optional, win over Costs when it give positive cost, e.g. MessageSizeCost
+	Adaptive *gen.AdaptiveLimiter // optional global load shedding after per ip check, shed call get Unavailable, nil to skip
+	PriorityFunc func(ctx context.Context, method string) gen.Priority // optional, nil treat every call as gen.PriorityNormal
+	Fair *gen.FairQueue // optional weighted fair queuing between tenant right before handler, full queue get Unavailable, nil to skip
+	TenantFunc func(ctx context.Context, method string) string // optional, nil use client ip as tenant
+	Shared gen.KeyLimiter // optional, count per ip only instead of per method, e.g. limiter shared with http; Limiter is unused when set
+	Access *pkg_cidr.AccessList // optional allow/deny list checked on peer ip, forwarded ip only from its trusted proxy, nil to skip
+	Ban *pkg_ban.BanBox // optional temporary ban after repeated rejection, nil to skip
+	Policy string // policy name reported to observer, "default" when empty
+	Exempt []string // optional full method prefix passed through without any check, e.g. pb.RATE_LIMIT_SERVICE called by envoy
+	Observers []gen.DecisionObserver // optional metrics, tracing, logging hook
 }
 
 func NewGrpcMiddleware(limiter *GrpcRateLimiter) *GrpcMiddleware {
 	return &GrpcMiddleware{Limiter: limiter}
 }
 
-// @brief get client ip from middleware
+// @brief per method view of GrpcRateLimiter as gen.KeyLimiter
+type methodLimiter struct {
+	limiter *GrpcRateLimiter
+	method string
+}
+
+func (l methodLimiter) CheckRequestLimitState(ip string) (gen.KeyState, bool) {
+	return l.limiter.CheckRequestLimitState(ip, l.method)
+}
+
+func (l methodLimiter) CheckRequestLimitStateN(ip string, n int) (gen.KeyState, bool) {
+	return l.limiter.CheckRequestLimitStateN(ip, l.method, n)
+}
+
+func (l methodLimiter) Policy() (uint, time.Duration) {
+	return l.limiter.Policy()
+}
+
+func (l methodLimiter) Now() time.Time {
+	return l.limiter.Now()
+}
+
+func (l methodLimiter) Wait(ctx context.Context, ip string) (gen.KeyState, error) {
+	return l.limiter.Wait(ctx, ip, l.method)
+}
+
+func (l methodLimiter) WaitN(ctx context.Context, ip string, n int) (gen.KeyState, error) {
+	return l.limiter.WaitN(ctx, ip, l.method, n)
+}
+
+func (l methodLimiter) RefundN(ip string, n int) {
+	l.limiter.RefundN(ip, l.method, n)
+}
+
+// @brief per key view of one method, e.g. for pkg_listener connection rate
 //
-// @note empty string need to be handled correctly, otherwise it's panic
+// @return gen.KeyWaiter
+func (lmtr *GrpcRateLimiter) Method(method string) gen.KeyWaiter {
+	return methodLimiter{limiter: lmtr, method: method}
+}
+
+// @return gen.KeyLimiter - shared limiter when set, otherwise Limiter for method, nil when neither is set
+func (m *GrpcMiddleware) keyLimiter(method string) gen.KeyLimiter {
+	if m.Shared != nil {
+		return m.Shared
+	}
+	if m.Limiter == nil {
+		return nil
+	}
+
+	return m.Limiter.Method(method)
+}
+
+// @return int - call cost from CostFunc, then Costs by full method, 1 otherwise
+func (m *GrpcMiddleware) cost(ctx context.Context, method string, req any) int {
+	if m.CostFunc != nil {
+		if cost := m.CostFunc(ctx, method, req); cost > 0 {
+			return cost
+		}
+	}
+
+	return m.Costs.Cost(method)
+}
+
+// @brief take adaptive slot then fair queue slot for call priority, each one is skipped when nil
 //
-// @return string - "" is error/unknown
-func (m *GrpcMiddleware) ClientIP(ctx context.Context) string {
-	// try to get from x-real-ip and x-forwarded-for
-	if md, ok := metadata.FromIncomingContext(ctx); ok {
-		if realIp := md.Get("x-real-ip"); len(realIp) > 0 {
-			return realIp[0] // first index only
+// @return func(), bool - release func to defer and whether call is admitted
+func (m *GrpcMiddleware) admit(ctx context.Context, method string) (func(), bool) {
+	release := func() {}
+	p := m.priority(ctx, method)
+
+	if m.Adaptive != nil {
+		adaptive, _, ok := m.Adaptive.Acquire(p); if !ok {
+			return nil, false
+		}
+		release = adaptive
+	}
+
+	if m.Fair != nil {
+		fair, _, err := m.Fair.Acquire(ctx, m.tenant(ctx, method), p); if err != nil {
+			release()
+			return nil, false
+		}
+
+		adaptive := release
+		release = func() {
+			fair()
+			adaptive()
+		}
+	}
+
+	return release, true
+}
+
+// @return string - from TenantFunc, client ip when unset or empty
+func (m *GrpcMiddleware) tenant(ctx context.Context, method string) string {
+	if m.TenantFunc != nil {
+		if tenant := m.TenantFunc(ctx, method); len(tenant) > 0 {
+			return tenant
 		}
+	}
 
-		if forwarded := md.Get("x-forwarded-for"); len(forwarded) > 0 {
-			ips := strings.Split(forwarded[0], ",") // first index only
+	return clientIp(ctx)
+}
 
-			if len(ips) > 0 {
-				return strings.TrimSpace(ips[0])
+// @brief tenant from incoming metadata, e.g. api key or account id, missing fall back to client ip
+//
+// @note metadata is set by client, only trust it behind a proxy or auth layer that overwrite it
+func TenantFromMetadata(key string) func(ctx context.Context, method string) string {
+	return func(ctx context.Context, method string) string {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(key); len(values) > 0 && len(values[0]) > 0 {
+				return values[0]
 			}
 		}
+
+		return clientIp(ctx)
 	}
+}
 
-	// peer info
-	if pr, ok := peer.FromContext(ctx); ok {
-		if addr, ok := pr.Addr.(*net.TCPAddr); ok {
-			return addr.IP.String()
+// @brief priority class by tenant, e.g. paying account is high, others use fallback
+//
+// @param tenant func(ctx context.Context, method string) string - tenant of call, e.g. TenantFromMetadata
+//
+// @param classes map[string]gen.Priority - tenant / priority
+//
+// @param fallback func(ctx context.Context, method string) gen.Priority - optional, for tenant not in classes, nil is normal
+func PriorityByTenant(tenant func(ctx context.Context, method string) string, classes map[string]gen.Priority,
+	fallback func(ctx context.Context, method string) gen.Priority) func(ctx context.Context, method string) gen.Priority {
+	return func(ctx context.Context, method string) gen.Priority {
+		if p, ok := classes[tenant(ctx, method)]; ok {
+			return p
+		}
+		if fallback != nil {
+			return fallback(ctx, method)
 		}
 
-		// non tcp address
-		addrStr := pr.Addr.String()
+		return gen.PriorityNormal
+	}
+}
+
+// @return gen.Priority - from PriorityFunc, gen.PriorityNormal when unset
+func (m *GrpcMiddleware) priority(ctx context.Context, method string) gen.Priority {
+	if m.PriorityFunc == nil {
+		return gen.PriorityNormal
+	}
 
-		// parse as "ip:port"
-		if host, _, err := net.SplitHostPort(addrStr); err == nil {
-			return host
+	return m.PriorityFunc(ctx, method)
+}
+
+// @brief priority from incoming metadata value "low", "normal" or "high", anything else is normal
+//
+// @note metadata is set by client, only trust it behind a proxy that overwrite it
+func PriorityFromMetadata(key string) func(ctx context.Context, method string) gen.Priority {
+	return func(ctx context.Context, method string) gen.Priority {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(key); len(values) > 0 {
+				p, _ := gen.ParsePriority(values[0])
+				return p
+			}
 		}
 
-		return addrStr
+		return gen.PriorityNormal
 	}
+}
+
+// @brief cost from encoded message size, one unit per started unitBytes
+//
+// @note non proto message cost 1
+func MessageSizeCost(unitBytes int) func(ctx context.Context, method string, req any) int {
+	return func(ctx context.Context, method string, req any) int {
+		msg, ok := req.(proto.Message); if !ok || unitBytes <= 0 {
+			return 1
+		}
 
-	return ""
+		return max((proto.Size(msg) + unitBytes - 1) / unitBytes, 1)
+	}
+}
+
+// @brief get client ip from middleware
+//
+// @note empty string need to be handled correctly, otherwise it's panic
+//
+// @return string - "" is error/unknown
+func (m *GrpcMiddleware) ClientIP(ctx context.Context) string {
+	return clientIp(ctx)
+}
+
+// @brief x-real-ip, then first x-forwarded-for, then peer address, same as http
+func clientIp(ctx context.Context) string {
+	return pkg_cidr.ClientIP(clientAddr(ctx))
+}
+
+// @return string, string, string - x-real-ip, x-forwarded-for & peer address, "" when missing
+func clientAddr(ctx context.Context) (string, string, string) {
+	var realIp, forwardedFor, remoteAddr string
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-real-ip"); len(values) > 0 {
+			realIp = values[0] // first index only
+		}
+		if values := md.Get("x-forwarded-for"); len(values) > 0 {
+			forwardedFor = values[0]
+		}
+	}
+
+	if pr, ok := peer.FromContext(ctx); ok && pr.Addr != nil {
+		remoteAddr = pr.Addr.String()
+	}
+
+	return realIp, forwardedFor, remoteAddr
 }
 
 // @brief in-case of fire, helper for reset ip param
@@ -199,8 +542,48 @@ func (lmtr *GrpcRateLimiter) ResetIP(ip string) {
 	delete(lmtr.Requests, ip)
 }
 
+// @brief reset every ip starting with prefix, e.g. "10.0."
+//
+// @return int - number of reset ip
+func (lmtr *GrpcRateLimiter) ResetPrefix(prefix string) int {
+	lmtr.Mtx.Lock()
+	defer lmtr.Mtx.Unlock()
+
+	count := 0
+	for ip := range lmtr.Requests {
+		if strings.HasPrefix(ip, prefix) {
+			delete(lmtr.Requests, ip)
+			count++
+		}
+	}
+
+	return count
+}
+
+func (m *GrpcMiddleware) observe(ctx context.Context, d gen.Decision, start time.Time) {
+	if len(m.Observers) <= 0 {
+		return
+	}
+
+	if len(d.Policy) <= 0 {
+		d.Policy = "default"
+	}
+	d.Latency = time.Since(start)
+
+	for _, o := range m.Observers {
+		o.ObserveDecision(ctx, d)
+	}
+}
+
 func (m *GrpcMiddleware) Limit() grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		for _, prefix := range m.Exempt {
+			if strings.HasPrefix(info.FullMethod, prefix) {
+				return handler(ctx, req)
+			}
+		}
+
+		start := time.Now()
 		ip := m.ClientIP(ctx)
 
 		if len(ip) <= 0 {
@@ -210,19 +593,131 @@ func (m *GrpcMiddleware) Limit() grpc.UnaryServerInterceptor {
 		}
 
 		method := info.FullMethod
+		decision := gen.Decision{
+			Protocol: "grpc",
+			Policy: m.Policy,
+			Route: method,
+			Key: ip,
+		}
+
+		switch m.Access.Check(m.Access.PeerIP(clientAddr(ctx))) {
+		case pkg_cidr.DecisionDeny:
+			decision.Result = gen.ResultDenied
+			m.observe(ctx, decision, start)
+			return nil, status.Error(
+				codes.PermissionDenied,
+				"Permission Denied; IP Address Blocked")
+		case pkg_cidr.DecisionAllow:
+			decision.Result = gen.ResultExempt
+			m.observe(ctx, decision, start)
+			return handler(ctx, req)
+		}
+
+		if until, banned := m.Ban.IsBanned(ip); banned {
+			decision.Result = gen.ResultBanned
+			decision.RetryAfter = until.Sub(m.Ban.Now())
+			m.observe(ctx, decision, start)
+
+			grpc.SetTrailer(ctx, metadata.Pairs(
+				"retry-after", fmt.Sprintf("%d", int(math.Ceil(decision.RetryAfter.Seconds())))))
+			return nil, status.Errorf(
+				codes.ResourceExhausted,
+				"Rate limit exceeded; temporarily banned until %s",
+				until.UTC().Format(time.RFC3339))
+		}
+
+		rejectInFlight := func(state gen.KeyState) error {
+			decision.FromKeyState(state, start)
+			decision.Result = gen.ResultInFlight
+			m.observe(ctx, decision, start)
+
+			return status.Errorf(
+				codes.ResourceExhausted,
+				"Too many concurrent calls. Current: %d/%d in flight",
+				state.Count, state.Limit,
+			)
+		}
+
+		// slot first, so call rejected by in-flight cap doesn't use window quota
+		releaseSlot := func() {}
+		if m.Concurrency != nil {
+			slot, state, ok := m.Concurrency.Acquire(ip); if !ok {
+				return nil, rejectInFlight(state)
+			}
+			decision.FromKeyState(state, start)
+			releaseSlot = slot
+		}
+		defer func() { releaseSlot() }()
+
+		limiter := m.keyLimiter(method)
+		if limiter == nil {
+			release, ok := m.admit(ctx, method); if !ok {
+				decision.Result = gen.ResultShed
+				m.observe(ctx, decision, start)
+
+				return nil, status.Error(codes.Unavailable, "Service Unavailable; Server Overloaded")
+			}
+			defer release()
+
+			decision.Result = gen.ResultAllowed
+			m.observe(ctx, decision, start)
+
+			return handler(ctx, req)
+		}
+
+		maxReq, duration := limiter.Policy()
+
+		decision.Cost = m.cost(ctx, method, req)
+
+		state, ok := limiter.CheckRequestLimitStateN(ip, decision.Cost)
+		if waiter, canWait := limiter.(gen.KeyWaiter); !ok && canWait && m.Queue != nil {
+			// queued call isn't running, its slot is free while it wait
+			releaseSlot()
+			if waited, err := m.Queue.WaitN(ctx, waiter, ip, decision.Cost); !errors.Is(err, gen.ErrQueueFull) {
+				state, ok = waited, err == nil
+			}
+
+			if ok && m.Concurrency != nil {
+				slot, inFlight, acquired := m.Concurrency.Acquire(ip); if !acquired {
+					// slot is taken meanwhile, rejected call doesn't keep the quota it waited for
+					if refunder, canRefund := limiter.(gen.KeyRefunder); canRefund {
+						refunder.RefundN(ip, decision.Cost)
+					}
+					return nil, rejectInFlight(inFlight)
+				}
+				releaseSlot = slot
+			}
+		}
+		decision.FromKeyState(state, limiter.Now())
+
+		if !ok {
+			m.Ban.RecordRejection(ip)
+
+			decision.Result = gen.ResultRejected
+			m.observe(ctx, decision, start)
 
-		if !m.Limiter.CheckRequestLimit(ip, method) {
-			current := m.Limiter.GetRequestCount(ip, method)
 			return nil, status.Errorf(
 				codes.ResourceExhausted,
 				"Rate limit exceeded for %s. Current: %d/%d requests per %v",
-				method, current, m.Limiter.MaxRequests, m.Limiter.Duration,
+				method, state.Count, maxReq, duration,
 			)
 		}
 
+		// per ip limit pass first, overload only decide who of the rest is served
+		release, ok := m.admit(ctx, method); if !ok {
+			decision.Result = gen.ResultShed
+			m.observe(ctx, decision, start)
+
+			return nil, status.Error(codes.Unavailable, "Service Unavailable; Server Overloaded")
+		}
+		defer release()
+
+		decision.Result = gen.ResultAllowed
+		m.observe(ctx, decision, start)
+
 		header := metadata.Pairs(
-			"x-ratelimit-limit", fmt.Sprintf("%d", m.Limiter.MaxRequests),
-			"x-ratelimit-duration", m.Limiter.Duration.String(),
+			"x-ratelimit-limit", fmt.Sprintf("%d", maxReq),
+			"x-ratelimit-duration", duration.String(),
 			"x-ratelimit-ip", ip,
 			"x-ratelimit-method", method,
 		)
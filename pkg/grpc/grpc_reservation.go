@@ -0,0 +1,89 @@
+package pkg_grpc_limiter
+
+import (
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+)
+
+// @brief same as AllowN for 1 call at limiter clock now
+func (lmtr *GrpcRateLimiter) Allow(ip, method string) bool {
+	return lmtr.AllowN(ip, method, 1, lmtr.Now())
+}
+
+// @brief count n call at once when all of them fit, otherwise count nothing
+//
+// @param ip string
+//
+// @param method string - grpc full method, or any name for own code path
+//
+// @param n int - call number, e.g. batch size
+//
+// @param now time.Time - call time, usually limiter Now
+//
+// @return bool - whether n call is allowed
+func (lmtr *GrpcRateLimiter) AllowN(ip, method string, n int, now time.Time) bool {
+	lmtr.Mtx.Lock()
+	defer lmtr.Mtx.Unlock()
+
+	at, ok := gen.NextSlot(lmtr.methodRequests(ip, method), lmtr.MaxRequests, lmtr.Duration, n, now)
+	if !ok || at.After(now) {
+		return false
+	}
+
+	for i := 0; i < n; i++ {
+		lmtr.Requests[ip][method] = append(lmtr.Requests[ip][method], now)
+	}
+
+	return true
+}
+
+// @brief take the next free slot of ip & method, now or in the future
+//
+// @return *gen.Reservation - wait its Delay before acting, Cancel to refund the slot
+//
+// @note reserved slot count as a call right away, so later check see it
+func (lmtr *GrpcRateLimiter) Reserve(ip, method string) *gen.Reservation {
+	lmtr.Mtx.Lock()
+	defer lmtr.Mtx.Unlock()
+
+	now := lmtr.Now()
+	at, ok := gen.NextSlot(lmtr.methodRequests(ip, method), lmtr.MaxRequests, lmtr.Duration, 1, now); if !ok {
+		return gen.NewReservation(false, now, lmtr.Clock, nil)
+	}
+
+	lmtr.Requests[ip][method] = append(lmtr.Requests[ip][method], at)
+
+	return gen.NewReservation(true, at, lmtr.Clock, func() {
+		lmtr.Mtx.Lock()
+		defer lmtr.Mtx.Unlock()
+
+		if requests, found := gen.RemoveSlot(lmtr.Requests[ip][method], at); found {
+			lmtr.Requests[ip][method] = requests
+		}
+	})
+}
+
+// @brief give back the last n call counted for ip & method, e.g. admitted by limiter but rejected right after
+func (lmtr *GrpcRateLimiter) RefundN(ip, method string, n int) {
+	lmtr.Mtx.Lock()
+	defer lmtr.Mtx.Unlock()
+
+	if requests, found := lmtr.Requests[ip][method]; found {
+		lmtr.Requests[ip][method] = gen.RefundSlots(requests, n, lmtr.Now())
+	}
+}
+
+// @brief log of ip & method, map is initialized on the way
+//
+// @note caller must hold Mtx
+func (lmtr *GrpcRateLimiter) methodRequests(ip, method string) []time.Time {
+	if lmtr.Requests == nil {
+		lmtr.Requests = make(map[string]map[string][]time.Time)
+	}
+	if lmtr.Requests[ip] == nil {
+		lmtr.Requests[ip] = make(map[string][]time.Time)
+	}
+
+	return lmtr.Requests[ip][method]
+}
@@ -0,0 +1,214 @@
+package pkg_grpc_limiter
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// @brief descriptor to limiter mapping for envoy rate limit service
+type RlsPolicy struct {
+	Name string // reported as policy, also the method name inside Limiter
+	Domain string // empty match every domain
+	Match map[string]string // descriptor entry key to value, empty value match any value of the key
+	Limiter *GrpcRateLimiter
+}
+
+// @brief whether every Match entry is found in descriptor
+//
+// @note descriptor may have more entry than Match, each entry is still part of counting key
+func (p *RlsPolicy) Matches(domain string, entries []*pb.RateLimitDescriptor_Entry) bool {
+	if len(p.Domain) > 0 && p.Domain != domain {
+		return false
+	}
+
+	for key, value := range p.Match {
+		found := false
+		for _, entry := range entries {
+			if entry.GetKey() == key && (len(value) <= 0 || entry.GetValue() == value) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// @brief envoy.service.ratelimit.v3.RateLimitService on top of GrpcRateLimiter
+//
+// @note descriptor limit override is ignored, policy limit is always used
+type RlsServer struct {
+	pb.UnimplementedRateLimitServiceServer
+	Policies []*RlsPolicy // first match win, order from most to least specific
+	Observers []gen.DecisionObserver // optional, called once per matched descriptor
+}
+
+// @brief create new envoy rate limit service implementation
+//
+// @param policies []*RlsPolicy - first match win
+//
+// @return *RlsServer
+func NewRlsServer(policies []*RlsPolicy) *RlsServer {
+	return &RlsServer{Policies: policies}
+}
+
+// @brief policy for descriptor, nil when nothing match
+func (s *RlsServer) Policy(domain string, entries []*pb.RateLimitDescriptor_Entry) *RlsPolicy {
+	for _, p := range s.Policies {
+		if p.Matches(domain, entries) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// @note unmatched descriptor is OK without current limit, like envoy reference implementation
+func (s *RlsServer) ShouldRateLimit(ctx context.Context,
+									req *pb.RateLimitRequest) (*pb.RateLimitResponse, error) {
+	resp := &pb.RateLimitResponse{OverallCode: pb.RateLimitResponse_OK}
+
+	// most restrictive status is reported as x-ratelimit-* header
+	var tightest *gen.Decision
+
+	for _, descriptor := range req.GetDescriptors() {
+		start := time.Now()
+
+		policy := s.Policy(req.GetDomain(), descriptor.GetEntries())
+		if policy == nil {
+			resp.Statuses = append(resp.Statuses, &pb.RateLimitResponse_DescriptorStatus{
+				Code: pb.RateLimitResponse_OK,
+			})
+			continue
+		}
+
+		hits := uint64(req.GetHitsAddend())
+		if descriptor.GetHitsAddend() != nil {
+			hits = descriptor.GetHitsAddend().GetValue()
+		}
+		hits = max(hits, 1)
+
+		decision := gen.Decision{
+			Protocol: "rls",
+			Policy: policy.Name,
+			Route: req.GetDomain(),
+			Key: descriptorKey(req.GetDomain(), descriptor.GetEntries()),
+			Result: gen.ResultAllowed,
+		}
+
+		limiter := methodLimiter{limiter: policy.Limiter, method: policy.Name}
+		maxReq, duration := limiter.Policy()
+
+		// all hits or nothing, over limit request is not charged, hits over max can't fit anyway
+		hits = min(hits, uint64(maxReq) + 1)
+		state, ok := limiter.CheckRequestLimitStateN(decision.Key, int(hits)); if !ok {
+			decision.Result = gen.ResultRejected
+		}
+		decision.FromKeyState(state, limiter.Now())
+
+		count, unit := rlsUnit(maxReq, duration)
+		status := &pb.RateLimitResponse_DescriptorStatus{
+			Code: pb.RateLimitResponse_OK,
+			CurrentLimit: &pb.RateLimitResponse_RateLimit{
+				Name: policy.Name,
+				RequestsPerUnit: count,
+				Unit: unit,
+			},
+			LimitRemaining: uint32(decision.Remaining),
+			DurationUntilReset: durationpb.New(max(state.ResetAt.Sub(limiter.Now()), 0)),
+		}
+
+		if decision.Result == gen.ResultRejected {
+			status.Code = pb.RateLimitResponse_OVER_LIMIT
+			resp.OverallCode = pb.RateLimitResponse_OVER_LIMIT
+		}
+		resp.Statuses = append(resp.Statuses, status)
+
+		if tightest == nil || decision.Remaining < tightest.Remaining {
+			d := decision
+			tightest = &d
+		}
+
+		decision.Latency = time.Since(start)
+		for _, o := range s.Observers {
+			o.ObserveDecision(ctx, decision)
+		}
+	}
+
+	if tightest != nil {
+		resp.ResponseHeadersToAdd = rlsHeaders(*tightest, resp.OverallCode)
+	}
+
+	return resp, nil
+}
+
+// @brief counting key, e.g. "edge|remote_address=10.0.0.1,path=/api"
+func descriptorKey(domain string, entries []*pb.RateLimitDescriptor_Entry) string {
+	pairs := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		pairs = append(pairs, entry.GetKey() + "=" + entry.GetValue())
+	}
+
+	return domain + "|" + strings.Join(pairs, ",")
+}
+
+var rlsUnits = []struct {
+	unit pb.RateLimitUnit
+	d time.Duration
+}{
+	{pb.RateLimitUnit_SECOND, time.Second},
+	{pb.RateLimitUnit_MINUTE, time.Minute},
+	{pb.RateLimitUnit_HOUR, time.Hour},
+	{pb.RateLimitUnit_DAY, 24 * time.Hour},
+}
+
+// @brief sliding window as envoy requests per unit
+//
+// @note exact unit window is kept as is, otherwise the smallest unit with at least 1 request, e.g. 10 per 500ms is 20 per second
+func rlsUnit(maxReq uint, window time.Duration) (uint32, pb.RateLimitUnit) {
+	for _, u := range rlsUnits {
+		if window == u.d {
+			return uint32(maxReq), u.unit
+		}
+	}
+
+	last := rlsUnits[len(rlsUnits) - 1]
+	for _, u := range rlsUnits {
+		n := float64(maxReq) * float64(u.d) / float64(window)
+		if n >= 1 || u == last {
+			return uint32(math.Max(math.Round(n), 1)), u.unit
+		}
+	}
+
+	return uint32(maxReq), pb.RateLimitUnit_UNKNOWN
+}
+
+// @brief draft ietf RateLimit header fields, plus retry-after when over limit
+func rlsHeaders(d gen.Decision, code pb.RateLimitResponse_Code) []*pb.HeaderValue {
+	headers := []*pb.HeaderValue{
+		{Key: "x-ratelimit-limit", Value: fmt.Sprintf("%d", d.Limit)},
+		{Key: "x-ratelimit-remaining", Value: fmt.Sprintf("%d", d.Remaining)},
+	}
+
+	if d.RetryAfter > 0 {
+		retryAfter := fmt.Sprintf("%d", int(math.Ceil(d.RetryAfter.Seconds())))
+		headers = append(headers, &pb.HeaderValue{Key: "x-ratelimit-reset", Value: retryAfter})
+
+		if code == pb.RateLimitResponse_OVER_LIMIT {
+			headers = append(headers, &pb.HeaderValue{Key: "retry-after", Value: retryAfter})
+		}
+	}
+
+	return headers
+}
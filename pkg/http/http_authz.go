@@ -0,0 +1,159 @@
+package pkg_http_limiter
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// header carrying original request method, first non empty win
+//
+// - nginx auth_request, set by `proxy_set_header X-Original-Method $request_method`
+//
+// - traefik forwardAuth
+var OriginalMethodHeaders = []string{"X-Original-Method", "X-Forwarded-Method"}
+
+// header carrying original request uri, first non empty win
+//
+// - nginx auth_request, set by `proxy_set_header X-Original-URI $request_uri`
+//
+// - traefik forwardAuth
+var OriginalUriHeaders = []string{"X-Original-URI", "X-Forwarded-Uri"}
+
+// route reported for original request matching no configured route, so metrics label & cost key stay bounded
+const AuthzRoute = "authz"
+
+// @brief decision only endpoint, answer 200 or RejectStatus without any upstream call
+//
+// @note envoy http ext_authz send original method & path as is, prefixed with its path_prefix
+type AuthzHandler struct {
+	Middleware *HttpMiddleware
+	PathPrefix string // stripped from path when no original uri header, e.g. envoy path_prefix
+	RejectStatus int // status for rejected & banned request, 0 use 429, nginx auth_request only accept 401 & 403
+	routes *http.ServeMux // configured route pattern, nil report every request as AuthzRoute
+}
+
+// @brief create new decision endpoint on top of middleware access list, ban & limiter
+//
+// @param m *HttpMiddleware
+//
+// @param prefix string - endpoint mount path, stripped from envoy ext_authz request path
+//
+// @return *AuthzHandler
+func NewAuthzHandler(m *HttpMiddleware, prefix string) *AuthzHandler {
+	return &AuthzHandler{
+		Middleware: m,
+		PathPrefix: prefix,
+	}
+}
+
+// @brief set route pattern original request is matched to, e.g. "GET /users/{id}", matched pattern is the reported route & cost key
+//
+// @note raw path is never reported, call before serving
+//
+// @return error - on invalid or conflicting pattern, old routes stay in place
+func (a *AuthzHandler) SetRoutes(patterns []string) error {
+	mux, err := ParseRoutes(patterns); if err != nil {
+		return err
+	}
+
+	a.routes = mux
+	return nil
+}
+
+// @brief build mux matching route pattern with http.ServeMux syntax, its handler is never called
+//
+// @return *http.ServeMux, error - on invalid or conflicting pattern
+func ParseRoutes(patterns []string) (*http.ServeMux, error) {
+	mux := http.NewServeMux()
+	for _, pattern := range patterns {
+		if err := handleRoute(mux, pattern); err != nil {
+			return nil, err
+		}
+	}
+
+	return mux, nil
+}
+
+func handleRoute(mux *http.ServeMux, pattern string) (err error) {
+	// ServeMux panic on invalid or conflicting pattern
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("invalid route %q: %v", pattern, r)
+		}
+	}()
+
+	mux.Handle(pattern, http.NotFoundHandler())
+	return nil
+}
+
+// @brief original method and uri, from forwarded header first then from request itself
+func (a *AuthzHandler) Original(r *http.Request) (string, *url.URL) {
+	method := r.Method
+	for _, name := range OriginalMethodHeaders {
+		if v := r.Header.Get(name); len(v) > 0 {
+			method = strings.ToUpper(v)
+			break
+		}
+	}
+
+	for _, name := range OriginalUriHeaders {
+		if v := r.Header.Get(name); len(v) > 0 {
+			if u, err := url.ParseRequestURI(v); err == nil {
+				return method, u
+			}
+		}
+	}
+
+	u := *r.URL
+	u.Path = strings.TrimPrefix(u.Path, strings.TrimSuffix(a.PathPrefix, "/"))
+	if !strings.HasPrefix(u.Path, "/") {
+		u.Path = "/" + u.Path
+	}
+	u.RawPath = ""
+
+	return method, &u
+}
+
+func (a *AuthzHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method, uri := a.Original(r)
+
+	// matched route pattern of original request is reported, e.g. "GET /api/users/{id}"
+	orig := r.Clone(r.Context())
+	orig.Method = method
+	orig.URL = uri
+	orig.Pattern = AuthzRoute
+	if a.routes != nil {
+		if _, pattern := a.routes.Handler(orig); len(pattern) > 0 {
+			orig.Pattern = pattern
+		}
+	}
+
+	// rate limit header is always sent back, so proxy can copy it to client
+	m := *a.Middleware
+	m.Headers = true
+
+	// slot would be released right away, in-flight request live behind the proxy
+	m.Concurrency = nil
+	m.Adaptive = nil
+	m.Fair = nil
+
+	m.Limit(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})(&rejectWriter{ResponseWriter: w, status: a.RejectStatus}, orig)
+}
+
+// @brief rewrite 429 into configured status
+type rejectWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *rejectWriter) WriteHeader(code int) {
+	if code == http.StatusTooManyRequests && w.status > 0 {
+		code = w.status
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,114 @@
+package pkg_http_limiter
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+)
+
+// largest chunk moved per wait, keep pacing smooth for big body
+const bandwidthChunk = 16 << 10
+
+// @brief upload & download throughput cap per client ip, slow stream is paced instead of cut off
+//
+// @note keyed by host without port, so opening more connection does not raise the cap
+type BandwidthMiddleware struct {
+	Upload *gen.BandwidthLimiter // request body, nil unlimited
+	Download *gen.BandwidthLimiter // response body, nil unlimited
+}
+
+// @brief create new bandwidth middleware
+//
+// @param upload *gen.BandwidthLimiter - request body, nil unlimited
+//
+// @param download *gen.BandwidthLimiter - response body, nil unlimited
+//
+// @return *BandwidthMiddleware
+func NewBandwidthMiddleware(upload, download *gen.BandwidthLimiter) *BandwidthMiddleware {
+	return &BandwidthMiddleware{
+		Upload: upload,
+		Download: download,
+	}
+}
+
+func (m *BandwidthMiddleware) Limit(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIp(r)
+
+		if m.Upload != nil && r.Body != nil && r.Body != http.NoBody {
+			r.Body = &pacedReader{ReadCloser: r.Body, ctx: r.Context(), limiter: m.Upload, key: ip}
+		}
+
+		if m.Download != nil {
+			w = &pacedWriter{ResponseWriter: w, ctx: r.Context(), limiter: m.Download, key: ip}
+		}
+
+		next(w, r)
+	}
+}
+
+// --------------------------------------------------------- //
+
+type pacedReader struct {
+	io.ReadCloser
+	ctx context.Context
+	limiter *gen.BandwidthLimiter
+	key string
+}
+
+// @note byte already read is returned together with ctx error
+func (r *pacedReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthChunk {
+		p = p[:bandwidthChunk]
+	}
+
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := r.limiter.WaitN(r.ctx, r.key, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
+
+type pacedWriter struct {
+	http.ResponseWriter
+	ctx context.Context
+	limiter *gen.BandwidthLimiter
+	key string
+}
+
+func (w *pacedWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p[:min(len(p), bandwidthChunk)]
+
+		if err := w.limiter.WaitN(w.ctx, w.key, len(chunk)); err != nil {
+			return written, err
+		}
+
+		n, err := w.ResponseWriter.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+
+		p = p[n:]
+	}
+
+	return written, nil
+}
+
+func (w *pacedWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// @brief for http.ResponseController
+func (w *pacedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
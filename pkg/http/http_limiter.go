@@ -1,9 +1,21 @@
 package pkg_http_limiter
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	pkg_ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	pkg_cidr "github.com/prothegee/network-limiter-go/pkg/cidr"
+	pkg_logging "github.com/prothegee/network-limiter-go/pkg/logging"
 )
 
 type HttpRateLimiter struct {
@@ -11,6 +23,9 @@ type HttpRateLimiter struct {
 	Requests map[string][]time.Time
 	MaxRequests uint
 	Duration time.Duration
+	OnCleanup func(elapsed time.Duration) // optional, called after each cleanup round
+	Logger *slog.Logger // optional, nil use slog.Default
+	Clock gen.Clock // optional, nil use gen.SystemClock
 }
 
 // @brief create new internal http limiter
@@ -19,20 +34,45 @@ type HttpRateLimiter struct {
 //
 // @param duration time.Duration - wind time duration limiter
 //
+// @param opts ...gen.Option - optional setting, e.g. gen.WithClock
+//
 // @return *NewHttpRateLimiter
-func NewHttpRateLimiter(maxReq uint, duration time.Duration) *HttpRateLimiter {
+func NewHttpRateLimiter(maxReq uint, duration time.Duration, opts ...gen.Option) *HttpRateLimiter {
+	o := gen.ApplyOptions(opts...)
+
 	return &HttpRateLimiter{
 		Requests: make(map[string][]time.Time),
 		MaxRequests: maxReq,
 		Duration: duration,
+		Clock: o.Clock,
 	}
 }
 
+// @return time.Time - current time from limiter clock
+func (lmtr *HttpRateLimiter) Now() time.Time {
+	return gen.OrSystemClock(lmtr.Clock).Now()
+}
+
 func (lmtr *HttpRateLimiter) CheckRequestLimit(ip string) bool {
+	_, ok := lmtr.CheckRequestLimitState(ip)
+	return ok
+}
+
+// @brief same as CheckRequestLimit, also give key state right after the check
+//
+// @return gen.KeyState, bool - state and whether request is allowed
+func (lmtr *HttpRateLimiter) CheckRequestLimitState(ip string) (gen.KeyState, bool) {
+	return lmtr.CheckRequestLimitStateN(ip, 1)
+}
+
+// @brief same as CheckRequestLimitState, request count as n unit, all or nothing
+//
+// @note n over max request is always rejected, same as AllowN
+func (lmtr *HttpRateLimiter) CheckRequestLimitStateN(ip string, n int) (gen.KeyState, bool) {
 	lmtr.Mtx.Lock()
 	defer lmtr.Mtx.Unlock()
 
-	now := time.Now()
+	now := lmtr.Now()
 
 	// cleanup for the old request from the current ip
 	validRequests := []time.Time{}
@@ -42,28 +82,85 @@ func (lmtr *HttpRateLimiter) CheckRequestLimit(ip string) bool {
 		}
 	}
 
-	if len(validRequests) >= int(lmtr.MaxRequests) {
-		return false
+	n = max(n, 1)
+	if len(validRequests) + n > int(lmtr.MaxRequests) {
+		return lmtr.keyState(ip, validRequests, now), false
 	}
 
-	validRequests = append(validRequests, now)
+	for i := 0; i < n; i++ {
+		validRequests = append(validRequests, now)
+	}
 	lmtr.Requests[ip] = validRequests
 
-	return true
+	return lmtr.keyState(ip, validRequests, now), true
+}
+
+// @brief block until ip is allowed or ctx is done
+//
+// @return gen.KeyState, error - state of the allowed check, or last rejected state with ctx error
+func (lmtr *HttpRateLimiter) Wait(ctx context.Context, ip string) (gen.KeyState, error) {
+	return gen.WaitKey(ctx, lmtr, ip, lmtr.Clock)
+}
+
+// @brief same as Wait for n unit at once
+func (lmtr *HttpRateLimiter) WaitN(ctx context.Context, ip string, n int) (gen.KeyState, error) {
+	return gen.WaitKeyN(ctx, lmtr, ip, n, lmtr.Clock)
+}
+
+// @brief change max request and window duration at once
+//
+// @note tracked request is kept, new policy apply from next check
+func (lmtr *HttpRateLimiter) UpdatePolicy(maxReq uint, duration time.Duration) {
+	lmtr.Mtx.Lock()
+	defer lmtr.Mtx.Unlock()
+
+	lmtr.MaxRequests = maxReq
+	lmtr.Duration = duration
+}
+
+// @return uint, time.Duration - current max request and window duration
+func (lmtr *HttpRateLimiter) Policy() (uint, time.Duration) {
+	lmtr.Mtx.RLock()
+	defer lmtr.Mtx.RUnlock()
+
+	return lmtr.MaxRequests, lmtr.Duration
+}
+
+// @return int - number of tracked ip
+func (lmtr *HttpRateLimiter) KeyCount() int {
+	lmtr.Mtx.RLock()
+	defer lmtr.Mtx.RUnlock()
+
+	return len(lmtr.Requests)
 }
 
+// @param ctx context.Context - cancel to stop the loop
+//
 // @param lmtr *HttpRateLimiter
 //
 // @param d time.Duration
-func CleanupOldRequest(lmtr *HttpRateLimiter, d time.Duration) {
-	ticker := time.NewTicker(d)
+//
+// @param opts ...gen.Option - optional setting, clock default to limiter clock
+func CleanupOldRequest(ctx context.Context, lmtr *HttpRateLimiter, d time.Duration, opts ...gen.Option) {
+	clock := gen.ApplyOptions(opts...).ClockOr(lmtr.Clock)
+
+	ticker := clock.NewTicker(d)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+
+		start := time.Now()
+		removed := 0
+
 		lmtr.Mtx.Lock()
+		now := clock.Now()
 		for ip, requests := range lmtr.Requests {
 			validRequests := []time.Time{}
-			now := time.Now()
 
 			for _, t := range requests {
 				if now.Sub(t) <= lmtr.Duration {
@@ -73,18 +170,88 @@ func CleanupOldRequest(lmtr *HttpRateLimiter, d time.Duration) {
 
 			if len(validRequests) == 0 {
 				delete(lmtr.Requests, ip)
+				removed++
 			} else {
 				lmtr.Requests[ip] = validRequests
 			}
 		}
+		tracked := len(lmtr.Requests)
 		lmtr.Mtx.Unlock()
+
+		pkg_logging.OrDefault(lmtr.Logger).Debug("http limiter cleanup",
+			"removed", removed, "tracked", tracked, "elapsed", time.Since(start))
+
+		if lmtr.OnCleanup != nil {
+			lmtr.OnCleanup(time.Since(start))
+		}
+	}
+}
+
+// @brief snapshot of tracked key state
+//
+// @return gen.KeyState, bool - false if key isn't tracked
+func (lmtr *HttpRateLimiter) GetKeyState(ip string) (gen.KeyState, bool) {
+	lmtr.Mtx.RLock()
+	defer lmtr.Mtx.RUnlock()
+
+	requests, ok := lmtr.Requests[ip]; if !ok {
+		return gen.KeyState{}, false
+	}
+
+	return lmtr.keyState(ip, requests, lmtr.Now()), true
+}
+
+// @brief snapshot of all tracked key sorted by key
+func (lmtr *HttpRateLimiter) ListKeys() []gen.KeyState {
+	lmtr.Mtx.RLock()
+	defer lmtr.Mtx.RUnlock()
+
+	now := lmtr.Now()
+	states := make([]gen.KeyState, 0, len(lmtr.Requests))
+	for ip, requests := range lmtr.Requests {
+		states = append(states, lmtr.keyState(ip, requests, now))
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Key < states[j].Key
+	})
+
+	return states
+}
+
+func (lmtr *HttpRateLimiter) keyState(ip string, requests []time.Time, now time.Time) gen.KeyState {
+	state := gen.KeyState{Key: ip, Limit: lmtr.MaxRequests}
+
+	for _, t := range requests {
+		if now.Sub(t) > lmtr.Duration {
+			continue
+		}
+		if state.Count == 0 || t.Add(lmtr.Duration).Before(state.ResetAt) {
+			state.ResetAt = t.Add(lmtr.Duration)
+		}
+		state.Count++
 	}
+
+	return state
 }
 
 // --------------------------------------------------------- //
 
 type HttpMiddleware struct {
-	Limiter *HttpRateLimiter
+	Limiter *HttpRateLimiter // nil to skip, e.g. concurrency only
+	Concurrency *gen.ConcurrencyLimiter // optional in-flight cap, slot is held until next return but not while waiting in Queue, nil to skip
+	Queue *gen.WaitQueue // optional, over limit request wait for capacity before rejection, nil to reject at once
+	Costs *gen.CostTable // optional unit per route pattern, nil cost 1
+	CostFunc func(r *http.Request) int // optional, win over Costs when it give positive cost, e.g. ContentLengthCost
+	Adaptive *gen.AdaptiveLimiter // optional global load shedding after per ip check, shed request get 503, nil to skip
+	PriorityFunc func(r *http.Request) gen.Priority // optional, nil treat every request as gen.PriorityNormal
+	Fair *gen.FairQueue // optional weighted fair queuing between tenant right before next, full queue get 503, nil to skip
+	TenantFunc func(r *http.Request) string // optional, nil use client ip as tenant
+	Access *pkg_cidr.AccessList // optional allow/deny list checked on peer ip, forwarded ip only from its trusted proxy, nil to skip
+	Ban *pkg_ban.BanBox // optional temporary ban after repeated rejection, nil to skip
+	Policy string // policy name reported to observer, "default" when empty
+	Observers []gen.DecisionObserver // optional metrics, tracing, logging hook
+	Headers bool // add X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset and Retry-After when rejected
 }
 
 // @brief in-case of fire, helper for reset ip param
@@ -94,21 +261,266 @@ func (lmtr *HttpRateLimiter) ResetIP(ip string) {
 	delete(lmtr.Requests, ip)
 }
 
+// @brief reset every ip starting with prefix, e.g. "10.0."
+//
+// @return int - number of reset ip
+func (lmtr *HttpRateLimiter) ResetPrefix(prefix string) int {
+	lmtr.Mtx.Lock()
+	defer lmtr.Mtx.Unlock()
+
+	count := 0
+	for ip := range lmtr.Requests {
+		if strings.HasPrefix(ip, prefix) {
+			delete(lmtr.Requests, ip)
+			count++
+		}
+	}
+
+	return count
+}
+
+func (m *HttpMiddleware) observe(r *http.Request, d gen.Decision, start time.Time) {
+	if len(m.Observers) <= 0 {
+		return
+	}
+
+	if len(d.Policy) <= 0 {
+		d.Policy = "default"
+	}
+	d.Latency = time.Since(start)
+
+	for _, o := range m.Observers {
+		o.ObserveDecision(r.Context(), d)
+	}
+}
+
 func (m *HttpMiddleware) Limit(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		ip := r.RemoteAddr
+		start := time.Now()
+		ip := clientIp(r)
+
+		decision := gen.Decision{
+			Protocol: "http",
+			Policy: m.Policy,
+			Route: r.Pattern,
+			Key: ip,
+		}
 
-		if xForwardedFor := r.Header.Get("X-Forwarded-For"); xForwardedFor != "" {
-			ip = xForwardedFor
-		} else if xRealIp := r.Header.Get("X-Real-IP"); xRealIp != "" {
-			ip = xRealIp
+		peerIp := m.Access.PeerIP(r.Header.Get("X-Real-IP"), r.Header.Get("X-Forwarded-For"), r.RemoteAddr)
+		switch m.Access.Check(peerIp) {
+		case pkg_cidr.DecisionDeny:
+			decision.Result = gen.ResultDenied
+			m.observe(r, decision, start)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		case pkg_cidr.DecisionAllow:
+			decision.Result = gen.ResultExempt
+			m.observe(r, decision, start)
+			next(w, r)
+			return
 		}
 
-		if !m.Limiter.CheckRequestLimit(ip) {
-			http.Error(w, "Request Limit Exceeded", http.StatusTooManyRequests)
+		if until, banned := m.Ban.IsBanned(ip); banned {
+			decision.Result = gen.ResultBanned
+			decision.RetryAfter = until.Sub(m.Ban.Now())
+			m.observe(r, decision, start)
+
+			retryAfter := math.Ceil(decision.RetryAfter.Seconds())
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(retryAfter)))
+			http.Error(w, "Request Limit Exceeded; Temporarily Banned", http.StatusTooManyRequests)
 			return
 		}
 
+		rejectInFlight := func(state gen.KeyState) {
+			decision.FromKeyState(state, start)
+			decision.Result = gen.ResultInFlight
+			m.observe(r, decision, start)
+
+			http.Error(w, "Too Many Concurrent Requests", http.StatusTooManyRequests)
+		}
+
+		// slot first, so request rejected by in-flight cap doesn't use window quota
+		releaseSlot := func() {}
+		if m.Concurrency != nil {
+			slot, state, ok := m.Concurrency.Acquire(ip); if !ok {
+				rejectInFlight(state)
+				return
+			}
+			decision.FromKeyState(state, start)
+			releaseSlot = slot
+		}
+		defer func() { releaseSlot() }()
+
+		if m.Limiter != nil {
+			decision.Cost = m.cost(r)
+
+			state, ok := m.Limiter.CheckRequestLimitStateN(ip, decision.Cost)
+			if !ok && m.Queue != nil {
+				// queued request isn't running, its slot is free while it wait
+				releaseSlot()
+				if waited, err := m.Queue.WaitN(r.Context(), m.Limiter, ip, decision.Cost); !errors.Is(err, gen.ErrQueueFull) {
+					state, ok = waited, err == nil
+				}
+
+				if ok && m.Concurrency != nil {
+					slot, inFlight, acquired := m.Concurrency.Acquire(ip); if !acquired {
+						// slot is taken meanwhile, rejected request doesn't keep the quota it waited for
+						m.Limiter.RefundN(ip, decision.Cost)
+						rejectInFlight(inFlight)
+						return
+					}
+					releaseSlot = slot
+				}
+			}
+			decision.FromKeyState(state, m.Limiter.Now())
+
+			if m.Headers {
+				setRateLimitHeaders(w, decision, state.ResetAt.Sub(m.Limiter.Now()))
+			}
+
+			if !ok {
+				m.Ban.RecordRejection(ip)
+
+				decision.Result = gen.ResultRejected
+				m.observe(r, decision, start)
+
+				if m.Headers && decision.RetryAfter > 0 {
+					w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(decision.RetryAfter.Seconds()))))
+				}
+				http.Error(w, "Request Limit Exceeded", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		// per ip limit pass first, overload only decide who of the rest is served
+		if m.Adaptive != nil {
+			release, _, ok := m.Adaptive.Acquire(m.priority(r)); if !ok {
+				decision.Result = gen.ResultShed
+				m.observe(r, decision, start)
+
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "Service Unavailable; Server Overloaded", http.StatusServiceUnavailable)
+				return
+			}
+			defer release()
+		}
+
+		if m.Fair != nil {
+			release, _, err := m.Fair.Acquire(r.Context(), m.tenant(r), m.priority(r)); if err != nil {
+				decision.Result = gen.ResultShed
+				m.observe(r, decision, start)
+
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "Service Unavailable; Server Overloaded", http.StatusServiceUnavailable)
+				return
+			}
+			defer release()
+		}
+
+		decision.Result = gen.ResultAllowed
+		m.observe(r, decision, start)
+
 		next(w, r)
 	}
 }
+
+// @return gen.Priority - from PriorityFunc, gen.PriorityNormal when unset
+func (m *HttpMiddleware) priority(r *http.Request) gen.Priority {
+	if m.PriorityFunc == nil {
+		return gen.PriorityNormal
+	}
+
+	return m.PriorityFunc(r)
+}
+
+// @brief priority from request header value "low", "normal" or "high", anything else is normal
+//
+// @note header is set by client, only trust it behind a proxy that overwrite it
+func PriorityFromHeader(name string) func(r *http.Request) gen.Priority {
+	return func(r *http.Request) gen.Priority {
+		p, _ := gen.ParsePriority(r.Header.Get(name))
+		return p
+	}
+}
+
+// @return string - from TenantFunc, client ip when unset or empty
+func (m *HttpMiddleware) tenant(r *http.Request) string {
+	if m.TenantFunc != nil {
+		if tenant := m.TenantFunc(r); len(tenant) > 0 {
+			return tenant
+		}
+	}
+
+	return clientIp(r)
+}
+
+// @brief tenant from request header, e.g. api key or account id, missing fall back to client ip
+//
+// @note header is set by client, only trust it behind a proxy or auth layer that overwrite it
+func TenantFromHeader(name string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		if tenant := r.Header.Get(name); len(tenant) > 0 {
+			return tenant
+		}
+
+		return clientIp(r)
+	}
+}
+
+// @brief priority class by tenant, e.g. paying account is high, others use fallback
+//
+// @param tenant func(r *http.Request) string - tenant of request, e.g. TenantFromHeader
+//
+// @param classes map[string]gen.Priority - tenant / priority
+//
+// @param fallback func(r *http.Request) gen.Priority - optional, for tenant not in classes, nil is normal
+func PriorityByTenant(tenant func(r *http.Request) string, classes map[string]gen.Priority,
+	fallback func(r *http.Request) gen.Priority) func(r *http.Request) gen.Priority {
+	return func(r *http.Request) gen.Priority {
+		if p, ok := classes[tenant(r)]; ok {
+			return p
+		}
+		if fallback != nil {
+			return fallback(r)
+		}
+
+		return gen.PriorityNormal
+	}
+}
+
+// @return string - X-Real-IP, then first X-Forwarded-For hop, then host of remote address, same as grpc
+//
+// @note port is dropped, otherwise every keep-alive connection of one client is a new key
+func clientIp(r *http.Request) string {
+	return pkg_cidr.ClientIP(r.Header.Get("X-Real-IP"), r.Header.Get("X-Forwarded-For"), r.RemoteAddr)
+}
+
+// @return int - request cost from CostFunc, then Costs by route pattern, 1 otherwise
+func (m *HttpMiddleware) cost(r *http.Request) int {
+	if m.CostFunc != nil {
+		if cost := m.CostFunc(r); cost > 0 {
+			return cost
+		}
+	}
+
+	return m.Costs.Cost(r.Pattern)
+}
+
+// @brief cost from body size, one unit per started unitBytes, e.g. 1 unit per 64KiB upload
+//
+// @note empty or unknown length, e.g. chunked body, give 0 so Costs is used instead
+func ContentLengthCost(unitBytes int64) func(r *http.Request) int {
+	return func(r *http.Request) int {
+		if r.ContentLength <= 0 || unitBytes <= 0 {
+			return 0
+		}
+
+		return int((r.ContentLength + unitBytes - 1) / unitBytes)
+	}
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, d gen.Decision, reset time.Duration) {
+	w.Header().Set("X-RateLimit-Limit", fmt.Sprintf("%d", d.Limit))
+	w.Header().Set("X-RateLimit-Remaining", fmt.Sprintf("%d", d.Remaining))
+	w.Header().Set("X-RateLimit-Reset", fmt.Sprintf("%d", int(math.Ceil(max(reset, 0).Seconds()))))
+}
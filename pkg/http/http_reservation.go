@@ -0,0 +1,73 @@
+package pkg_http_limiter
+
+import (
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+)
+
+// @brief same as AllowN for 1 request at limiter clock now
+func (lmtr *HttpRateLimiter) Allow(ip string) bool {
+	return lmtr.AllowN(ip, 1, lmtr.Now())
+}
+
+// @brief count n request at once when all of them fit, otherwise count nothing
+//
+// @param ip string
+//
+// @param n int - request number, e.g. batch size
+//
+// @param now time.Time - request time, usually limiter Now
+//
+// @return bool - whether n request is allowed
+func (lmtr *HttpRateLimiter) AllowN(ip string, n int, now time.Time) bool {
+	lmtr.Mtx.Lock()
+	defer lmtr.Mtx.Unlock()
+
+	at, ok := gen.NextSlot(lmtr.Requests[ip], lmtr.MaxRequests, lmtr.Duration, n, now)
+	if !ok || at.After(now) {
+		return false
+	}
+
+	for i := 0; i < n; i++ {
+		lmtr.Requests[ip] = append(lmtr.Requests[ip], now)
+	}
+
+	return true
+}
+
+// @brief take the next free slot of ip, now or in the future
+//
+// @return *gen.Reservation - wait its Delay before acting, Cancel to refund the slot
+//
+// @note reserved slot count as a request right away, so later check see it
+func (lmtr *HttpRateLimiter) Reserve(ip string) *gen.Reservation {
+	lmtr.Mtx.Lock()
+	defer lmtr.Mtx.Unlock()
+
+	now := lmtr.Now()
+	at, ok := gen.NextSlot(lmtr.Requests[ip], lmtr.MaxRequests, lmtr.Duration, 1, now); if !ok {
+		return gen.NewReservation(false, now, lmtr.Clock, nil)
+	}
+
+	lmtr.Requests[ip] = append(lmtr.Requests[ip], at)
+
+	return gen.NewReservation(true, at, lmtr.Clock, func() {
+		lmtr.Mtx.Lock()
+		defer lmtr.Mtx.Unlock()
+
+		if requests, found := gen.RemoveSlot(lmtr.Requests[ip], at); found {
+			lmtr.Requests[ip] = requests
+		}
+	})
+}
+
+// @brief give back the last n request counted for ip, e.g. admitted by limiter but rejected right after
+func (lmtr *HttpRateLimiter) RefundN(ip string, n int) {
+	lmtr.Mtx.Lock()
+	defer lmtr.Mtx.Unlock()
+
+	if requests, found := lmtr.Requests[ip]; found {
+		lmtr.Requests[ip] = gen.RefundSlots(requests, n, lmtr.Now())
+	}
+}
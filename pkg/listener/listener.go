@@ -0,0 +1,209 @@
+package pkg_listener
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+)
+
+const (
+	PolicyMaxConn = "max_conn_per_ip"
+	PolicyConnRate = "conn_rate_per_ip"
+)
+
+// @brief connection level limit around any net.Listener, e.g. http.Server.Serve or grpc.Server.Serve
+//
+// @note global excess wait inside Accept, per ip excess is closed right after accept
+type Listener struct {
+	net.Listener
+	MaxConn int // global concurrent connection, 0 unlimited
+	MaxConnPerIp int // concurrent connection per ip, 0 unlimited
+	Rate gen.KeyLimiter // optional new connection rate per ip, e.g. pkg_http_limiter.NewHttpRateLimiter(20, time.Second)
+	Observers []gen.DecisionObserver // optional, called once per accepted or closed connection
+	mtx sync.Mutex
+	active map[string]int
+	slots chan struct{}
+	closed chan struct{}
+	closeOnce sync.Once
+}
+
+// @brief wrap listener with connection limit
+//
+// @param inner net.Listener
+//
+// @param maxConn int - global concurrent connection, 0 unlimited
+//
+// @param maxConnPerIp int - concurrent connection per ip, 0 unlimited
+//
+// @param rate gen.KeyLimiter - new connection rate per ip, nil unlimited
+//
+// @return *Listener
+func NewListener(inner net.Listener, maxConn, maxConnPerIp int, rate gen.KeyLimiter) *Listener {
+	l := &Listener{
+		Listener: inner,
+		MaxConn: maxConn,
+		MaxConnPerIp: maxConnPerIp,
+		Rate: rate,
+		active: make(map[string]int),
+		closed: make(chan struct{}),
+	}
+
+	if maxConn > 0 {
+		l.slots = make(chan struct{}, maxConn)
+	}
+
+	return l
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		// global cap delay accept, client stay in kernel backlog
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+			case <-l.closed:
+				return nil, net.ErrClosed
+			}
+		}
+
+		conn, err := l.Listener.Accept(); if err != nil {
+			l.releaseSlot()
+			return nil, err
+		}
+
+		start := time.Now()
+		ip := RemoteIp(conn.RemoteAddr())
+
+		decision, ok := l.admit(ip)
+		decision.Latency = time.Since(start)
+		l.observe(decision)
+
+		if !ok {
+			conn.Close()
+			l.releaseSlot()
+			continue
+		}
+
+		return &limitedConn{Conn: conn, listener: l, ip: ip}, nil
+	}
+}
+
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return l.Listener.Close()
+}
+
+// @return int - open connection count of all ip
+func (l *Listener) Active() int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	total := 0
+	for _, n := range l.active {
+		total += n
+	}
+
+	return total
+}
+
+// @return int - open connection count of one ip
+func (l *Listener) ActiveIp(ip string) int {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	return l.active[ip]
+}
+
+func (l *Listener) admit(ip string) (gen.Decision, bool) {
+	decision := gen.Decision{
+		Protocol: "tcp",
+		Policy: PolicyMaxConn,
+		Key: ip,
+		Result: gen.ResultAllowed,
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	if l.MaxConnPerIp > 0 {
+		decision.Count = l.active[ip]
+		decision.Limit = uint(l.MaxConnPerIp)
+
+		if l.active[ip] >= l.MaxConnPerIp {
+			decision.Result = gen.ResultRejected
+			return decision, false
+		}
+	}
+
+	if l.Rate != nil {
+		state, ok := l.Rate.CheckRequestLimitState(ip)
+
+		decision.Policy = PolicyConnRate
+		decision.FromKeyState(state, l.Rate.Now())
+
+		if !ok {
+			decision.Result = gen.ResultRejected
+			return decision, false
+		}
+	}
+
+	l.active[ip]++
+	decision.Count = l.active[ip]
+
+	return decision, true
+}
+
+func (l *Listener) done(ip string) {
+	l.mtx.Lock()
+	if l.active[ip]--; l.active[ip] <= 0 {
+		delete(l.active, ip)
+	}
+	l.mtx.Unlock()
+
+	l.releaseSlot()
+}
+
+func (l *Listener) releaseSlot() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+func (l *Listener) observe(d gen.Decision) {
+	for _, o := range l.Observers {
+		o.ObserveDecision(context.Background(), d)
+	}
+}
+
+// @brief host part of remote address, whole address when it has no port, e.g. unix socket
+func RemoteIp(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(addr.String()); if err != nil {
+		return addr.String()
+	}
+
+	return host
+}
+
+// --------------------------------------------------------- //
+
+type limitedConn struct {
+	net.Conn
+	listener *Listener
+	ip string
+	closeOnce sync.Once
+}
+
+// @note slot is released once, even when closed many time
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() { c.listener.done(c.ip) })
+
+	return err
+}
@@ -0,0 +1,170 @@
+package pkg_logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+)
+
+// @brief create slog logger from config value
+//
+// @param w io.Writer - e.g. os.Stderr
+//
+// @param level string - "debug", "info", "warn" or "error", "" is info
+//
+// @param format string - "text" or "json", "" is text
+//
+// @return *slog.Logger, error
+func NewLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if len(level) > 0 {
+		if err := lvl.UnmarshalText([]byte(level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q", level)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+
+	return nil, fmt.Errorf("invalid log format %q, expected text or json", format)
+}
+
+// @brief nil safe logger, fallback to slog.Default
+func OrDefault(logger *slog.Logger) *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
+
+// --------------------------------------------------------- //
+
+type sample struct {
+	Logged int
+	Suppressed int
+}
+
+// @brief decision observer that log non allowed decision, sampled per key
+//
+// @note each key log at most burst line per interval, the rest is counted as suppressed
+type RejectionLogger struct {
+	Mtx sync.Mutex
+	Logger *slog.Logger
+	Interval time.Duration
+	Burst int
+	Samples map[string]*sample
+	Clock gen.Clock // nil use SystemClock
+	windowStart time.Time
+}
+
+// @brief create new sampled rejection logger
+//
+// @param logger *slog.Logger
+//
+// @param interval time.Duration - sampling window
+//
+// @param burst int - max log line per key per window, 0 or less to log everything
+//
+// @param opts ...gen.Option - e.g. gen.WithClock, default to SystemClock
+//
+// @return *RejectionLogger
+func NewRejectionLogger(logger *slog.Logger, interval time.Duration, burst int, opts ...gen.Option) *RejectionLogger {
+	return &RejectionLogger{
+		Logger: OrDefault(logger),
+		Interval: interval,
+		Burst: burst,
+		Samples: make(map[string]*sample),
+		Clock: gen.ApplyOptions(opts...).ClockOr(nil),
+	}
+}
+
+func (l *RejectionLogger) ObserveDecision(ctx context.Context, d gen.Decision) {
+	if d.Result == gen.ResultAllowed || d.Result == gen.ResultExempt {
+		return
+	}
+
+	suppressed, ok, flushed := l.sample(d.Key)
+
+	// key suppressed in previous window never log again there, keep its count
+	for key, n := range flushed {
+		l.Logger.LogAttrs(ctx, slog.LevelWarn, "rejection log suppressed",
+			slog.String("key", key), slog.Int("suppressed", n))
+	}
+
+	if !ok {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("protocol", d.Protocol),
+		slog.String("policy", d.Policy),
+		slog.String("route", d.Route),
+		slog.String("key", d.Key),
+		slog.String("result", d.Result),
+		slog.Int("count", d.Count),
+		slog.Uint64("limit", uint64(d.Limit)),
+	}
+	if d.RetryAfter > 0 {
+		attrs = append(attrs, slog.Duration("retry_after", d.RetryAfter))
+	}
+	if suppressed > 0 {
+		attrs = append(attrs, slog.Int("suppressed", suppressed))
+	}
+
+	l.Logger.LogAttrs(ctx, slog.LevelWarn, "request rejected by limiter", attrs...)
+}
+
+// @return int, bool, map[string]int - suppressed line since last log of key, whether to log now and suppressed count of every key when a window end
+func (l *RejectionLogger) sample(key string) (int, bool, map[string]int) {
+	if l.Burst <= 0 {
+		return 0, true, nil
+	}
+
+	l.Mtx.Lock()
+	defer l.Mtx.Unlock()
+
+	// start new window, forget every key so map doesn't grow forever
+	var flushed map[string]int
+	now := gen.OrSystemClock(l.Clock).Now()
+	if now.Sub(l.windowStart) >= l.Interval {
+		for k, s := range l.Samples {
+			if s.Suppressed > 0 {
+				if flushed == nil {
+					flushed = make(map[string]int)
+				}
+				flushed[k] = s.Suppressed
+			}
+		}
+
+		l.windowStart = now
+		clear(l.Samples)
+	}
+
+	s, ok := l.Samples[key]; if !ok {
+		s = &sample{}
+		l.Samples[key] = s
+	}
+
+	if s.Logged >= l.Burst {
+		s.Suppressed++
+		return 0, false, flushed
+	}
+
+	s.Logged++
+	suppressed := s.Suppressed
+	s.Suppressed = 0
+
+	return suppressed, true, flushed
+}
@@ -0,0 +1,91 @@
+package pkg_metrics
+
+import (
+	"context"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+)
+
+type LimiterMetrics struct {
+	Registry *Registry
+	Decisions *CounterVec
+	DecisionLatency *HistogramVec
+	TrackedKeys *GaugeVec
+	ActiveBans *GaugeVec
+	CleanupDuration *HistogramVec
+}
+
+// @brief create limiter metrics and register them into registry
+//
+// @note satisfy gen.DecisionObserver, append it to middleware Observers
+//
+// @param reg *Registry
+//
+// @return *LimiterMetrics
+func NewLimiterMetrics(reg *Registry) *LimiterMetrics {
+	m := &LimiterMetrics{
+		Registry: reg,
+		Decisions: NewCounterVec(
+			"network_limiter_decisions_total",
+			"Limiter middleware decision by result.",
+			"protocol", "policy", "route", "result"),
+		DecisionLatency: NewHistogramVec(
+			"network_limiter_decision_duration_seconds",
+			"Time spent deciding whether a request is admitted.",
+			DefaultBuckets,
+			"protocol", "policy"),
+		TrackedKeys: NewGaugeVec(
+			"network_limiter_tracked_keys",
+			"Number of key currently tracked by limiter.",
+			"protocol"),
+		ActiveBans: NewGaugeVec(
+			"network_limiter_active_bans",
+			"Number of key currently banned.",
+			"protocol"),
+		CleanupDuration: NewHistogramVec(
+			"network_limiter_cleanup_duration_seconds",
+			"Time spent by one old request cleanup round.",
+			DefaultBuckets,
+			"protocol"),
+	}
+
+	reg.Register(m.Decisions)
+	reg.Register(m.DecisionLatency)
+	reg.Register(m.TrackedKeys)
+	reg.Register(m.ActiveBans)
+	reg.Register(m.CleanupDuration)
+
+	return m
+}
+
+func (m *LimiterMetrics) ObserveDecision(ctx context.Context, d gen.Decision) {
+	route := d.Route
+	if len(route) <= 0 {
+		route = "other"
+	}
+
+	m.Decisions.Inc(d.Protocol, d.Policy, route, d.Result)
+	m.DecisionLatency.Observe(d.Latency.Seconds(), d.Protocol, d.Policy)
+}
+
+// @brief report number of tracked key on each scrape
+//
+// @param fn func() int - e.g. limiter.KeyCount
+func (m *LimiterMetrics) TrackKeys(protocol string, fn func() int) {
+	m.TrackedKeys.Func(func() float64 { return float64(fn()) }, protocol)
+}
+
+// @brief report number of active ban on each scrape
+//
+// @param fn func() int - e.g. banBox.Count
+func (m *LimiterMetrics) TrackBans(protocol string, fn func() int) {
+	m.ActiveBans.Func(func() float64 { return float64(fn()) }, protocol)
+}
+
+// @return func(time.Duration) - assign to limiter OnCleanup
+func (m *LimiterMetrics) ObserveCleanup(protocol string) func(time.Duration) {
+	return func(elapsed time.Duration) {
+		m.CleanupDuration.Observe(elapsed.Seconds(), protocol)
+	}
+}
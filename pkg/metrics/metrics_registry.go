@@ -0,0 +1,315 @@
+package pkg_metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// @brief one metric family able to write itself in prometheus text format
+type Collector interface {
+	Write(w io.Writer)
+}
+
+// @brief hand written prometheus text exposition registry, no client library needed
+type Registry struct {
+	Mtx sync.Mutex
+	Collectors []Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (reg *Registry) Register(c Collector) {
+	reg.Mtx.Lock()
+	defer reg.Mtx.Unlock()
+
+	reg.Collectors = append(reg.Collectors, c)
+}
+
+// @brief write all metric family in text exposition format 0.0.4
+func (reg *Registry) Write(w io.Writer) {
+	reg.Mtx.Lock()
+	collectors := append([]Collector{}, reg.Collectors...)
+	reg.Mtx.Unlock()
+
+	for _, c := range collectors {
+		c.Write(w)
+	}
+}
+
+// @brief /metrics endpoint
+func (reg *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		bw := bufio.NewWriter(w)
+		reg.Write(bw)
+		bw.Flush()
+	})
+}
+
+// --------------------------------------------------------- //
+
+type series struct {
+	Values []string
+	Value float64
+	Func func() float64 // gauge only, evaluated on scrape
+	Buckets []uint64 // histogram only, not cumulative
+	Sum float64
+	Count uint64
+}
+
+type metricVec struct {
+	Mtx sync.Mutex
+	Name string
+	Help string
+	Type string
+	Labels []string
+	Series map[string]*series
+}
+
+func newMetricVec(name, help, typ string, labels []string) metricVec {
+	return metricVec{
+		Name: name,
+		Help: help,
+		Type: typ,
+		Labels: labels,
+		Series: make(map[string]*series),
+	}
+}
+
+// @note caller must hold Mtx
+func (v *metricVec) get(values []string) *series {
+	if len(values) != len(v.Labels) {
+		panic(fmt.Sprintf("metric %s: got %d label value, want %d", v.Name, len(values), len(v.Labels)))
+	}
+
+	key := strings.Join(values, "\xff")
+
+	s, ok := v.Series[key]; if !ok {
+		s = &series{Values: append([]string{}, values...)}
+		v.Series[key] = s
+	}
+
+	return s
+}
+
+// @note caller must hold Mtx, unlike get it doesn't create series
+func (v *metricVec) lookup(values []string) (*series, bool) {
+	s, ok := v.Series[strings.Join(values, "\xff")]
+	return s, ok
+}
+
+// @note caller must hold Mtx
+func (v *metricVec) sorted() []*series {
+	keys := make([]string, 0, len(v.Series))
+	for key := range v.Series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	all := make([]*series, 0, len(keys))
+	for _, key := range keys {
+		all = append(all, v.Series[key])
+	}
+
+	return all
+}
+
+func (v *metricVec) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", v.Name, strings.ReplaceAll(v.Help, "\n", " "))
+	fmt.Fprintf(w, "# TYPE %s %s\n", v.Name, v.Type)
+}
+
+func labelPairs(names, values []string, extra ...string) string {
+	pairs := []string{}
+	for i, name := range names {
+		pairs = append(pairs, name + "=\"" + escapeLabel(values[i]) + "\"")
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i] + "=\"" + escapeLabel(extra[i+1]) + "\"")
+	}
+
+	if len(pairs) <= 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(strings.ToValidUTF8(s, "\uFFFD"))
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// --------------------------------------------------------- //
+
+type CounterVec struct {
+	metricVec
+}
+
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{newMetricVec(name, help, "counter", labels)}
+}
+
+// @brief add value to counter, negative value is ignored
+func (c *CounterVec) Add(v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+
+	c.Mtx.Lock()
+	c.get(values).Value += v
+	c.Mtx.Unlock()
+}
+
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// @return float64 - current value, 0 for unknown label value
+func (c *CounterVec) Value(values ...string) float64 {
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	if s, ok := c.lookup(values); ok {
+		return s.Value
+	}
+	return 0
+}
+
+func (c *CounterVec) Write(w io.Writer) {
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	c.header(w)
+	for _, s := range c.sorted() {
+		fmt.Fprintf(w, "%s%s %s\n", c.Name, labelPairs(c.Labels, s.Values), formatFloat(s.Value))
+	}
+}
+
+// --------------------------------------------------------- //
+
+type GaugeVec struct {
+	metricVec
+}
+
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{newMetricVec(name, help, "gauge", labels)}
+}
+
+func (g *GaugeVec) Set(v float64, values ...string) {
+	g.Mtx.Lock()
+	g.get(values).Value = v
+	g.Mtx.Unlock()
+}
+
+// @brief evaluate fn on each scrape instead of storing value
+func (g *GaugeVec) Func(fn func() float64, values ...string) {
+	g.Mtx.Lock()
+	g.get(values).Func = fn
+	g.Mtx.Unlock()
+}
+
+func (g *GaugeVec) Write(w io.Writer) {
+	g.Mtx.Lock()
+	defer g.Mtx.Unlock()
+
+	g.header(w)
+	for _, s := range g.sorted() {
+		v := s.Value
+		if s.Func != nil {
+			v = s.Func()
+		}
+		fmt.Fprintf(w, "%s%s %s\n", g.Name, labelPairs(g.Labels, s.Values), formatFloat(v))
+	}
+}
+
+// --------------------------------------------------------- //
+
+var DefaultBuckets = []float64{.0001, .00025, .0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1}
+
+type HistogramVec struct {
+	metricVec
+	Bounds []float64 // upper bound, sorted, +Inf is implicit
+}
+
+func NewHistogramVec(name, help string, bounds []float64, labels ...string) *HistogramVec {
+	bounds = append([]float64{}, bounds...)
+	sort.Float64s(bounds)
+
+	return &HistogramVec{
+		metricVec: newMetricVec(name, help, "histogram", labels),
+		Bounds: bounds,
+	}
+}
+
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	h.Mtx.Lock()
+	defer h.Mtx.Unlock()
+
+	s := h.get(values)
+	if s.Buckets == nil {
+		s.Buckets = make([]uint64, len(h.Bounds))
+	}
+
+	// first bucket with bound >= v, beyond last bound only count in +Inf
+	if i := sort.SearchFloat64s(h.Bounds, v); i < len(h.Bounds) {
+		s.Buckets[i]++
+	}
+	s.Sum += v
+	s.Count++
+}
+
+// @return uint64 - number of observation, 0 for unknown label value
+func (h *HistogramVec) Count(values ...string) uint64 {
+	h.Mtx.Lock()
+	defer h.Mtx.Unlock()
+
+	if s, ok := h.lookup(values); ok {
+		return s.Count
+	}
+	return 0
+}
+
+func (h *HistogramVec) Write(w io.Writer) {
+	h.Mtx.Lock()
+	defer h.Mtx.Unlock()
+
+	h.header(w)
+	for _, s := range h.sorted() {
+		cumulative := uint64(0)
+		for i, bound := range h.Bounds {
+			if s.Buckets != nil {
+				cumulative += s.Buckets[i]
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n",
+				h.Name, labelPairs(h.Labels, s.Values, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.Name, labelPairs(h.Labels, s.Values, "le", "+Inf"), s.Count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.Name, labelPairs(h.Labels, s.Values), formatFloat(s.Sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.Name, labelPairs(h.Labels, s.Values), s.Count)
+	}
+}
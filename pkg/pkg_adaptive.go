@@ -0,0 +1,168 @@
+package pkg
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// @brief traffic importance, lower one is shed first when server is overloaded
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+)
+
+// @brief priority from name, e.g. header value
+//
+// @return Priority, bool - false when name isn't "low", "normal" or "high"
+func ParsePriority(name string) (Priority, bool) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "low":
+		return PriorityLow, true
+	case "normal":
+		return PriorityNormal, true
+	case "high":
+		return PriorityHigh, true
+	}
+
+	return PriorityNormal, false
+}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLow:
+		return "low"
+	case PriorityHigh:
+		return "high"
+	}
+
+	return "normal"
+}
+
+// --------------------------------------------------------- //
+
+// @brief global concurrency limit following server latency, AIMD style
+//
+// @note sample over TargetLatency shrink limit by Backoff at most once per round trip, fast sample while limit is well used grow it by about 1 per limit sample
+type AdaptiveLimiter struct {
+	Mtx sync.Mutex
+	MinLimit int
+	MaxLimit int
+	TargetLatency time.Duration // handler latency above it count as overload
+	Backoff float64 // multiplicative decrease, e.g. 0.9
+	Shares map[Priority]float64 // fraction of limit each priority may fill, missing one use 1
+	limit float64
+	inFlight int
+	backoffAt time.Time // last decrease, slow request started before it is already accounted for
+}
+
+// @brief create new adaptive limiter starting at max limit, low priority may fill half of it & normal 80%
+//
+// @param minLimit int - limit never go below it, at least 1
+//
+// @param maxLimit int - limit never go above it
+//
+// @param target time.Duration - handler latency above it count as overload
+//
+// @return *AdaptiveLimiter
+func NewAdaptiveLimiter(minLimit, maxLimit int, target time.Duration) *AdaptiveLimiter {
+	minLimit = max(minLimit, 1)
+	maxLimit = max(maxLimit, minLimit)
+
+	return &AdaptiveLimiter{
+		MinLimit: minLimit,
+		MaxLimit: maxLimit,
+		TargetLatency: target,
+		Backoff: 0.9,
+		Shares: map[Priority]float64{
+			PriorityLow: 0.5,
+			PriorityNormal: 0.8,
+			PriorityHigh: 1,
+		},
+		limit: float64(maxLimit),
+	}
+}
+
+// @brief take one slot when priority share of current limit isn't full
+//
+// @return func(), KeyState, bool - release func (nil when shed, feed handler latency back, safe to call many time), in-flight state against priority share and whether request is admitted
+func (a *AdaptiveLimiter) Acquire(p Priority) (func(), KeyState, bool) {
+	a.Mtx.Lock()
+	defer a.Mtx.Unlock()
+
+	share, ok := a.Shares[p]; if !ok {
+		share = 1
+	}
+	allowed := max(int(a.limit * share), 1)
+
+	if a.inFlight >= allowed {
+		return nil, KeyState{Count: a.inFlight, Limit: uint(allowed)}, false
+	}
+
+	a.inFlight++
+	state := KeyState{Count: a.inFlight, Limit: uint(allowed)}
+
+	start := time.Now()
+	var once sync.Once
+	release := func() {
+		once.Do(func() { a.sample(start, time.Since(start)) })
+	}
+
+	return release, state, true
+}
+
+// @brief release one slot and move limit by its latency
+//
+// @note request running concurrently when limit was cut saw the same overload, so they don't cut it again
+func (a *AdaptiveLimiter) sample(start time.Time, latency time.Duration) {
+	a.Mtx.Lock()
+	defer a.Mtx.Unlock()
+
+	inFlight := a.inFlight
+	a.inFlight--
+
+	if a.TargetLatency > 0 && latency > a.TargetLatency {
+		if start.Before(a.backoffAt) {
+			return
+		}
+
+		a.limit = max(a.limit * a.Backoff, float64(a.MinLimit))
+		a.backoffAt = time.Now()
+		return
+	}
+
+	// only grow when limit is actually reached, idle server learn nothing
+	if float64(inFlight) * 2 >= a.limit {
+		a.limit = min(a.limit + 1 / a.limit, float64(a.MaxLimit))
+	}
+}
+
+// @return int - current limit, before priority share
+func (a *AdaptiveLimiter) Limit() int {
+	a.Mtx.Lock()
+	defer a.Mtx.Unlock()
+
+	return int(a.limit)
+}
+
+// @return int - admitted request still running
+func (a *AdaptiveLimiter) InFlight() int {
+	a.Mtx.Lock()
+	defer a.Mtx.Unlock()
+
+	return a.inFlight
+}
+
+// @brief change bound & target at once, current limit is clamped into new bound
+func (a *AdaptiveLimiter) UpdatePolicy(minLimit, maxLimit int, target time.Duration) {
+	a.Mtx.Lock()
+	defer a.Mtx.Unlock()
+
+	a.MinLimit = max(minLimit, 1)
+	a.MaxLimit = max(maxLimit, a.MinLimit)
+	a.TargetLatency = target
+	a.limit = min(max(a.limit, float64(a.MinLimit)), float64(a.MaxLimit))
+}
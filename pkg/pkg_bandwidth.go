@@ -0,0 +1,171 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// @brief byte budget refilled at rate per second, balance can go negative so big chunk is paced instead of refused
+type bucket struct {
+	tokens float64
+	last time.Time
+}
+
+// @return time.Duration - wait until balance is back to 0 after taking n
+func (b *bucket) take(n int, rate float64, burst float64, now time.Time) time.Duration {
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.tokens + elapsed * rate, burst)
+	}
+	b.last = now
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// @return bool - whether bucket is full again, i.e. safe to forget
+func (b *bucket) idle(rate float64, burst float64, now time.Time) bool {
+	return b.tokens + now.Sub(b.last).Seconds() * rate >= burst
+}
+
+// --------------------------------------------------------- //
+
+// @brief byte per second pacing per key and in total, token bucket based
+//
+// @note over budget caller is delayed, never refused
+type BandwidthLimiter struct {
+	Mtx sync.Mutex
+	PerKey int64 // byte per second per key, 0 unlimited
+	Global int64 // byte per second of all key, 0 unlimited
+	Clock Clock // optional, nil use SystemClock
+	buckets map[string]*bucket
+	global bucket
+}
+
+// @brief create new bandwidth limiter, burst is one second of rate
+//
+// @param perKey int64 - byte per second per key, 0 unlimited
+//
+// @param global int64 - byte per second of all key, 0 unlimited
+//
+// @param opts ...Option - optional setting, e.g. WithClock
+//
+// @return *BandwidthLimiter
+func NewBandwidthLimiter(perKey, global int64, opts ...Option) *BandwidthLimiter {
+	o := ApplyOptions(opts...)
+
+	return &BandwidthLimiter{
+		PerKey: perKey,
+		Global: global,
+		Clock: o.Clock,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// @brief take n byte of key right away
+//
+// @return time.Duration - how long caller should wait before moving the bytes, the longest of key and global
+func (l *BandwidthLimiter) Reserve(key string, n int) time.Duration {
+	l.Mtx.Lock()
+	defer l.Mtx.Unlock()
+
+	now := OrSystemClock(l.Clock).Now()
+	delay := time.Duration(0)
+
+	if l.PerKey > 0 {
+		if l.buckets == nil {
+			l.buckets = make(map[string]*bucket)
+		}
+
+		b := l.buckets[key]
+		if b == nil {
+			b = &bucket{}
+			l.buckets[key] = b
+		}
+
+		delay = b.take(n, float64(l.PerKey), float64(l.PerKey), now)
+	}
+
+	if l.Global > 0 {
+		delay = max(delay, l.global.take(n, float64(l.Global), float64(l.Global), now))
+	}
+
+	return delay
+}
+
+// @brief take n byte of key and sleep until they may move, or ctx is done
+//
+// @note taken byte is not given back on ctx error
+func (l *BandwidthLimiter) WaitN(ctx context.Context, key string, n int) error {
+	delay := l.Reserve(key, n); if delay <= 0 {
+		return nil
+	}
+
+	ticker := OrSystemClock(l.Clock).NewTicker(delay)
+	defer ticker.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-ticker.Chan():
+		return nil
+	}
+}
+
+// @brief change both rate at once
+//
+// @note current balance is kept, new rate apply from next refill
+func (l *BandwidthLimiter) UpdatePolicy(perKey, global int64) {
+	l.Mtx.Lock()
+	defer l.Mtx.Unlock()
+
+	l.PerKey = perKey
+	l.Global = global
+}
+
+// @return int - number of tracked key
+func (l *BandwidthLimiter) KeyCount() int {
+	l.Mtx.Lock()
+	defer l.Mtx.Unlock()
+
+	return len(l.buckets)
+}
+
+// @brief forget key with full bucket, it behave the same as a new one
+//
+// @param ctx context.Context - cancel to stop the loop
+//
+// @param l *BandwidthLimiter
+//
+// @param d time.Duration
+//
+// @param opts ...Option - optional setting, clock default to limiter clock
+func CleanupIdleBandwidth(ctx context.Context, l *BandwidthLimiter, d time.Duration, opts ...Option) {
+	clock := ApplyOptions(opts...).ClockOr(l.Clock)
+
+	ticker := clock.NewTicker(d)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.Chan():
+		}
+
+		l.Mtx.Lock()
+		now := clock.Now()
+		for key, b := range l.buckets {
+			if l.PerKey <= 0 || b.idle(float64(l.PerKey), float64(l.PerKey), now) {
+				delete(l.buckets, key)
+			}
+		}
+		l.Mtx.Unlock()
+	}
+}
@@ -0,0 +1,195 @@
+package pkg
+
+import (
+	"sync"
+	"time"
+)
+
+// @brief time source for limiter, ban box & their cleanup routine
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// @brief ticker created by Clock
+type Ticker interface {
+	Chan() <-chan time.Time
+	Stop()
+}
+
+// @brief optional setting shared by limiter constructor & cleanup routine
+type Options struct {
+	Clock Clock // nil use SystemClock
+}
+
+type Option func(*Options)
+
+// @brief use given clock instead of system clock
+func WithClock(clock Clock) Option {
+	return func(o *Options) {
+		o.Clock = clock
+	}
+}
+
+// @return Options - options after applying each opts in order
+func ApplyOptions(opts ...Option) Options {
+	var o Options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+// @brief clock from options, fallback when it's not set
+func (o Options) ClockOr(fallback Clock) Clock {
+	if o.Clock != nil {
+		return o.Clock
+	}
+
+	return OrSystemClock(fallback)
+}
+
+// @return Clock - clock itself, or SystemClock when nil
+func OrSystemClock(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock{}
+	}
+
+	return clock
+}
+
+// --------------------------------------------------------- //
+
+// @brief Clock backed by time package
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) NewTicker(d time.Duration) Ticker {
+	return systemTicker{time.NewTicker(d)}
+}
+
+type systemTicker struct {
+	ticker *time.Ticker
+}
+
+func (t systemTicker) Chan() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t systemTicker) Stop() {
+	t.ticker.Stop()
+}
+
+// --------------------------------------------------------- //
+
+// @brief manual Clock for test, time only move on Advance or Set
+//
+// @note ticker fire on Advance, tick is dropped when previous one isn't received yet, same as time.Ticker
+type FakeClock struct {
+	Mtx sync.Mutex
+	now time.Time
+	tickers []*fakeTicker
+}
+
+// @param now time.Time - initial time
+//
+// @return *FakeClock
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	t := &fakeTicker{
+		clock: c,
+		c: make(chan time.Time, 1),
+		interval: d,
+		next: c.now.Add(d),
+	}
+	c.tickers = append(c.tickers, t)
+
+	return t
+}
+
+// @brief move time forward by d and fire due ticker
+func (c *FakeClock) Advance(d time.Duration) {
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// @brief jump to t, ticker fire only when t is after current time
+func (c *FakeClock) Set(t time.Time) {
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	c.now = t
+	c.fire()
+}
+
+// @return int - number of running ticker
+func (c *FakeClock) TickerCount() int {
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	return len(c.tickers)
+}
+
+func (c *FakeClock) fire() {
+	for _, t := range c.tickers {
+		if t.next.After(c.now) {
+			continue
+		}
+
+		select {
+		case t.c <- c.now:
+		default:
+		}
+
+		// skip missed tick at once, only one is delivered anyway
+		missed := c.now.Sub(t.next) / t.interval
+		t.next = t.next.Add((missed + 1) * t.interval)
+	}
+}
+
+type fakeTicker struct {
+	clock *FakeClock
+	c chan time.Time
+	interval time.Duration
+	next time.Time
+}
+
+func (t *fakeTicker) Chan() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTicker) Stop() {
+	t.clock.Mtx.Lock()
+	defer t.clock.Mtx.Unlock()
+
+	for i, ticker := range t.clock.tickers {
+		if ticker == t {
+			t.clock.tickers = append(t.clock.tickers[:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
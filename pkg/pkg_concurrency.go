@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"sync"
+)
+
+// @brief in-flight request cap per key and in total, released when handler return
+//
+// @note unlike window limiter, a slot is held for the whole handler duration, so slow request count longer
+type ConcurrencyLimiter struct {
+	Mtx sync.Mutex
+	InFlight map[string]int // key / running request
+	Total int
+	MaxPerKey int // 0 unlimited
+	MaxGlobal int // 0 unlimited
+}
+
+// @brief create new in-flight limiter
+//
+// @param maxPerKey int - running request per key, 0 unlimited
+//
+// @param maxGlobal int - running request of all key, 0 unlimited
+//
+// @return *ConcurrencyLimiter
+func NewConcurrencyLimiter(maxPerKey, maxGlobal int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		InFlight: make(map[string]int),
+		MaxPerKey: maxPerKey,
+		MaxGlobal: maxGlobal,
+	}
+}
+
+// @brief take one slot for key
+//
+// @return func(), KeyState, bool - release func (nil when rejected, safe to call many time), state right after the check and whether request is allowed
+//
+// @note state is of the cap that reject, otherwise of per key cap, or global cap when only global is set
+func (c *ConcurrencyLimiter) Acquire(key string) (func(), KeyState, bool) {
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	if c.InFlight == nil {
+		c.InFlight = make(map[string]int)
+	}
+
+	if c.MaxPerKey > 0 && c.InFlight[key] >= c.MaxPerKey {
+		return nil, c.keyState(key, false), false
+	}
+	if c.MaxGlobal > 0 && c.Total >= c.MaxGlobal {
+		return nil, c.keyState(key, true), false
+	}
+
+	c.InFlight[key]++
+	c.Total++
+	state := c.keyState(key, c.MaxPerKey <= 0)
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() { c.release(key) })
+	}
+
+	return release, state, true
+}
+
+func (c *ConcurrencyLimiter) release(key string) {
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	if c.InFlight[key]--; c.InFlight[key] <= 0 {
+		delete(c.InFlight, key)
+	}
+	c.Total--
+}
+
+// @brief change both cap at once
+//
+// @note running request is kept, lowered cap only reject new request until enough is released
+func (c *ConcurrencyLimiter) UpdatePolicy(maxPerKey, maxGlobal int) {
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	c.MaxPerKey = maxPerKey
+	c.MaxGlobal = maxGlobal
+}
+
+// @return int, int - current per key and global cap
+func (c *ConcurrencyLimiter) Policy() (int, int) {
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	return c.MaxPerKey, c.MaxGlobal
+}
+
+// @return int - running request of key, all key when key is empty
+func (c *ConcurrencyLimiter) Running(key string) int {
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	if len(key) <= 0 {
+		return c.Total
+	}
+
+	return c.InFlight[key]
+}
+
+// @param global bool - report global count & cap instead of key one
+func (c *ConcurrencyLimiter) keyState(key string, global bool) KeyState {
+	if global && c.MaxGlobal > 0 {
+		return KeyState{Key: key, Count: c.Total, Limit: uint(c.MaxGlobal)}
+	}
+
+	return KeyState{Key: key, Count: c.InFlight[key], Limit: uint(max(c.MaxPerKey, 0))}
+}
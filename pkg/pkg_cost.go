@@ -0,0 +1,51 @@
+package pkg
+
+import (
+	"maps"
+	"sync"
+)
+
+// @brief request cost per route or method name, missing name cost 1
+type CostTable struct {
+	Mtx sync.RWMutex
+	Costs map[string]int // name / unit consumed per request, e.g. "POST /upload" or grpc full method
+}
+
+// @param costs map[string]int - copied, non positive cost is ignored
+//
+// @return *CostTable
+func NewCostTable(costs map[string]int) *CostTable {
+	c := &CostTable{}
+	c.Update(costs)
+
+	return c
+}
+
+// @return int - cost of name, 1 when table is nil or name isn't set
+func (c *CostTable) Cost(name string) int {
+	if c == nil {
+		return 1
+	}
+
+	c.Mtx.RLock()
+	defer c.Mtx.RUnlock()
+
+	if cost, ok := c.Costs[name]; ok && cost > 0 {
+		return cost
+	}
+
+	return 1
+}
+
+// @brief replace every cost at once, e.g. on config reload
+func (c *CostTable) Update(costs map[string]int) {
+	next := maps.Clone(costs)
+	if next == nil {
+		next = make(map[string]int)
+	}
+
+	c.Mtx.Lock()
+	defer c.Mtx.Unlock()
+
+	c.Costs = next
+}
@@ -0,0 +1,49 @@
+package pkg
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ResultAllowed = "allowed"
+	ResultRejected = "rejected" // limit exceeded
+	ResultDenied = "denied" // deny list
+	ResultBanned = "banned" // temporary ban
+	ResultExempt = "exempt" // allow list, not counted
+	ResultInFlight = "in_flight" // concurrency limit exceeded, not counted as rejection for ban
+	ResultShed = "shed" // server overloaded, dropped by adaptive limiter
+)
+
+// @brief outcome of one limiter middleware check
+type Decision struct {
+	Protocol string // "http" or "grpc"
+	Policy string
+	Route string // http route pattern or grpc full method
+	Key string // client ip
+	Result string // one of Result* constant
+	Count int // request inside window after the check
+	Cost int // unit consumed by this request, 1 unless weighted, 0 when not counted
+	Limit uint
+	Remaining int
+	RetryAfter time.Duration // when rejected or banned
+	Latency time.Duration // time spent to decide
+}
+
+// @brief hook for metrics, tracing, logging etc. called once per decision
+//
+// @note called on request path, implementation must be cheap & concurrency safe
+type DecisionObserver interface {
+	ObserveDecision(ctx context.Context, d Decision)
+}
+
+// @brief fill count, remaining and retry after from key state
+func (d *Decision) FromKeyState(state KeyState, now time.Time) {
+	d.Count = state.Count
+	d.Limit = state.Limit
+	d.Remaining = max(int(state.Limit) - state.Count, 0)
+
+	if d.Remaining <= 0 && state.ResetAt.After(now) {
+		d.RetryAfter = state.ResetAt.Sub(now)
+	}
+}
@@ -0,0 +1,334 @@
+package pkg
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// @brief one request waiting for a fair queue slot
+type fairWaiter struct {
+	tenant string
+	priority Priority
+	start float64 // virtual time the request may start
+	finish float64 // virtual time it is done, smallest is served first
+	seq uint64
+	ready chan struct{} // closed once admitted or evicted
+	admitted bool
+}
+
+// @brief weighted fair queuing between tenant in front of the handler
+//
+// @note each tenant get a share of slot by its priority weight, e.g. high tenant is served 16 time as often as low one, when queue is full lowest priority waiter is evicted for higher one
+type FairQueue struct {
+	Mtx sync.Mutex
+	MaxInFlight int // running request of all tenant, 0 never queue
+	MaxQueue int // waiting request of all tenant, 0 reject at once when no slot is free
+	MaxWait time.Duration // 0 wait until ctx is done
+	Weights map[Priority]float64 // service share per priority, missing one use 1
+	inFlight int
+	vtime float64
+	seq uint64
+	tenants map[string]float64 // tenant / finish tag of its last request, dropped once vtime pass it
+	waiters []*fairWaiter
+}
+
+// @brief create new fair queue, low tenant weight 1, normal 4 & high 16
+//
+// @param maxInFlight int - running request of all tenant, 0 never queue
+//
+// @param maxQueue int - waiting request of all tenant, 0 disable waiting
+//
+// @param maxWait time.Duration - longest wait, 0 only bounded by ctx
+//
+// @return *FairQueue
+func NewFairQueue(maxInFlight, maxQueue int, maxWait time.Duration) *FairQueue {
+	return &FairQueue{
+		MaxInFlight: maxInFlight,
+		MaxQueue: maxQueue,
+		MaxWait: maxWait,
+		Weights: map[Priority]float64{
+			PriorityLow: 1,
+			PriorityNormal: 4,
+			PriorityHigh: 16,
+		},
+		tenants: make(map[string]float64),
+	}
+}
+
+// @brief take one slot for tenant, waiting behind other tenant by weighted fair order when all slot is busy
+//
+// @return func(), KeyState, error - release func (safe to call many time), in-flight state against MaxInFlight and ErrQueueFull when queue is full or waiter is evicted, ctx error on timeout
+func (q *FairQueue) Acquire(ctx context.Context, tenant string, p Priority) (func(), KeyState, error) {
+	return q.AcquireN(ctx, tenant, p, 1)
+}
+
+// @brief same as Acquire, n weight the request in tenant share, still one slot
+func (q *FairQueue) AcquireN(ctx context.Context, tenant string, p Priority, n int) (func(), KeyState, error) {
+	q.Mtx.Lock()
+
+	if q.MaxInFlight <= 0 {
+		q.Mtx.Unlock()
+		return func() {}, KeyState{}, nil
+	}
+
+	if q.inFlight < q.MaxInFlight && len(q.waiters) <= 0 {
+		w := q.tag(tenant, p, max(n, 1))
+		q.admit(w)
+		state := q.state()
+		q.Mtx.Unlock()
+
+		return q.releaser(w), state, nil
+	}
+
+	if len(q.waiters) >= q.MaxQueue && !q.evict(p) {
+		state := q.state()
+		q.Mtx.Unlock()
+
+		return nil, state, ErrQueueFull
+	}
+
+	w := q.tag(tenant, p, max(n, 1))
+	q.waiters = append(q.waiters, w)
+	maxWait := q.MaxWait
+	q.Mtx.Unlock()
+
+	if maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxWait)
+		defer cancel()
+	}
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+	}
+
+	q.Mtx.Lock()
+	defer q.Mtx.Unlock()
+
+	if !w.admitted {
+		if q.remove(w) {
+			// still waiting, gave up on ctx
+			return nil, q.state(), ctx.Err()
+		}
+
+		return nil, q.state(), ErrQueueFull
+	}
+
+	// admitted at the same time ctx is done, the slot is still usable
+	return q.releaser(w), q.state(), nil
+}
+
+// @brief waiter with its virtual finish tag, tenant get later tag the more it use
+func (q *FairQueue) tag(tenant string, p Priority, n int) *fairWaiter {
+	if q.tenants == nil {
+		q.tenants = make(map[string]float64)
+	}
+
+	weight, ok := q.Weights[p]; if !ok || weight <= 0 {
+		weight = 1
+	}
+
+	start := max(q.vtime, q.tenants[tenant])
+	finish := start + float64(n) / weight
+	q.tenants[tenant] = finish
+	q.seq++
+
+	return &fairWaiter{
+		tenant: tenant,
+		priority: p,
+		start: start,
+		finish: finish,
+		seq: q.seq,
+		ready: make(chan struct{}),
+	}
+}
+
+func (q *FairQueue) admit(w *fairWaiter) {
+	q.inFlight++
+	q.advance(w.start)
+	w.admitted = true
+	close(w.ready)
+}
+
+// @brief move virtual time forward and forget tenant whose last tag is behind it
+//
+// @note start tag is max(vtime, tag), so forgotten tenant is served the same, map only hold tenant with request running or waiting
+func (q *FairQueue) advance(vtime float64) {
+	if vtime <= q.vtime {
+		return
+	}
+
+	q.vtime = vtime
+	for tenant, finish := range q.tenants {
+		if finish <= q.vtime {
+			delete(q.tenants, tenant)
+		}
+	}
+}
+
+// @brief give back the share of waiter that never ran, later waiter of same tenant move up
+func (q *FairQueue) rollback(w *fairWaiter) {
+	cost := w.finish - w.start
+	for _, waiter := range q.waiters {
+		if waiter.tenant == w.tenant && waiter.start >= w.finish {
+			waiter.start -= cost
+			waiter.finish -= cost
+		}
+	}
+
+	finish, ok := q.tenants[w.tenant]; if !ok {
+		return
+	}
+
+	finish -= cost
+	if finish <= q.vtime {
+		delete(q.tenants, w.tenant)
+		return
+	}
+	q.tenants[w.tenant] = finish
+}
+
+// @brief drop lowest priority & latest finishing waiter when it rank below p
+//
+// @return bool - whether a place is freed
+func (q *FairQueue) evict(p Priority) bool {
+	victim := -1
+	for i, w := range q.waiters {
+		if w.priority >= p {
+			continue
+		}
+		if victim < 0 || w.priority < q.waiters[victim].priority ||
+			(w.priority == q.waiters[victim].priority && w.finish > q.waiters[victim].finish) {
+			victim = i
+		}
+	}
+
+	if victim < 0 {
+		return false
+	}
+
+	w := q.waiters[victim]
+	q.waiters = append(q.waiters[:victim], q.waiters[victim + 1:]...)
+	q.rollback(w)
+	close(w.ready)
+
+	return true
+}
+
+// @return bool - whether waiter was still queued
+func (q *FairQueue) remove(w *fairWaiter) bool {
+	for i, waiter := range q.waiters {
+		if waiter == w {
+			q.waiters = append(q.waiters[:i], q.waiters[i + 1:]...)
+			q.rollback(w)
+			return true
+		}
+	}
+
+	return false
+}
+
+func (q *FairQueue) releaser(w *fairWaiter) func() {
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			q.Mtx.Lock()
+			defer q.Mtx.Unlock()
+
+			// self clocked, finished request is done in virtual time too
+			q.inFlight--
+			q.advance(w.finish)
+			q.dispatch()
+		})
+	}
+}
+
+// @brief hand free slot to waiter with smallest finish tag
+func (q *FairQueue) dispatch() {
+	for q.inFlight < q.MaxInFlight && len(q.waiters) > 0 {
+		next := 0
+		for i, w := range q.waiters {
+			if w.finish < q.waiters[next].finish ||
+				(w.finish == q.waiters[next].finish && w.seq < q.waiters[next].seq) {
+				next = i
+			}
+		}
+
+		w := q.waiters[next]
+		q.waiters = append(q.waiters[:next], q.waiters[next + 1:]...)
+		q.admit(w)
+	}
+
+	// nothing running or waiting, every tenant start over from zero
+	if len(q.waiters) <= 0 && q.inFlight <= 0 {
+		clear(q.tenants)
+		q.vtime = 0
+	}
+}
+
+func (q *FairQueue) state() KeyState {
+	return KeyState{Count: q.inFlight, Limit: uint(max(q.MaxInFlight, 0))}
+}
+
+// @brief change slot, queue size and max wait at once
+//
+// @note raising MaxInFlight admit waiter right away, waiting request keep its own deadline
+func (q *FairQueue) UpdatePolicy(maxInFlight, maxQueue int, maxWait time.Duration) {
+	q.Mtx.Lock()
+	defer q.Mtx.Unlock()
+
+	q.MaxInFlight = maxInFlight
+	q.MaxQueue = maxQueue
+	q.MaxWait = maxWait
+
+	if q.MaxInFlight <= 0 {
+		// unlimited, let every waiter through
+		for _, w := range q.waiters {
+			q.inFlight++
+			w.admitted = true
+			close(w.ready)
+		}
+		q.waiters = nil
+		return
+	}
+
+	q.dispatch()
+}
+
+// @return int - running request of all tenant
+func (q *FairQueue) InFlight() int {
+	q.Mtx.Lock()
+	defer q.Mtx.Unlock()
+
+	return q.inFlight
+}
+
+// @return int - tenant still holding a finish tag, i.e. with request running or waiting
+func (q *FairQueue) TenantCount() int {
+	q.Mtx.Lock()
+	defer q.Mtx.Unlock()
+
+	return len(q.tenants)
+}
+
+// @return int - waiting request of tenant, all tenant when tenant is empty
+func (q *FairQueue) Len(tenant string) int {
+	q.Mtx.Lock()
+	defer q.Mtx.Unlock()
+
+	if len(tenant) <= 0 {
+		return len(q.waiters)
+	}
+
+	n := 0
+	for _, w := range q.waiters {
+		if w.tenant == tenant {
+			n++
+		}
+	}
+
+	return n
+}
@@ -1,7 +1,7 @@
 package pkg
 
 import (
-	"log"
+	"log/slog"
 	"math/rand"
 )
 
@@ -14,7 +14,7 @@ type Number interface {
 // @note safe for seed concurency
 func RandomNumberSign[T Number](r *rand.Rand, minRange, maxRange T) T {
 	if minRange > maxRange {
-		log.Printf("min range is larger than max range, swaping\n")
+		slog.Warn("min range is larger than max range, swaping")
 		minRange, maxRange = maxRange, minRange
 	}
 
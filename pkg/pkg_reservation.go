@@ -0,0 +1,139 @@
+package pkg
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// @brief slot taken ahead of time, similar to golang.org/x/time/rate Reservation
+//
+// @note slot is counted from reservation time, act only after Delay or Cancel to give it back
+type Reservation struct {
+	ok bool
+	at time.Time
+	clock Clock
+	refund func()
+	once sync.Once
+}
+
+// @brief create reservation, used by limiter implementation
+//
+// @param ok bool - false when request can never fit, e.g. 0 max request
+//
+// @param at time.Time - when reserved slot start
+//
+// @param clock Clock - limiter clock, nil use SystemClock
+//
+// @param refund func() - give slot back to key, nil when nothing to refund
+//
+// @return *Reservation
+func NewReservation(ok bool, at time.Time, clock Clock, refund func()) *Reservation {
+	return &Reservation{
+		ok: ok,
+		at: at,
+		clock: OrSystemClock(clock),
+		refund: refund,
+	}
+}
+
+// @return bool - whether slot is reserved, Delay is meaningless when false
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// @return time.Time - when reserved slot start
+func (r *Reservation) At() time.Time {
+	return r.at
+}
+
+// @return time.Duration - wait before acting, 0 act now
+func (r *Reservation) Delay() time.Duration {
+	return r.DelayFrom(r.clock.Now())
+}
+
+// @return time.Duration - wait from now before acting, 0 act now
+func (r *Reservation) DelayFrom(now time.Time) time.Duration {
+	return max(r.at.Sub(now), 0)
+}
+
+// @brief give reserved slot back to key, e.g. job is aborted
+//
+// @note safe to call many time, only the first one refund, nothing is refunded once slot time has passed, same as golang.org/x/time/rate
+func (r *Reservation) Cancel() {
+	if !r.ok || r.refund == nil || r.clock.Now().After(r.at) {
+		return
+	}
+
+	r.once.Do(r.refund)
+}
+
+// --------------------------------------------------------- //
+
+// @brief earliest time n more request fit in sliding window log
+//
+// @param requests []time.Time - key log, may hold future reserved time
+//
+// @param maxReq uint - max request in window
+//
+// @param window time.Duration
+//
+// @param n int - request to fit at once
+//
+// @param now time.Time
+//
+// @return time.Time, bool - slot time, not before now, and false when n is over maxReq
+func NextSlot(requests []time.Time, maxReq uint, window time.Duration, n int, now time.Time) (time.Time, bool) {
+	if n <= 0 {
+		return now, true
+	}
+	if n > int(maxReq) {
+		return now, false
+	}
+
+	valid := make([]time.Time, 0, len(requests))
+	for _, t := range requests {
+		if now.Sub(t) <= window {
+			valid = append(valid, t)
+		}
+	}
+
+	over := len(valid) + n - int(maxReq)
+	if over <= 0 {
+		return now, true
+	}
+
+	// the over-th oldest request must leave window first, it still count on its last instant
+	sort.Slice(valid, func(i, j int) bool {
+		return valid[i].Before(valid[j])
+	})
+
+	return valid[over - 1].Add(window + time.Nanosecond), true
+}
+
+// @brief remove one occurrence of t from log
+//
+// @return []time.Time, bool - log without t, and whether t is found
+func RemoveSlot(requests []time.Time, t time.Time) ([]time.Time, bool) {
+	for i, r := range requests {
+		if r.Equal(t) {
+			return append(requests[:i], requests[i+1:]...), true
+		}
+	}
+
+	return requests, false
+}
+
+// @brief remove the n latest request not after now, i.e. the last n counted, reserved future slot is kept
+//
+// @return []time.Time - log without them
+func RefundSlots(requests []time.Time, n int, now time.Time) []time.Time {
+	for i := len(requests) - 1; i >= 0 && n > 0; i-- {
+		if !requests[i].After(now) {
+			requests = append(requests[:i], requests[i+1:]...)
+			n--
+		}
+	}
+
+	return requests
+}
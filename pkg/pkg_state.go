@@ -0,0 +1,29 @@
+package pkg
+
+import (
+	"time"
+)
+
+// @brief snapshot of a tracked limiter key
+type KeyState struct {
+	Key string `json:"key"`
+	Count int `json:"count"` // request inside window
+	Limit uint `json:"limit"`
+	Methods map[string]int `json:"methods,omitempty"` // grpc only, request count per method
+	ResetAt time.Time `json:"reset_at"` // when the oldest request leave window
+}
+
+// @brief limiter counting per key only
+//
+// @note HttpRateLimiter satisfy this, so it can be shared with grpc middleware for one quota across protocol
+type KeyLimiter interface {
+	CheckRequestLimitState(key string) (KeyState, bool)
+	CheckRequestLimitStateN(key string, n int) (KeyState, bool) // n unit at once, all or nothing
+	Policy() (uint, time.Duration)
+	Now() time.Time
+}
+
+// @brief limiter that can give back request it just counted, e.g. when request is turned away after all
+type KeyRefunder interface {
+	RefundN(key string, n int)
+}
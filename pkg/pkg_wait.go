@@ -0,0 +1,159 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// shortest sleep between check, also used when limiter has no reset time, e.g. 0 max request
+const minWaitStep = time.Millisecond
+
+var ErrQueueFull = errors.New("wait queue is full")
+
+// cost over max request of the window, waiting would never succeed
+var ErrOverLimit = errors.New("cost exceed limit")
+
+// @brief KeyLimiter that can block until key is allowed
+//
+// @note HttpRateLimiter satisfy this, grpc limiter does per method
+type KeyWaiter interface {
+	KeyLimiter
+	Wait(ctx context.Context, key string) (KeyState, error)
+	WaitN(ctx context.Context, key string, n int) (KeyState, error)
+}
+
+// @brief block until key is allowed or ctx is done, sleeping until the oldest request leave window
+//
+// @param clock Clock - limiter clock, nil use SystemClock
+//
+// @return KeyState, error - state of the allowed check, or last rejected state with ctx error
+//
+// @note request is counted once, only when it's allowed
+func WaitKey(ctx context.Context, l KeyLimiter, key string, clock Clock) (KeyState, error) {
+	return WaitKeyN(ctx, l, key, 1, clock)
+}
+
+// @brief same as WaitKey for n unit at once, e.g. weighted request
+//
+// @note ErrOverLimit right away when n is over limit, it would never fit
+func WaitKeyN(ctx context.Context, l KeyLimiter, key string, n int, clock Clock) (KeyState, error) {
+	clock = OrSystemClock(clock)
+
+	for {
+		state, ok := l.CheckRequestLimitStateN(key, n); if ok {
+			return state, nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return state, err
+		}
+		if state.Limit > 0 && uint(n) > state.Limit {
+			return state, ErrOverLimit
+		}
+
+		ticker := clock.NewTicker(max(state.ResetAt.Sub(l.Now()), minWaitStep))
+		select {
+		case <-ctx.Done():
+			ticker.Stop()
+			return state, ctx.Err()
+		case <-ticker.Chan():
+			ticker.Stop()
+		}
+	}
+}
+
+// --------------------------------------------------------- //
+
+// @brief bounded per key queue for over limit request, waiting on limiter instead of immediate rejection
+type WaitQueue struct {
+	Mtx sync.Mutex
+	Waiting map[string]int // key / waiting request
+	MaxPerKey int // waiting request per key, excess is rejected at once, 0 disable waiting
+	MaxWait time.Duration // 0 wait until ctx is done
+}
+
+// @brief create new wait queue
+//
+// @param maxPerKey int - waiting request per key, 0 disable waiting
+//
+// @param maxWait time.Duration - longest wait, 0 only bounded by ctx
+//
+// @return *WaitQueue
+func NewWaitQueue(maxPerKey int, maxWait time.Duration) *WaitQueue {
+	return &WaitQueue{
+		Waiting: make(map[string]int),
+		MaxPerKey: maxPerKey,
+		MaxWait: maxWait,
+	}
+}
+
+// @brief wait on limiter as one of key queue
+//
+// @return KeyState, error - ErrQueueFull without any check when key queue is full, otherwise same as KeyWaiter.Wait
+func (q *WaitQueue) Wait(ctx context.Context, l KeyWaiter, key string) (KeyState, error) {
+	return q.WaitN(ctx, l, key, 1)
+}
+
+// @brief same as Wait for n unit at once, still one place in queue
+func (q *WaitQueue) WaitN(ctx context.Context, l KeyWaiter, key string, n int) (KeyState, error) {
+	q.Mtx.Lock()
+	if q.Waiting == nil {
+		q.Waiting = make(map[string]int)
+	}
+	if q.Waiting[key] >= q.MaxPerKey {
+		q.Mtx.Unlock()
+		return KeyState{}, ErrQueueFull
+	}
+	q.Waiting[key]++
+	maxWait := q.MaxWait
+	q.Mtx.Unlock()
+
+	defer q.leave(key)
+
+	if maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, maxWait)
+		defer cancel()
+	}
+
+	return l.WaitN(ctx, key, n)
+}
+
+func (q *WaitQueue) leave(key string) {
+	q.Mtx.Lock()
+	defer q.Mtx.Unlock()
+
+	if q.Waiting[key]--; q.Waiting[key] <= 0 {
+		delete(q.Waiting, key)
+	}
+}
+
+// @brief change queue size and max wait at once
+//
+// @note waiting request keep its own deadline, new policy apply from next wait
+func (q *WaitQueue) UpdatePolicy(maxPerKey int, maxWait time.Duration) {
+	q.Mtx.Lock()
+	defer q.Mtx.Unlock()
+
+	q.MaxPerKey = maxPerKey
+	q.MaxWait = maxWait
+}
+
+// @return int - waiting request of key, all key when key is empty
+func (q *WaitQueue) Len(key string) int {
+	q.Mtx.Lock()
+	defer q.Mtx.Unlock()
+
+	if len(key) > 0 {
+		return q.Waiting[key]
+	}
+
+	total := 0
+	for _, n := range q.Waiting {
+		total += n
+	}
+
+	return total
+}
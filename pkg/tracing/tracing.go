@@ -0,0 +1,219 @@
+package pkg_tracing
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const TracerName = "github.com/prothegee/network-limiter-go"
+
+// @brief create otlp/grpc exporting tracer provider
+//
+// @note also install w3c trace context & baggage as global propagator
+//
+// @param endpoint string - otlp collector "host:port"
+//
+// @param insecure bool - plaintext connection to collector
+//
+// @param ratio float64 - parent based sampling ratio, 0 to 1
+//
+// @param serviceName string
+//
+// @return *sdktrace.TracerProvider, error - caller should Shutdown provider to flush span
+func NewProvider(ctx context.Context, endpoint string, insecure bool, ratio float64, serviceName string) (*sdktrace.TracerProvider, error) {
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+	if insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+
+	exporter, err := otlptracegrpc.New(ctx, opts...); if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", serviceName))),
+	)
+
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{}))
+
+	return tp, nil
+}
+
+// per process hmac key, plain hash of ipv4 space is enumerated in seconds
+var hashSecret = func() []byte {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}()
+
+// @brief short keyed hash so client ip doesn't land in trace backend
+//
+// @note same key give same hash only within one process, hash can't be reversed without the process secret
+func HashKey(key string) string {
+	mac := hmac.New(sha256.New, hashSecret)
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+// --------------------------------------------------------- //
+
+// @brief decision observer that annotate current span with limiter decision
+type DecisionTracer struct{}
+
+// @note satisfy gen.DecisionObserver, append it to middleware Observers
+func NewDecisionTracer() *DecisionTracer {
+	return &DecisionTracer{}
+}
+
+func (t *DecisionTracer) ObserveDecision(ctx context.Context, d gen.Decision) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	attrs := []attribute.KeyValue{
+		attribute.String("ratelimit.policy", d.Policy),
+		attribute.String("ratelimit.result", d.Result),
+		attribute.String("ratelimit.key_hash", HashKey(d.Key)),
+		attribute.Int("ratelimit.limit", int(d.Limit)),
+		attribute.Int("ratelimit.remaining", d.Remaining),
+	}
+	if d.RetryAfter > 0 {
+		attrs = append(attrs, attribute.Float64("ratelimit.retry_after", d.RetryAfter.Seconds()))
+	}
+
+	span.SetAttributes(attrs...)
+	span.AddEvent("ratelimit.decision", trace.WithAttributes(
+		attribute.String("ratelimit.result", d.Result),
+		attribute.Int("ratelimit.count", d.Count),
+		attribute.Float64("ratelimit.latency", d.Latency.Seconds()),
+	))
+}
+
+// --------------------------------------------------------- //
+
+type statusRecorder struct {
+	http.ResponseWriter
+	Code int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.Code = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+// @brief start server span for each http request, put it before the limiter middleware
+//
+// @param tp trace.TracerProvider
+//
+// @param next http.Handler
+//
+// @return http.Handler
+func HttpServerSpan(tp trace.TracerProvider, next http.Handler) http.Handler {
+	tracer := tp.Tracer(TracerName)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			))
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, Code: http.StatusOK}
+		r = r.WithContext(ctx)
+
+		next.ServeHTTP(rec, r)
+
+		// pattern is known only after mux routing
+		if len(r.Pattern) > 0 {
+			span.SetName(r.Method + " " + r.Pattern)
+			span.SetAttributes(attribute.String("http.route", r.Pattern))
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.Code))
+		if rec.Code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.Code))
+		}
+	})
+}
+
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// @brief start server span for each unary call, chain it before the limiter interceptor
+//
+// @param tp trace.TracerProvider
+//
+// @return grpc.UnaryServerInterceptor
+func UnaryServerSpan(tp trace.TracerProvider) grpc.UnaryServerInterceptor {
+	tracer := tp.Tracer(TracerName)
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+		}
+
+		ctx, span := tracer.Start(ctx, info.FullMethod,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("rpc.system", "grpc"),
+				attribute.String("rpc.method", info.FullMethod),
+			))
+		defer span.End()
+
+		resp, err := handler(ctx, req)
+
+		st, _ := status.FromError(err)
+		span.SetAttributes(attribute.Int("rpc.grpc.status_code", int(st.Code())))
+		if err != nil {
+			span.SetStatus(codes.Error, st.Message())
+		}
+
+		return resp, err
+	}
+}
@@ -0,0 +1,41 @@
+package pkg_unified
+
+import (
+	"net/http"
+	"strings"
+)
+
+// @brief whether request is grpc, http/2 with "application/grpc" content-type
+func IsGrpcRequest(r *http.Request) bool {
+	return r.ProtoMajor == 2 &&
+		strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// @brief route grpc request to grpcHandler, everything else to httpHandler
+//
+// @note grpcHandler is usually *grpc.Server, its ServeHTTP need http/2, see Protocols
+//
+// @param grpcHandler http.Handler
+//
+// @param httpHandler http.Handler
+//
+// @return http.Handler
+func Handler(grpcHandler, httpHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsGrpcRequest(r) {
+			grpcHandler.ServeHTTP(w, r)
+			return
+		}
+
+		httpHandler.ServeHTTP(w, r)
+	})
+}
+
+// @brief http/1.1 plus cleartext http/2 (h2c), so grpc client can share plain http port
+func Protocols() *http.Protocols {
+	protocols := &http.Protocols{}
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	return protocols
+}
@@ -0,0 +1,349 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.0
+// - protoc             v6.33.1
+// source: admin.proto
+
+package protobuf
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RateLimiterAdmin_ListKeys_FullMethodName     = "/admin.RateLimiterAdmin/ListKeys"
+	RateLimiterAdmin_GetKeyState_FullMethodName  = "/admin.RateLimiterAdmin/GetKeyState"
+	RateLimiterAdmin_ResetKey_FullMethodName     = "/admin.RateLimiterAdmin/ResetKey"
+	RateLimiterAdmin_ListBans_FullMethodName     = "/admin.RateLimiterAdmin/ListBans"
+	RateLimiterAdmin_Ban_FullMethodName          = "/admin.RateLimiterAdmin/Ban"
+	RateLimiterAdmin_Unban_FullMethodName        = "/admin.RateLimiterAdmin/Unban"
+	RateLimiterAdmin_UpdatePolicy_FullMethodName = "/admin.RateLimiterAdmin/UpdatePolicy"
+)
+
+// RateLimiterAdminClient is the client API for RateLimiterAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RateLimiterAdminClient interface {
+	ListKeys(ctx context.Context, in *ListKeysReq, opts ...grpc.CallOption) (*ListKeysResp, error)
+	GetKeyState(ctx context.Context, in *GetKeyStateReq, opts ...grpc.CallOption) (*KeyState, error)
+	ResetKey(ctx context.Context, in *ResetKeyReq, opts ...grpc.CallOption) (*ResetKeyResp, error)
+	ListBans(ctx context.Context, in *ListBansReq, opts ...grpc.CallOption) (*ListBansResp, error)
+	Ban(ctx context.Context, in *BanReq, opts ...grpc.CallOption) (*BanEntry, error)
+	Unban(ctx context.Context, in *UnbanReq, opts ...grpc.CallOption) (*UnbanResp, error)
+	UpdatePolicy(ctx context.Context, in *Policy, opts ...grpc.CallOption) (*Policy, error)
+}
+
+type rateLimiterAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRateLimiterAdminClient(cc grpc.ClientConnInterface) RateLimiterAdminClient {
+	return &rateLimiterAdminClient{cc}
+}
+
+func (c *rateLimiterAdminClient) ListKeys(ctx context.Context, in *ListKeysReq, opts ...grpc.CallOption) (*ListKeysResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListKeysResp)
+	err := c.cc.Invoke(ctx, RateLimiterAdmin_ListKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rateLimiterAdminClient) GetKeyState(ctx context.Context, in *GetKeyStateReq, opts ...grpc.CallOption) (*KeyState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(KeyState)
+	err := c.cc.Invoke(ctx, RateLimiterAdmin_GetKeyState_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rateLimiterAdminClient) ResetKey(ctx context.Context, in *ResetKeyReq, opts ...grpc.CallOption) (*ResetKeyResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetKeyResp)
+	err := c.cc.Invoke(ctx, RateLimiterAdmin_ResetKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rateLimiterAdminClient) ListBans(ctx context.Context, in *ListBansReq, opts ...grpc.CallOption) (*ListBansResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListBansResp)
+	err := c.cc.Invoke(ctx, RateLimiterAdmin_ListBans_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rateLimiterAdminClient) Ban(ctx context.Context, in *BanReq, opts ...grpc.CallOption) (*BanEntry, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BanEntry)
+	err := c.cc.Invoke(ctx, RateLimiterAdmin_Ban_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rateLimiterAdminClient) Unban(ctx context.Context, in *UnbanReq, opts ...grpc.CallOption) (*UnbanResp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnbanResp)
+	err := c.cc.Invoke(ctx, RateLimiterAdmin_Unban_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *rateLimiterAdminClient) UpdatePolicy(ctx context.Context, in *Policy, opts ...grpc.CallOption) (*Policy, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Policy)
+	err := c.cc.Invoke(ctx, RateLimiterAdmin_UpdatePolicy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RateLimiterAdminServer is the server API for RateLimiterAdmin service.
+// All implementations must embed UnimplementedRateLimiterAdminServer
+// for forward compatibility.
+type RateLimiterAdminServer interface {
+	ListKeys(context.Context, *ListKeysReq) (*ListKeysResp, error)
+	GetKeyState(context.Context, *GetKeyStateReq) (*KeyState, error)
+	ResetKey(context.Context, *ResetKeyReq) (*ResetKeyResp, error)
+	ListBans(context.Context, *ListBansReq) (*ListBansResp, error)
+	Ban(context.Context, *BanReq) (*BanEntry, error)
+	Unban(context.Context, *UnbanReq) (*UnbanResp, error)
+	UpdatePolicy(context.Context, *Policy) (*Policy, error)
+	mustEmbedUnimplementedRateLimiterAdminServer()
+}
+
+// UnimplementedRateLimiterAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRateLimiterAdminServer struct{}
+
+func (UnimplementedRateLimiterAdminServer) ListKeys(context.Context, *ListKeysReq) (*ListKeysResp, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListKeys not implemented")
+}
+func (UnimplementedRateLimiterAdminServer) GetKeyState(context.Context, *GetKeyStateReq) (*KeyState, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetKeyState not implemented")
+}
+func (UnimplementedRateLimiterAdminServer) ResetKey(context.Context, *ResetKeyReq) (*ResetKeyResp, error) {
+	return nil, status.Error(codes.Unimplemented, "method ResetKey not implemented")
+}
+func (UnimplementedRateLimiterAdminServer) ListBans(context.Context, *ListBansReq) (*ListBansResp, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListBans not implemented")
+}
+func (UnimplementedRateLimiterAdminServer) Ban(context.Context, *BanReq) (*BanEntry, error) {
+	return nil, status.Error(codes.Unimplemented, "method Ban not implemented")
+}
+func (UnimplementedRateLimiterAdminServer) Unban(context.Context, *UnbanReq) (*UnbanResp, error) {
+	return nil, status.Error(codes.Unimplemented, "method Unban not implemented")
+}
+func (UnimplementedRateLimiterAdminServer) UpdatePolicy(context.Context, *Policy) (*Policy, error) {
+	return nil, status.Error(codes.Unimplemented, "method UpdatePolicy not implemented")
+}
+func (UnimplementedRateLimiterAdminServer) mustEmbedUnimplementedRateLimiterAdminServer() {}
+func (UnimplementedRateLimiterAdminServer) testEmbeddedByValue()                          {}
+
+// UnsafeRateLimiterAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RateLimiterAdminServer will
+// result in compilation errors.
+type UnsafeRateLimiterAdminServer interface {
+	mustEmbedUnimplementedRateLimiterAdminServer()
+}
+
+func RegisterRateLimiterAdminServer(s grpc.ServiceRegistrar, srv RateLimiterAdminServer) {
+	// If the following call panics, it indicates UnimplementedRateLimiterAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RateLimiterAdmin_ServiceDesc, srv)
+}
+
+func _RateLimiterAdmin_ListKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListKeysReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateLimiterAdminServer).ListKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateLimiterAdmin_ListKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateLimiterAdminServer).ListKeys(ctx, req.(*ListKeysReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RateLimiterAdmin_GetKeyState_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetKeyStateReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateLimiterAdminServer).GetKeyState(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateLimiterAdmin_GetKeyState_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateLimiterAdminServer).GetKeyState(ctx, req.(*GetKeyStateReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RateLimiterAdmin_ResetKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetKeyReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateLimiterAdminServer).ResetKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateLimiterAdmin_ResetKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateLimiterAdminServer).ResetKey(ctx, req.(*ResetKeyReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RateLimiterAdmin_ListBans_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBansReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateLimiterAdminServer).ListBans(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateLimiterAdmin_ListBans_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateLimiterAdminServer).ListBans(ctx, req.(*ListBansReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RateLimiterAdmin_Ban_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BanReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateLimiterAdminServer).Ban(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateLimiterAdmin_Ban_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateLimiterAdminServer).Ban(ctx, req.(*BanReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RateLimiterAdmin_Unban_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnbanReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateLimiterAdminServer).Unban(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateLimiterAdmin_Unban_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateLimiterAdminServer).Unban(ctx, req.(*UnbanReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RateLimiterAdmin_UpdatePolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Policy)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RateLimiterAdminServer).UpdatePolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RateLimiterAdmin_UpdatePolicy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RateLimiterAdminServer).UpdatePolicy(ctx, req.(*Policy))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RateLimiterAdmin_ServiceDesc is the grpc.ServiceDesc for RateLimiterAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RateLimiterAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.RateLimiterAdmin",
+	HandlerType: (*RateLimiterAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListKeys",
+			Handler:    _RateLimiterAdmin_ListKeys_Handler,
+		},
+		{
+			MethodName: "GetKeyState",
+			Handler:    _RateLimiterAdmin_GetKeyState_Handler,
+		},
+		{
+			MethodName: "ResetKey",
+			Handler:    _RateLimiterAdmin_ResetKey_Handler,
+		},
+		{
+			MethodName: "ListBans",
+			Handler:    _RateLimiterAdmin_ListBans_Handler,
+		},
+		{
+			MethodName: "Ban",
+			Handler:    _RateLimiterAdmin_Ban_Handler,
+		},
+		{
+			MethodName: "Unban",
+			Handler:    _RateLimiterAdmin_Unban_Handler,
+		},
+		{
+			MethodName: "UpdatePolicy",
+			Handler:    _RateLimiterAdmin_UpdatePolicy_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin.proto",
+}
@@ -2,4 +2,8 @@ package protobuf
 
 const (
 	LOCATION_SEND_LOCATION_AND_SAVE = "/location.Location/SendLocationAndSave"
+
+	RATE_LIMITER_ADMIN_SERVICE = "/admin.RateLimiterAdmin/"
+
+	RATE_LIMIT_SERVICE = "/envoy.service.ratelimit.v3.RateLimitService/"
 )
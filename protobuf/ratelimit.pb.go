@@ -0,0 +1,746 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        v6.33.1
+// source: ratelimit.proto
+
+// wire compatible subset of envoy rate limit service v3
+// - envoy/service/ratelimit/v3/rls.proto
+// - envoy/extensions/common/ratelimit/v3/ratelimit.proto
+// - envoy/config/core/v3/base.proto (HeaderValue)
+//
+// field number, service and method name must stay the same as envoy,
+// message is flattened into one package since only the wire format matter
+
+package protobuf
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type RateLimitUnit int32
+
+const (
+	RateLimitUnit_UNKNOWN RateLimitUnit = 0
+	RateLimitUnit_SECOND  RateLimitUnit = 1
+	RateLimitUnit_MINUTE  RateLimitUnit = 2
+	RateLimitUnit_HOUR    RateLimitUnit = 3
+	RateLimitUnit_DAY     RateLimitUnit = 4
+	RateLimitUnit_MONTH   RateLimitUnit = 5
+	RateLimitUnit_YEAR    RateLimitUnit = 6
+)
+
+// Enum value maps for RateLimitUnit.
+var (
+	RateLimitUnit_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "SECOND",
+		2: "MINUTE",
+		3: "HOUR",
+		4: "DAY",
+		5: "MONTH",
+		6: "YEAR",
+	}
+	RateLimitUnit_value = map[string]int32{
+		"UNKNOWN": 0,
+		"SECOND":  1,
+		"MINUTE":  2,
+		"HOUR":    3,
+		"DAY":     4,
+		"MONTH":   5,
+		"YEAR":    6,
+	}
+)
+
+func (x RateLimitUnit) Enum() *RateLimitUnit {
+	p := new(RateLimitUnit)
+	*p = x
+	return p
+}
+
+func (x RateLimitUnit) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RateLimitUnit) Descriptor() protoreflect.EnumDescriptor {
+	return file_ratelimit_proto_enumTypes[0].Descriptor()
+}
+
+func (RateLimitUnit) Type() protoreflect.EnumType {
+	return &file_ratelimit_proto_enumTypes[0]
+}
+
+func (x RateLimitUnit) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RateLimitUnit.Descriptor instead.
+func (RateLimitUnit) EnumDescriptor() ([]byte, []int) {
+	return file_ratelimit_proto_rawDescGZIP(), []int{0}
+}
+
+type RateLimitResponse_Code int32
+
+const (
+	RateLimitResponse_UNKNOWN    RateLimitResponse_Code = 0
+	RateLimitResponse_OK         RateLimitResponse_Code = 1
+	RateLimitResponse_OVER_LIMIT RateLimitResponse_Code = 2
+)
+
+// Enum value maps for RateLimitResponse_Code.
+var (
+	RateLimitResponse_Code_name = map[int32]string{
+		0: "UNKNOWN",
+		1: "OK",
+		2: "OVER_LIMIT",
+	}
+	RateLimitResponse_Code_value = map[string]int32{
+		"UNKNOWN":    0,
+		"OK":         1,
+		"OVER_LIMIT": 2,
+	}
+)
+
+func (x RateLimitResponse_Code) Enum() *RateLimitResponse_Code {
+	p := new(RateLimitResponse_Code)
+	*p = x
+	return p
+}
+
+func (x RateLimitResponse_Code) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RateLimitResponse_Code) Descriptor() protoreflect.EnumDescriptor {
+	return file_ratelimit_proto_enumTypes[1].Descriptor()
+}
+
+func (RateLimitResponse_Code) Type() protoreflect.EnumType {
+	return &file_ratelimit_proto_enumTypes[1]
+}
+
+func (x RateLimitResponse_Code) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RateLimitResponse_Code.Descriptor instead.
+func (RateLimitResponse_Code) EnumDescriptor() ([]byte, []int) {
+	return file_ratelimit_proto_rawDescGZIP(), []int{3, 0}
+}
+
+type RateLimitDescriptor struct {
+	state         protoimpl.MessageState                 `protogen:"open.v1"`
+	Entries       []*RateLimitDescriptor_Entry           `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	Limit         *RateLimitDescriptor_RateLimitOverride `protobuf:"bytes,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	HitsAddend    *wrapperspb.UInt64Value                `protobuf:"bytes,3,opt,name=hits_addend,json=hitsAddend,proto3" json:"hits_addend,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateLimitDescriptor) Reset() {
+	*x = RateLimitDescriptor{}
+	mi := &file_ratelimit_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitDescriptor) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitDescriptor) ProtoMessage() {}
+
+func (x *RateLimitDescriptor) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimit_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitDescriptor.ProtoReflect.Descriptor instead.
+func (*RateLimitDescriptor) Descriptor() ([]byte, []int) {
+	return file_ratelimit_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *RateLimitDescriptor) GetEntries() []*RateLimitDescriptor_Entry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *RateLimitDescriptor) GetLimit() *RateLimitDescriptor_RateLimitOverride {
+	if x != nil {
+		return x.Limit
+	}
+	return nil
+}
+
+func (x *RateLimitDescriptor) GetHitsAddend() *wrapperspb.UInt64Value {
+	if x != nil {
+		return x.HitsAddend
+	}
+	return nil
+}
+
+type RateLimitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Domain        string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Descriptors   []*RateLimitDescriptor `protobuf:"bytes,2,rep,name=descriptors,proto3" json:"descriptors,omitempty"`
+	HitsAddend    uint32                 `protobuf:"varint,3,opt,name=hits_addend,json=hitsAddend,proto3" json:"hits_addend,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateLimitRequest) Reset() {
+	*x = RateLimitRequest{}
+	mi := &file_ratelimit_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitRequest) ProtoMessage() {}
+
+func (x *RateLimitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimit_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitRequest.ProtoReflect.Descriptor instead.
+func (*RateLimitRequest) Descriptor() ([]byte, []int) {
+	return file_ratelimit_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RateLimitRequest) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *RateLimitRequest) GetDescriptors() []*RateLimitDescriptor {
+	if x != nil {
+		return x.Descriptors
+	}
+	return nil
+}
+
+func (x *RateLimitRequest) GetHitsAddend() uint32 {
+	if x != nil {
+		return x.HitsAddend
+	}
+	return 0
+}
+
+type HeaderValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HeaderValue) Reset() {
+	*x = HeaderValue{}
+	mi := &file_ratelimit_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HeaderValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeaderValue) ProtoMessage() {}
+
+func (x *HeaderValue) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimit_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeaderValue.ProtoReflect.Descriptor instead.
+func (*HeaderValue) Descriptor() ([]byte, []int) {
+	return file_ratelimit_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *HeaderValue) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *HeaderValue) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type RateLimitResponse struct {
+	state                protoimpl.MessageState                `protogen:"open.v1"`
+	OverallCode          RateLimitResponse_Code                `protobuf:"varint,1,opt,name=overall_code,json=overallCode,proto3,enum=envoy.service.ratelimit.v3.RateLimitResponse_Code" json:"overall_code,omitempty"`
+	Statuses             []*RateLimitResponse_DescriptorStatus `protobuf:"bytes,2,rep,name=statuses,proto3" json:"statuses,omitempty"`
+	ResponseHeadersToAdd []*HeaderValue                        `protobuf:"bytes,3,rep,name=response_headers_to_add,json=responseHeadersToAdd,proto3" json:"response_headers_to_add,omitempty"`
+	RequestHeadersToAdd  []*HeaderValue                        `protobuf:"bytes,4,rep,name=request_headers_to_add,json=requestHeadersToAdd,proto3" json:"request_headers_to_add,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *RateLimitResponse) Reset() {
+	*x = RateLimitResponse{}
+	mi := &file_ratelimit_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitResponse) ProtoMessage() {}
+
+func (x *RateLimitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimit_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitResponse.ProtoReflect.Descriptor instead.
+func (*RateLimitResponse) Descriptor() ([]byte, []int) {
+	return file_ratelimit_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RateLimitResponse) GetOverallCode() RateLimitResponse_Code {
+	if x != nil {
+		return x.OverallCode
+	}
+	return RateLimitResponse_UNKNOWN
+}
+
+func (x *RateLimitResponse) GetStatuses() []*RateLimitResponse_DescriptorStatus {
+	if x != nil {
+		return x.Statuses
+	}
+	return nil
+}
+
+func (x *RateLimitResponse) GetResponseHeadersToAdd() []*HeaderValue {
+	if x != nil {
+		return x.ResponseHeadersToAdd
+	}
+	return nil
+}
+
+func (x *RateLimitResponse) GetRequestHeadersToAdd() []*HeaderValue {
+	if x != nil {
+		return x.RequestHeadersToAdd
+	}
+	return nil
+}
+
+type RateLimitDescriptor_Entry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         string                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateLimitDescriptor_Entry) Reset() {
+	*x = RateLimitDescriptor_Entry{}
+	mi := &file_ratelimit_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitDescriptor_Entry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitDescriptor_Entry) ProtoMessage() {}
+
+func (x *RateLimitDescriptor_Entry) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimit_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitDescriptor_Entry.ProtoReflect.Descriptor instead.
+func (*RateLimitDescriptor_Entry) Descriptor() ([]byte, []int) {
+	return file_ratelimit_proto_rawDescGZIP(), []int{0, 0}
+}
+
+func (x *RateLimitDescriptor_Entry) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *RateLimitDescriptor_Entry) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type RateLimitDescriptor_RateLimitOverride struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	RequestsPerUnit uint32                 `protobuf:"varint,1,opt,name=requests_per_unit,json=requestsPerUnit,proto3" json:"requests_per_unit,omitempty"`
+	Unit            RateLimitUnit          `protobuf:"varint,2,opt,name=unit,proto3,enum=envoy.service.ratelimit.v3.RateLimitUnit" json:"unit,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RateLimitDescriptor_RateLimitOverride) Reset() {
+	*x = RateLimitDescriptor_RateLimitOverride{}
+	mi := &file_ratelimit_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitDescriptor_RateLimitOverride) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitDescriptor_RateLimitOverride) ProtoMessage() {}
+
+func (x *RateLimitDescriptor_RateLimitOverride) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimit_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitDescriptor_RateLimitOverride.ProtoReflect.Descriptor instead.
+func (*RateLimitDescriptor_RateLimitOverride) Descriptor() ([]byte, []int) {
+	return file_ratelimit_proto_rawDescGZIP(), []int{0, 1}
+}
+
+func (x *RateLimitDescriptor_RateLimitOverride) GetRequestsPerUnit() uint32 {
+	if x != nil {
+		return x.RequestsPerUnit
+	}
+	return 0
+}
+
+func (x *RateLimitDescriptor_RateLimitOverride) GetUnit() RateLimitUnit {
+	if x != nil {
+		return x.Unit
+	}
+	return RateLimitUnit_UNKNOWN
+}
+
+type RateLimitResponse_RateLimit struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Name            string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	RequestsPerUnit uint32                 `protobuf:"varint,1,opt,name=requests_per_unit,json=requestsPerUnit,proto3" json:"requests_per_unit,omitempty"`
+	Unit            RateLimitUnit          `protobuf:"varint,2,opt,name=unit,proto3,enum=envoy.service.ratelimit.v3.RateLimitUnit" json:"unit,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RateLimitResponse_RateLimit) Reset() {
+	*x = RateLimitResponse_RateLimit{}
+	mi := &file_ratelimit_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitResponse_RateLimit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitResponse_RateLimit) ProtoMessage() {}
+
+func (x *RateLimitResponse_RateLimit) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimit_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitResponse_RateLimit.ProtoReflect.Descriptor instead.
+func (*RateLimitResponse_RateLimit) Descriptor() ([]byte, []int) {
+	return file_ratelimit_proto_rawDescGZIP(), []int{3, 0}
+}
+
+func (x *RateLimitResponse_RateLimit) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RateLimitResponse_RateLimit) GetRequestsPerUnit() uint32 {
+	if x != nil {
+		return x.RequestsPerUnit
+	}
+	return 0
+}
+
+func (x *RateLimitResponse_RateLimit) GetUnit() RateLimitUnit {
+	if x != nil {
+		return x.Unit
+	}
+	return RateLimitUnit_UNKNOWN
+}
+
+type RateLimitResponse_DescriptorStatus struct {
+	state              protoimpl.MessageState       `protogen:"open.v1"`
+	Code               RateLimitResponse_Code       `protobuf:"varint,1,opt,name=code,proto3,enum=envoy.service.ratelimit.v3.RateLimitResponse_Code" json:"code,omitempty"`
+	CurrentLimit       *RateLimitResponse_RateLimit `protobuf:"bytes,2,opt,name=current_limit,json=currentLimit,proto3" json:"current_limit,omitempty"`
+	LimitRemaining     uint32                       `protobuf:"varint,3,opt,name=limit_remaining,json=limitRemaining,proto3" json:"limit_remaining,omitempty"`
+	DurationUntilReset *durationpb.Duration         `protobuf:"bytes,4,opt,name=duration_until_reset,json=durationUntilReset,proto3" json:"duration_until_reset,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *RateLimitResponse_DescriptorStatus) Reset() {
+	*x = RateLimitResponse_DescriptorStatus{}
+	mi := &file_ratelimit_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitResponse_DescriptorStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitResponse_DescriptorStatus) ProtoMessage() {}
+
+func (x *RateLimitResponse_DescriptorStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_ratelimit_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitResponse_DescriptorStatus.ProtoReflect.Descriptor instead.
+func (*RateLimitResponse_DescriptorStatus) Descriptor() ([]byte, []int) {
+	return file_ratelimit_proto_rawDescGZIP(), []int{3, 1}
+}
+
+func (x *RateLimitResponse_DescriptorStatus) GetCode() RateLimitResponse_Code {
+	if x != nil {
+		return x.Code
+	}
+	return RateLimitResponse_UNKNOWN
+}
+
+func (x *RateLimitResponse_DescriptorStatus) GetCurrentLimit() *RateLimitResponse_RateLimit {
+	if x != nil {
+		return x.CurrentLimit
+	}
+	return nil
+}
+
+func (x *RateLimitResponse_DescriptorStatus) GetLimitRemaining() uint32 {
+	if x != nil {
+		return x.LimitRemaining
+	}
+	return 0
+}
+
+func (x *RateLimitResponse_DescriptorStatus) GetDurationUntilReset() *durationpb.Duration {
+	if x != nil {
+		return x.DurationUntilReset
+	}
+	return nil
+}
+
+var File_ratelimit_proto protoreflect.FileDescriptor
+
+const file_ratelimit_proto_rawDesc = "" +
+	"\n" +
+	"\x0fratelimit.proto\x12\x1aenvoy.service.ratelimit.v3\x1a\x1egoogle/protobuf/duration.proto\x1a\x1egoogle/protobuf/wrappers.proto\"\xaf\x03\n" +
+	"\x13RateLimitDescriptor\x12O\n" +
+	"\aentries\x18\x01 \x03(\v25.envoy.service.ratelimit.v3.RateLimitDescriptor.EntryR\aentries\x12W\n" +
+	"\x05limit\x18\x02 \x01(\v2A.envoy.service.ratelimit.v3.RateLimitDescriptor.RateLimitOverrideR\x05limit\x12=\n" +
+	"\vhits_addend\x18\x03 \x01(\v2\x1c.google.protobuf.UInt64ValueR\n" +
+	"hitsAddend\x1a/\n" +
+	"\x05Entry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\x1a~\n" +
+	"\x11RateLimitOverride\x12*\n" +
+	"\x11requests_per_unit\x18\x01 \x01(\rR\x0frequestsPerUnit\x12=\n" +
+	"\x04unit\x18\x02 \x01(\x0e2).envoy.service.ratelimit.v3.RateLimitUnitR\x04unit\"\x9e\x01\n" +
+	"\x10RateLimitRequest\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12Q\n" +
+	"\vdescriptors\x18\x02 \x03(\v2/.envoy.service.ratelimit.v3.RateLimitDescriptorR\vdescriptors\x12\x1f\n" +
+	"\vhits_addend\x18\x03 \x01(\rR\n" +
+	"hitsAddend\"5\n" +
+	"\vHeaderValue\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value\"\xef\x06\n" +
+	"\x11RateLimitResponse\x12U\n" +
+	"\foverall_code\x18\x01 \x01(\x0e22.envoy.service.ratelimit.v3.RateLimitResponse.CodeR\voverallCode\x12Z\n" +
+	"\bstatuses\x18\x02 \x03(\v2>.envoy.service.ratelimit.v3.RateLimitResponse.DescriptorStatusR\bstatuses\x12^\n" +
+	"\x17response_headers_to_add\x18\x03 \x03(\v2'.envoy.service.ratelimit.v3.HeaderValueR\x14responseHeadersToAdd\x12\\\n" +
+	"\x16request_headers_to_add\x18\x04 \x03(\v2'.envoy.service.ratelimit.v3.HeaderValueR\x13requestHeadersToAdd\x1a\x8a\x01\n" +
+	"\tRateLimit\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12*\n" +
+	"\x11requests_per_unit\x18\x01 \x01(\rR\x0frequestsPerUnit\x12=\n" +
+	"\x04unit\x18\x02 \x01(\x0e2).envoy.service.ratelimit.v3.RateLimitUnitR\x04unit\x1a\xae\x02\n" +
+	"\x10DescriptorStatus\x12F\n" +
+	"\x04code\x18\x01 \x01(\x0e22.envoy.service.ratelimit.v3.RateLimitResponse.CodeR\x04code\x12\\\n" +
+	"\rcurrent_limit\x18\x02 \x01(\v27.envoy.service.ratelimit.v3.RateLimitResponse.RateLimitR\fcurrentLimit\x12'\n" +
+	"\x0flimit_remaining\x18\x03 \x01(\rR\x0elimitRemaining\x12K\n" +
+	"\x14duration_until_reset\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\x12durationUntilReset\"+\n" +
+	"\x04Code\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\x06\n" +
+	"\x02OK\x10\x01\x12\x0e\n" +
+	"\n" +
+	"OVER_LIMIT\x10\x02*\\\n" +
+	"\rRateLimitUnit\x12\v\n" +
+	"\aUNKNOWN\x10\x00\x12\n" +
+	"\n" +
+	"\x06SECOND\x10\x01\x12\n" +
+	"\n" +
+	"\x06MINUTE\x10\x02\x12\b\n" +
+	"\x04HOUR\x10\x03\x12\a\n" +
+	"\x03DAY\x10\x04\x12\t\n" +
+	"\x05MONTH\x10\x05\x12\b\n" +
+	"\x04YEAR\x10\x062\x84\x01\n" +
+	"\x10RateLimitService\x12p\n" +
+	"\x0fShouldRateLimit\x12,.envoy.service.ratelimit.v3.RateLimitRequest\x1a-.envoy.service.ratelimit.v3.RateLimitResponse\"\x00B\x14Z\x12protobuf/;protobufb\x06proto3"
+
+var (
+	file_ratelimit_proto_rawDescOnce sync.Once
+	file_ratelimit_proto_rawDescData []byte
+)
+
+func file_ratelimit_proto_rawDescGZIP() []byte {
+	file_ratelimit_proto_rawDescOnce.Do(func() {
+		file_ratelimit_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_ratelimit_proto_rawDesc), len(file_ratelimit_proto_rawDesc)))
+	})
+	return file_ratelimit_proto_rawDescData
+}
+
+var file_ratelimit_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_ratelimit_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_ratelimit_proto_goTypes = []any{
+	(RateLimitUnit)(0),                            // 0: envoy.service.ratelimit.v3.RateLimitUnit
+	(RateLimitResponse_Code)(0),                   // 1: envoy.service.ratelimit.v3.RateLimitResponse.Code
+	(*RateLimitDescriptor)(nil),                   // 2: envoy.service.ratelimit.v3.RateLimitDescriptor
+	(*RateLimitRequest)(nil),                      // 3: envoy.service.ratelimit.v3.RateLimitRequest
+	(*HeaderValue)(nil),                           // 4: envoy.service.ratelimit.v3.HeaderValue
+	(*RateLimitResponse)(nil),                     // 5: envoy.service.ratelimit.v3.RateLimitResponse
+	(*RateLimitDescriptor_Entry)(nil),             // 6: envoy.service.ratelimit.v3.RateLimitDescriptor.Entry
+	(*RateLimitDescriptor_RateLimitOverride)(nil), // 7: envoy.service.ratelimit.v3.RateLimitDescriptor.RateLimitOverride
+	(*RateLimitResponse_RateLimit)(nil),           // 8: envoy.service.ratelimit.v3.RateLimitResponse.RateLimit
+	(*RateLimitResponse_DescriptorStatus)(nil),    // 9: envoy.service.ratelimit.v3.RateLimitResponse.DescriptorStatus
+	(*wrapperspb.UInt64Value)(nil),                // 10: google.protobuf.UInt64Value
+	(*durationpb.Duration)(nil),                   // 11: google.protobuf.Duration
+}
+var file_ratelimit_proto_depIdxs = []int32{
+	6,  // 0: envoy.service.ratelimit.v3.RateLimitDescriptor.entries:type_name -> envoy.service.ratelimit.v3.RateLimitDescriptor.Entry
+	7,  // 1: envoy.service.ratelimit.v3.RateLimitDescriptor.limit:type_name -> envoy.service.ratelimit.v3.RateLimitDescriptor.RateLimitOverride
+	10, // 2: envoy.service.ratelimit.v3.RateLimitDescriptor.hits_addend:type_name -> google.protobuf.UInt64Value
+	2,  // 3: envoy.service.ratelimit.v3.RateLimitRequest.descriptors:type_name -> envoy.service.ratelimit.v3.RateLimitDescriptor
+	1,  // 4: envoy.service.ratelimit.v3.RateLimitResponse.overall_code:type_name -> envoy.service.ratelimit.v3.RateLimitResponse.Code
+	9,  // 5: envoy.service.ratelimit.v3.RateLimitResponse.statuses:type_name -> envoy.service.ratelimit.v3.RateLimitResponse.DescriptorStatus
+	4,  // 6: envoy.service.ratelimit.v3.RateLimitResponse.response_headers_to_add:type_name -> envoy.service.ratelimit.v3.HeaderValue
+	4,  // 7: envoy.service.ratelimit.v3.RateLimitResponse.request_headers_to_add:type_name -> envoy.service.ratelimit.v3.HeaderValue
+	0,  // 8: envoy.service.ratelimit.v3.RateLimitDescriptor.RateLimitOverride.unit:type_name -> envoy.service.ratelimit.v3.RateLimitUnit
+	0,  // 9: envoy.service.ratelimit.v3.RateLimitResponse.RateLimit.unit:type_name -> envoy.service.ratelimit.v3.RateLimitUnit
+	1,  // 10: envoy.service.ratelimit.v3.RateLimitResponse.DescriptorStatus.code:type_name -> envoy.service.ratelimit.v3.RateLimitResponse.Code
+	8,  // 11: envoy.service.ratelimit.v3.RateLimitResponse.DescriptorStatus.current_limit:type_name -> envoy.service.ratelimit.v3.RateLimitResponse.RateLimit
+	11, // 12: envoy.service.ratelimit.v3.RateLimitResponse.DescriptorStatus.duration_until_reset:type_name -> google.protobuf.Duration
+	3,  // 13: envoy.service.ratelimit.v3.RateLimitService.ShouldRateLimit:input_type -> envoy.service.ratelimit.v3.RateLimitRequest
+	5,  // 14: envoy.service.ratelimit.v3.RateLimitService.ShouldRateLimit:output_type -> envoy.service.ratelimit.v3.RateLimitResponse
+	14, // [14:15] is the sub-list for method output_type
+	13, // [13:14] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_ratelimit_proto_init() }
+func file_ratelimit_proto_init() {
+	if File_ratelimit_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_ratelimit_proto_rawDesc), len(file_ratelimit_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_ratelimit_proto_goTypes,
+		DependencyIndexes: file_ratelimit_proto_depIdxs,
+		EnumInfos:         file_ratelimit_proto_enumTypes,
+		MessageInfos:      file_ratelimit_proto_msgTypes,
+	}.Build()
+	File_ratelimit_proto = out.File
+	file_ratelimit_proto_goTypes = nil
+	file_ratelimit_proto_depIdxs = nil
+}
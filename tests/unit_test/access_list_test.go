@@ -0,0 +1,266 @@
+package unit_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	cidr "github.com/prothegee/network-limiter-go/pkg/cidr"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnit_AccessList(t *testing.T) {
+	access, err := cidr.NewAccessList(
+		[]string{"10.0.0.0/8", "192.168.1.10", "2001:db8::/32"},
+		[]string{"10.66.0.0/16", "203.0.113.0/24"},
+		"", ""); if err != nil {
+			t.Fatalf("can't create access list: %v\n", err)
+		}
+
+	cases := []struct {
+		ip string
+		expected cidr.Decision
+	}{
+		{"10.1.2.3", cidr.DecisionAllow},
+		{"10.66.1.1", cidr.DecisionDeny}, // more specific deny inside allow
+		{"192.168.1.10", cidr.DecisionAllow},
+		{"192.168.1.11", cidr.DecisionNone},
+		{"203.0.113.7:4433", cidr.DecisionDeny},
+		{"203.0.113.7, 10.1.1.1", cidr.DecisionDeny},
+		{"[2001:db8::1]:80", cidr.DecisionAllow},
+		{"::ffff:10.1.2.3", cidr.DecisionAllow},
+		{"not-an-ip", cidr.DecisionNone},
+	}
+
+	for _, c := range cases {
+		if got := access.Check(c.ip); got != c.expected {
+			t.Errorf("ip %q: got decision %v, want %v\n", c.ip, got, c.expected)
+		}
+	}
+
+	t.Run("TEST: invalid cidr", func(t *testing.T) {
+		if _, err := cidr.NewAccessList([]string{"10.0.0.0/33"}, nil, "", ""); err == nil {
+			t.Fatalf("expected error for invalid cidr\n")
+		}
+	})
+
+	t.Run("TEST: reload from file", func(t *testing.T) {
+		fp := filepath.Join(t.TempDir(), "deny.txt")
+		os.WriteFile(fp, []byte("# bad range\n198.51.100.0/24\n"), 0o644)
+
+		list, err := cidr.NewAccessList(nil, nil, "", fp); if err != nil {
+			t.Fatalf("can't create access list: %v\n", err)
+		}
+		if list.Check("198.51.100.1") != cidr.DecisionDeny {
+			t.Fatalf("expected deny from file\n")
+		}
+
+		os.WriteFile(fp, []byte("198.51.101.0/24\n"), 0o644)
+		if err := list.Reload(); err != nil {
+			t.Fatalf("reload failed: %v\n", err)
+		}
+		if list.Check("198.51.100.1") != cidr.DecisionNone {
+			t.Fatalf("expected old entry to be gone after reload\n")
+		}
+
+		// broken file keep previous list
+		os.WriteFile(fp, []byte("nonsense\n"), 0o644)
+		if err := list.Reload(); err == nil {
+			t.Fatalf("expected reload error\n")
+		}
+		if list.Check("198.51.101.1") != cidr.DecisionDeny {
+			t.Fatalf("expected previous list to stay in place\n")
+		}
+	})
+
+	t.Run("TEST: watcher reload changed file on clock tick", func(t *testing.T) {
+		fp := filepath.Join(t.TempDir(), "deny.txt")
+		os.WriteFile(fp, []byte("198.51.100.0/24\n"), 0o644)
+
+		list, err := cidr.NewAccessList(nil, nil, "", fp); if err != nil {
+			t.Fatalf("can't create access list: %v\n", err)
+		}
+
+		clock := gen.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go cidr.WatchAccessList(ctx, list, time.Minute, gen.WithClock(clock))
+		waitTickers(t, clock, 1)
+
+		os.WriteFile(fp, []byte("198.51.101.0/24\n"), 0o644)
+		os.Chtimes(fp, time.Now(), time.Now().Add(time.Hour))
+		clock.Advance(time.Minute)
+
+		deadline := time.Now().Add(time.Second)
+		for list.Check("198.51.101.1") != cidr.DecisionDeny && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if list.Check("198.51.101.1") != cidr.DecisionDeny {
+			t.Errorf("expected new entry after tick\n")
+		}
+	})
+
+	t.Run("TEST: reload race trust proxies", func(t *testing.T) {
+		list, _ := cidr.NewAccessList(nil, []string{"203.0.113.0/24"}, "", "")
+
+		var wg sync.WaitGroup
+		for i := range 50 {
+			wg.Add(2)
+			go func() { defer wg.Done(); list.Reload() }()
+			go func() { defer wg.Done(); list.TrustProxies([]string{fmt.Sprintf("10.0.0.%d", i)}) }()
+		}
+		wg.Wait()
+
+		// last writer win and stay in place after another reload
+		list.TrustProxies([]string{"10.0.0.200"})
+		list.Reload()
+		if ip := list.PeerIP("203.0.113.9", "", "10.0.0.200:80"); ip != "203.0.113.9" {
+			t.Errorf("expected trusted proxy to be kept after reload, got %q\n", ip)
+		}
+	})
+
+	t.Run("TEST: canonical client ip", func(t *testing.T) {
+		cases := []struct {
+			realIp, forwardedFor, remoteAddr string
+			expected string
+		}{
+			{"", "", "10.0.0.1:53211", "10.0.0.1"},
+			{"", "", "[2001:DB8::1]:80", "2001:db8::1"},
+			{"", "10.0.0.2, 172.16.0.1", "127.0.0.1:8080", "10.0.0.2"},
+			{"::ffff:10.0.0.3", "10.0.0.2", "127.0.0.1:8080", "10.0.0.3"},
+			{"", "", "@unix", "@unix"},
+		}
+
+		for _, c := range cases {
+			if got := cidr.ClientIP(c.realIp, c.forwardedFor, c.remoteAddr); got != c.expected {
+				t.Errorf("%+v: got %q\n", c, got)
+			}
+		}
+	})
+}
+
+func TestIntegration_HttpAccessList(t *testing.T) {
+	access, _ := cidr.NewAccessList([]string{"10.0.0.0/8"}, []string{"203.0.113.0/24"}, "", "")
+	access.TrustProxies([]string{"192.0.2.0/24"}) // httptest remote address
+	rateLimiter := http_limiter.NewHttpRateLimiter(1, 30*time.Second)
+	middleware := &http_limiter.HttpMiddleware{Limiter: rateLimiter, Access: access}
+
+	handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	send := func(ip string) int {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Real-IP", ip)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Code
+	}
+
+	t.Run("TEST: deny list", func(t *testing.T) {
+		if code := send("203.0.113.9"); code != http.StatusForbidden {
+			t.Fatalf("expected %d, but got %d\n", http.StatusForbidden, code)
+		}
+	})
+
+	t.Run("TEST: allow list exempt from limit", func(t *testing.T) {
+		for i := 1; i <= 5; i++ {
+			if code := send("10.9.9.9"); code != http.StatusOK {
+				t.Fatalf("request #%d: expected %d, but got %d\n", i, http.StatusOK, code)
+			}
+		}
+		if count := len(rateLimiter.Requests["10.9.9.9"]); count != 0 {
+			t.Fatalf("allowed ip should not be counted, got %d\n", count)
+		}
+	})
+
+	t.Run("TEST: unlisted still limited", func(t *testing.T) {
+		if code := send("192.0.2.1"); code != http.StatusOK {
+			t.Fatalf("expected %d, but got %d\n", http.StatusOK, code)
+		}
+		if code := send("192.0.2.1"); code != http.StatusTooManyRequests {
+			t.Fatalf("expected %d, but got %d\n", http.StatusTooManyRequests, code)
+		}
+	})
+
+	t.Run("TEST: forwarded ip from untrusted peer is not believed", func(t *testing.T) {
+		untrusted, _ := cidr.NewAccessList([]string{"10.0.0.0/8"}, nil, "", "")
+		middleware := &http_limiter.HttpMiddleware{Limiter: http_limiter.NewHttpRateLimiter(1, 30*time.Second), Access: untrusted}
+		handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+
+		got := []int{}
+		for _, header := range []string{"X-Real-IP", "X-Forwarded-For"} {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(header, "10.9.9.9")
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			got = append(got, rec.Code)
+		}
+
+		if got[0] != http.StatusOK || got[1] != http.StatusTooManyRequests {
+			t.Errorf("expected spoofed allowed ip to stay limited, got %v\n", got)
+		}
+	})
+}
+
+func TestIntegration_GrpcAccessList(t *testing.T) {
+	access, _ := cidr.NewAccessList([]string{"10.0.0.0/8"}, []string{"203.0.113.0/24"}, "", "")
+	middleware := grpc_limiter.NewGrpcMiddleware(grpc_limiter.NewGrpcRateLimiter(1, 30*time.Second))
+	middleware.Access = access
+
+	interceptor := middleware.Limit()
+
+	handler := func(ctx context.Context, req any) (any, error) {
+		return "ok", nil
+	}
+
+	proxy := &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 50051}}
+
+	send := func(ip string) codes.Code {
+		ctx := metadata.NewIncomingContext(peer.NewContext(context.Background(), proxy),
+			metadata.Pairs("x-real-ip", ip))
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{
+			FullMethod: pb.LOCATION_SEND_LOCATION_AND_SAVE,
+		}, handler)
+		return status.Code(err)
+	}
+
+	// forwarded ip is not believed until proxy peer is trusted
+	if code := send("203.0.113.9"); code != codes.OK {
+		t.Errorf("untrusted peer: expected %v, but got %v\n", codes.OK, code)
+	}
+	access.TrustProxies([]string{"127.0.0.1"})
+
+	if code := send("203.0.113.9"); code != codes.PermissionDenied {
+		t.Errorf("deny: expected %v, but got %v\n", codes.PermissionDenied, code)
+	}
+	for i := 1; i <= 3; i++ {
+		if code := send("10.1.1.1"); code != codes.OK {
+			t.Errorf("allow #%d: expected %v, but got %v\n", i, codes.OK, code)
+		}
+	}
+	if code := send("192.0.2.1"); code != codes.OK {
+		t.Errorf("unlisted: expected %v, but got %v\n", codes.OK, code)
+	}
+	if code := send("192.0.2.1"); code != codes.ResourceExhausted {
+		t.Errorf("unlisted: expected %v, but got %v\n", codes.ResourceExhausted, code)
+	}
+}
@@ -0,0 +1,222 @@
+package unit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnit_AdaptiveLimiter(t *testing.T) {
+	t.Run("TEST: slow sample shrink limit down to min", func(t *testing.T) {
+		adaptive := gen.NewAdaptiveLimiter(2, 10, time.Nanosecond)
+		adaptive.Backoff = 0.5
+
+		for range 5 {
+			release, _, ok := adaptive.Acquire(gen.PriorityHigh); if !ok {
+				t.Fatalf("expected high priority to be admitted\n")
+			}
+			time.Sleep(time.Millisecond)
+			release()
+		}
+
+		if adaptive.Limit() != 2 {
+			t.Errorf("expected limit to stop at min 2, got %d\n", adaptive.Limit())
+		}
+		if adaptive.InFlight() != 0 {
+			t.Errorf("expected no request in flight, got %d\n", adaptive.InFlight())
+		}
+	})
+
+	t.Run("TEST: concurrent slow sample back off once", func(t *testing.T) {
+		adaptive := gen.NewAdaptiveLimiter(1, 10, time.Nanosecond)
+		adaptive.Backoff = 0.5
+
+		releases := []func(){}
+		for range 4 {
+			release, _, _ := adaptive.Acquire(gen.PriorityHigh)
+			releases = append(releases, release)
+		}
+		time.Sleep(time.Millisecond)
+		for _, release := range releases {
+			release()
+		}
+
+		if adaptive.Limit() != 5 {
+			t.Errorf("expected 1 backoff from 10 to 5 for 1 overloaded round trip, got %d\n", adaptive.Limit())
+		}
+
+		// request admitted after the cut is a new round trip
+		release, _, _ := adaptive.Acquire(gen.PriorityHigh)
+		time.Sleep(time.Millisecond)
+		release()
+		if adaptive.Limit() != 2 {
+			t.Errorf("expected next round trip to back off again, got %d\n", adaptive.Limit())
+		}
+	})
+
+	t.Run("TEST: fast sample grow limit up to max", func(t *testing.T) {
+		adaptive := gen.NewAdaptiveLimiter(1, 3, time.Nanosecond)
+		adaptive.Backoff = 0.5
+		for range 3 {
+			release, _, _ := adaptive.Acquire(gen.PriorityHigh)
+			time.Sleep(time.Millisecond)
+			release()
+		}
+		if adaptive.Limit() != 1 {
+			t.Fatalf("expected limit 1 after slow sample, got %d\n", adaptive.Limit())
+		}
+
+		adaptive.UpdatePolicy(1, 3, time.Hour)
+		for range 50 {
+			release, _, ok := adaptive.Acquire(gen.PriorityHigh); if !ok {
+				t.Fatalf("expected single request to be admitted\n")
+			}
+			release()
+			release() // second call is ignored
+		}
+
+		if adaptive.Limit() < 2 || adaptive.Limit() > 3 {
+			t.Errorf("expected limit to grow within max 3, got %d\n", adaptive.Limit())
+		}
+	})
+
+	t.Run("TEST: low priority is shed first", func(t *testing.T) {
+		adaptive := gen.NewAdaptiveLimiter(4, 4, time.Hour)
+
+		for range 2 {
+			if _, _, ok := adaptive.Acquire(gen.PriorityLow); !ok {
+				t.Fatalf("expected low priority within its share to be admitted\n")
+			}
+		}
+		if _, state, ok := adaptive.Acquire(gen.PriorityLow); ok || state.Limit != 2 {
+			t.Fatalf("expected low priority shed at share 2, got %v %d\n", ok, state.Limit)
+		}
+		if _, _, ok := adaptive.Acquire(gen.PriorityNormal); !ok {
+			t.Fatalf("expected normal priority to be admitted\n")
+		}
+		if _, _, ok := adaptive.Acquire(gen.PriorityHigh); !ok {
+			t.Fatalf("expected high priority to be admitted\n")
+		}
+		if _, _, ok := adaptive.Acquire(gen.PriorityHigh); ok {
+			t.Errorf("expected high priority shed once limit is full\n")
+		}
+	})
+
+	t.Run("TEST: parse priority", func(t *testing.T) {
+		if p, ok := gen.ParsePriority(" High "); !ok || p != gen.PriorityHigh {
+			t.Errorf("expected high, got %v %v\n", p, ok)
+		}
+		if p, ok := gen.ParsePriority("urgent"); ok || p != gen.PriorityNormal {
+			t.Errorf("expected unknown name to be normal, got %v %v\n", p, ok)
+		}
+	})
+}
+
+func TestIntegration_HttpAdaptive(t *testing.T) {
+	var got gen.Decision
+
+	middleware := &http_limiter.HttpMiddleware{
+		Limiter: http_limiter.NewHttpRateLimiter(3, time.Minute),
+		Adaptive: gen.NewAdaptiveLimiter(2, 2, time.Hour),
+		PriorityFunc: http_limiter.PriorityFromHeader("X-Priority"),
+		Observers: []gen.DecisionObserver{observerFunc(func(d gen.Decision) { got = d })},
+	}
+
+	hold := make(chan struct{})
+	entered := make(chan struct{})
+	handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-hold
+	})
+
+	do := func(ip, priority string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Real-IP", ip)
+		r.Header.Set("X-Priority", priority)
+
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		return w
+	}
+
+	// low may fill half of limit 2, i.e. one slot
+	done := make(chan struct{})
+	go func() { do("10.0.0.1", "low"); close(done) }()
+	<-entered
+
+	t.Run("TEST: low priority over its share get 503", func(t *testing.T) {
+		w := do("10.0.0.2", "low")
+		if w.Code != http.StatusServiceUnavailable || got.Result != gen.ResultShed {
+			t.Fatalf("expected %d shed, got %d %s\n", http.StatusServiceUnavailable, w.Code, got.Result)
+		}
+		if w.Header().Get("Retry-After") != "1" {
+			t.Errorf("expected Retry-After 1, got %q\n", w.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("TEST: high priority still served", func(t *testing.T) {
+		go do("10.0.0.3", "high")
+		<-entered
+		hold <- struct{}{}
+		hold <- struct{}{}
+		<-done
+	})
+
+	t.Run("TEST: per ip limit still apply before shedding", func(t *testing.T) {
+		close(hold)
+		go func() {
+			for range entered {
+			}
+		}()
+
+		for range 2 {
+			do("10.0.0.1", "high")
+		}
+		if w := do("10.0.0.1", "high"); w.Code != http.StatusTooManyRequests || got.Result != gen.ResultRejected {
+			t.Errorf("expected %d rejected, got %d %s\n", http.StatusTooManyRequests, w.Code, got.Result)
+		}
+	})
+}
+
+func TestIntegration_GrpcAdaptive(t *testing.T) {
+	middleware := grpc_limiter.NewGrpcMiddleware(grpc_limiter.NewGrpcRateLimiter(10, time.Minute))
+	middleware.Adaptive = gen.NewAdaptiveLimiter(2, 2, time.Hour)
+	middleware.PriorityFunc = grpc_limiter.PriorityFromMetadata("x-priority")
+	interceptor := middleware.Limit()
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Call"}
+	call := func(priority string, handler grpc.UnaryHandler) codes.Code {
+		ctx := metadata.NewIncomingContext(context.Background(),
+			metadata.Pairs("x-real-ip", "10.0.0.1", "x-priority", priority))
+		_, err := interceptor(ctx, nil, info, handler)
+
+		return status.Code(err)
+	}
+
+	code := call("low", func(ctx context.Context, req any) (any, error) {
+		if inner := call("low", nil); inner != codes.Unavailable {
+			t.Errorf("expected %v for second low call, got %v\n", codes.Unavailable, inner)
+		}
+
+		return nil, nil
+	})
+	if code != codes.OK {
+		t.Fatalf("expected first low call OK, got %v\n", code)
+	}
+
+	if middleware.Adaptive.InFlight() != 0 {
+		t.Errorf("expected slot to be released, got %d in flight\n", middleware.Adaptive.InFlight())
+	}
+}
@@ -0,0 +1,167 @@
+package unit_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestIntegration_AdminGrpc(t *testing.T) {
+	rateLimiter := grpc_limiter.NewGrpcRateLimiter(2, 30*time.Second)
+	box := ban.NewBanBox(3, time.Minute, time.Minute, 10, time.Hour)
+	server := grpc_limiter.NewGrpcAdminServer("secret", rateLimiter, box)
+
+	rateLimiter.CheckRequestLimit("10.0.0.1", pb.LOCATION_SEND_LOCATION_AND_SAVE)
+	rateLimiter.CheckRequestLimit("10.0.0.2", pb.LOCATION_SEND_LOCATION_AND_SAVE)
+	rateLimiter.CheckRequestLimit("192.168.1.1", pb.LOCATION_SEND_LOCATION_AND_SAVE)
+
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("authorization", "Bearer secret"))
+
+	t.Run("TEST: token required", func(t *testing.T) {
+		_, err := server.ListKeys(context.Background(), &pb.ListKeysReq{})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("expected %v, but got %v\n", codes.Unauthenticated, status.Code(err))
+		}
+
+		badCtx := metadata.NewIncomingContext(context.Background(),
+			metadata.Pairs("authorization", "Bearer nope"))
+		_, err = server.ListKeys(badCtx, &pb.ListKeysReq{})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("expected %v, but got %v\n", codes.Unauthenticated, status.Code(err))
+		}
+	})
+
+	t.Run("TEST: list & get key", func(t *testing.T) {
+		resp, err := server.ListKeys(ctx, &pb.ListKeysReq{Prefix: "10."}); if err != nil {
+			t.Fatalf("list keys failed: %v\n", err)
+		}
+		if len(resp.GetKeys()) != 2 || resp.GetKeys()[0].GetKey() != "10.0.0.1" {
+			t.Fatalf("unexpected key list: %v\n", resp.GetKeys())
+		}
+
+		state, err := server.GetKeyState(ctx, &pb.GetKeyStateReq{Key: "192.168.1.1"}); if err != nil {
+			t.Fatalf("get key state failed: %v\n", err)
+		}
+		if state.GetCount() != 1 || state.GetMethods()[pb.LOCATION_SEND_LOCATION_AND_SAVE] != 1 {
+			t.Fatalf("unexpected key state: %v\n", state)
+		}
+
+		_, err = server.GetKeyState(ctx, &pb.GetKeyStateReq{Key: "10.9.9.9"})
+		if status.Code(err) != codes.NotFound {
+			t.Fatalf("expected %v, but got %v\n", codes.NotFound, status.Code(err))
+		}
+	})
+
+	t.Run("TEST: reset key & prefix", func(t *testing.T) {
+		resp, err := server.ResetKey(ctx, &pb.ResetKeyReq{Key: "10.", Prefix: true}); if err != nil {
+			t.Fatalf("reset prefix failed: %v\n", err)
+		}
+		if resp.GetResetCount() != 2 {
+			t.Fatalf("expected 2 reset key, got %d\n", resp.GetResetCount())
+		}
+
+		if _, err := server.ResetKey(ctx, &pb.ResetKeyReq{Key: "192.168.1.1"}); err != nil {
+			t.Fatalf("reset key failed: %v\n", err)
+		}
+		if keys := rateLimiter.ListKeys(); len(keys) != 0 {
+			t.Fatalf("expected no tracked key, got %+v\n", keys)
+		}
+	})
+
+	t.Run("TEST: ban & unban", func(t *testing.T) {
+		entry, err := server.Ban(ctx, &pb.BanReq{Key: "10.6.6.6", Duration: durationpb.New(5 * time.Minute)}); if err != nil {
+			t.Fatalf("ban failed: %v\n", err)
+		}
+		if entry.GetOffence() != 1 {
+			t.Fatalf("expected first offence, got %d\n", entry.GetOffence())
+		}
+
+		bans, _ := server.ListBans(ctx, &pb.ListBansReq{})
+		if len(bans.GetBans()) != 1 {
+			t.Fatalf("unexpected ban list: %v\n", bans.GetBans())
+		}
+
+		resp, _ := server.Unban(ctx, &pb.UnbanReq{Key: "10.6.6.6"})
+		if !resp.GetOk() {
+			t.Fatalf("expected unban to succeed\n")
+		}
+		if _, banned := box.IsBanned("10.6.6.6"); banned {
+			t.Fatalf("expected ban to be lifted\n")
+		}
+	})
+
+	t.Run("TEST: update policy", func(t *testing.T) {
+		_, err := server.UpdatePolicy(ctx, &pb.Policy{MaxRequests: 0, Duration: durationpb.New(time.Second)})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected %v, but got %v\n", codes.InvalidArgument, status.Code(err))
+		}
+
+		policy, err := server.UpdatePolicy(ctx, &pb.Policy{MaxRequests: 5, Duration: durationpb.New(time.Minute)}); if err != nil {
+			t.Fatalf("update policy failed: %v\n", err)
+		}
+		if policy.GetMaxRequests() != 5 || policy.GetDuration().AsDuration() != time.Minute {
+			t.Fatalf("unexpected policy: %v\n", policy)
+		}
+
+		for i := 1; i <= 6; i++ {
+			ok := rateLimiter.CheckRequestLimit("10.7.7.7", pb.LOCATION_SEND_LOCATION_AND_SAVE)
+			if ok != (i <= 5) {
+				t.Errorf("request #%d: got %v with new policy\n", i, ok)
+			}
+		}
+	})
+}
+
+func TestIntegration_AdminGrpcExempt(t *testing.T) {
+	box := ban.NewBanBox(3, time.Minute, time.Minute, 10, time.Hour)
+	rateLimiter := grpc_limiter.NewGrpcRateLimiter(1, 30*time.Second)
+
+	middleware := grpc_limiter.NewGrpcMiddleware(rateLimiter)
+	middleware.Ban = box
+	middleware.Exempt = []string{pb.RATE_LIMITER_ADMIN_SERVICE}
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(middleware.Limit()))
+	pb.RegisterRateLimiterAdminServer(server, grpc_limiter.NewGrpcAdminServer("secret", rateLimiter, box))
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0"); if err != nil {
+		t.Fatalf("can't listen: %v\n", err)
+	}
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials())); if err != nil {
+			t.Fatalf("can't create grpc client: %v\n", err)
+		}
+	defer conn.Close()
+	client := pb.NewRateLimiterAdminClient(conn)
+
+	// operator is banned itself
+	box.Ban("127.0.0.1", time.Hour)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer secret")
+
+	for range 3 {
+		if _, err := client.ListBans(ctx, &pb.ListBansReq{}); err != nil {
+			t.Fatalf("expected banned operator to reach admin service, got %v\n", err)
+		}
+	}
+	if _, err := client.Unban(ctx, &pb.UnbanReq{Key: "127.0.0.1"}); err != nil {
+		t.Fatalf("expected banned operator to unban itself, got %v\n", err)
+	}
+	if _, banned := box.IsBanned("127.0.0.1"); banned {
+		t.Errorf("expected ban to be lifted\n")
+	}
+}
@@ -0,0 +1,123 @@
+package unit_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	admin "github.com/prothegee/network-limiter-go/pkg/admin"
+	ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+)
+
+func TestIntegration_AdminHttp(t *testing.T) {
+	rateLimiter := http_limiter.NewHttpRateLimiter(5, 30*time.Second)
+	box := ban.NewBanBox(3, time.Minute, time.Minute, 10, time.Hour)
+
+	rateLimiter.CheckRequestLimit("10.0.0.1")
+	rateLimiter.CheckRequestLimit("10.0.0.1")
+	rateLimiter.CheckRequestLimit("10.0.0.2")
+	rateLimiter.CheckRequestLimit("192.168.1.1")
+
+	ts := httptest.NewServer(admin.NewAdminServer("secret", rateLimiter, box).Handler())
+	defer ts.Close()
+
+	do := func(method, path, token, body string) *http.Response {
+		req, _ := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if len(token) > 0 {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req); if err != nil {
+			t.Fatalf("request %s %s failed: %v\n", method, path, err)
+		}
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("TEST: token required", func(t *testing.T) {
+		if resp := do("GET", "/admin/keys", "", ""); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected %d, but got %d\n", http.StatusUnauthorized, resp.StatusCode)
+		}
+		if resp := do("GET", "/admin/keys", "wrong", ""); resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected %d, but got %d\n", http.StatusUnauthorized, resp.StatusCode)
+		}
+	})
+
+	t.Run("TEST: list & get key", func(t *testing.T) {
+		var states []gen.KeyState
+		json.NewDecoder(do("GET", "/admin/keys?prefix=10.", "secret", "").Body).Decode(&states)
+		if len(states) != 2 || states[0].Key != "10.0.0.1" || states[0].Count != 2 {
+			t.Fatalf("unexpected key list: %+v\n", states)
+		}
+
+		var state gen.KeyState
+		json.NewDecoder(do("GET", "/admin/keys/10.0.0.2", "secret", "").Body).Decode(&state)
+		if state.Count != 1 || state.Limit != 5 {
+			t.Fatalf("unexpected key state: %+v\n", state)
+		}
+
+		if resp := do("GET", "/admin/keys/10.9.9.9", "secret", ""); resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected %d, but got %d\n", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("TEST: reset key & prefix", func(t *testing.T) {
+		if resp := do("DELETE", "/admin/keys/192.168.1.1", "secret", ""); resp.StatusCode != http.StatusOK {
+			t.Fatalf("expected %d, but got %d\n", http.StatusOK, resp.StatusCode)
+		}
+		if resp := do("DELETE", "/admin/keys", "secret", ""); resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("reset without prefix: expected %d, but got %d\n", http.StatusBadRequest, resp.StatusCode)
+		}
+
+		var reset struct{ Reset int `json:"reset"` }
+		json.NewDecoder(do("DELETE", "/admin/keys?prefix=10.0.", "secret", "").Body).Decode(&reset)
+		if reset.Reset != 2 {
+			t.Fatalf("expected 2 reset key, got %d\n", reset.Reset)
+		}
+		if keys := rateLimiter.ListKeys(); len(keys) != 0 {
+			t.Fatalf("expected no tracked key, got %+v\n", keys)
+		}
+	})
+
+	t.Run("TEST: manage bans", func(t *testing.T) {
+		if resp := do("POST", "/admin/bans", "secret", `{"key": "10.6.6.6", "duration": 300}`); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("expected %d, but got %d\n", http.StatusCreated, resp.StatusCode)
+		}
+		if _, banned := box.IsBanned("10.6.6.6"); !banned {
+			t.Fatalf("expected key to be banned\n")
+		}
+
+		var bans []map[string]any
+		json.NewDecoder(do("GET", "/admin/bans", "secret", "").Body).Decode(&bans)
+		if len(bans) != 1 || bans[0]["key"] != "10.6.6.6" {
+			t.Fatalf("unexpected ban list: %+v\n", bans)
+		}
+
+		if resp := do("DELETE", "/admin/bans/10.6.6.6", "secret", ""); resp.StatusCode != http.StatusNoContent {
+			t.Fatalf("expected %d, but got %d\n", http.StatusNoContent, resp.StatusCode)
+		}
+		if resp := do("DELETE", "/admin/bans/10.6.6.6", "secret", ""); resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("expected %d, but got %d\n", http.StatusNotFound, resp.StatusCode)
+		}
+	})
+
+	t.Run("TEST: grpc limiter state", func(t *testing.T) {
+		grpcLimiter := grpc_limiter.NewGrpcRateLimiter(5, 30*time.Second)
+		grpcLimiter.CheckRequestLimit("10.0.0.1", "/a.A/One")
+		grpcLimiter.CheckRequestLimit("10.0.0.1", "/a.A/Two")
+		grpcLimiter.CheckRequestLimit("10.0.0.1", "/a.A/Two")
+
+		var server admin.Limiter = grpcLimiter
+		state, ok := server.GetKeyState("10.0.0.1"); if !ok {
+			t.Fatalf("expected tracked key\n")
+		}
+		if state.Count != 3 || state.Methods["/a.A/Two"] != 2 {
+			t.Fatalf("unexpected key state: %+v\n", state)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package unit_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	cidr "github.com/prothegee/network-limiter-go/pkg/cidr"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	metrics "github.com/prothegee/network-limiter-go/pkg/metrics"
+)
+
+func TestIntegration_AuthzHandler(t *testing.T) {
+	var got gen.Decision
+
+	access, err := cidr.NewAccessList(nil, []string{"10.9.0.0/16"}, "", ""); if err != nil {
+		t.Fatalf("can't create access list: %v\n", err)
+	}
+	// proxy in front of authz is httptest remote address
+	access.TrustProxies([]string{"192.0.2.1"})
+
+	middleware := &http_limiter.HttpMiddleware{
+		Limiter: http_limiter.NewHttpRateLimiter(2, 30*time.Second),
+		Access: access,
+		Observers: []gen.DecisionObserver{observerFunc(func(d gen.Decision) { got = d })},
+	}
+	authz := http_limiter.NewAuthzHandler(middleware, "/authz")
+	if err := authz.SetRoutes([]string{"POST /api/users", "DELETE /api/users/{id}", "GET /api/orders"}); err != nil {
+		t.Fatalf("can't set routes: %v\n", err)
+	}
+
+	do := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range headers {
+			r.Header.Set(k, v)
+		}
+
+		w := httptest.NewRecorder()
+		authz.ServeHTTP(w, r)
+
+		return w
+	}
+
+	t.Run("TEST: nginx auth_request", func(t *testing.T) {
+		headers := map[string]string{
+			"X-Real-IP": "10.0.0.1",
+			"X-Original-Method": "post",
+			"X-Original-URI": "/api/users?page=2",
+		}
+
+		w := do("/authz", headers)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d\n", http.StatusOK, w.Code)
+		}
+		if w.Header().Get("X-RateLimit-Limit") != "2" || w.Header().Get("X-RateLimit-Remaining") != "1" {
+			t.Errorf("expected limit 2 remaining 1, got %v\n", w.Header())
+		}
+		if got.Route != "POST /api/users" {
+			t.Errorf("expected original request route pattern, got %q\n", got.Route)
+		}
+
+		do("/authz", headers)
+		w = do("/authz", headers)
+		if w.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected %d, got %d\n", http.StatusTooManyRequests, w.Code)
+		}
+		if len(w.Header().Get("Retry-After")) <= 0 || w.Header().Get("X-RateLimit-Remaining") != "0" {
+			t.Errorf("expected Retry-After & 0 remaining, got %v\n", w.Header())
+		}
+	})
+
+	t.Run("TEST: traefik forwardAuth", func(t *testing.T) {
+		do("/authz", map[string]string{
+			"X-Forwarded-For": "10.0.0.2",
+			"X-Forwarded-Method": "DELETE",
+			"X-Forwarded-Uri": "/api/users/1",
+		})
+
+		if got.Route != "DELETE /api/users/{id}" || got.Key != "10.0.0.2" {
+			t.Errorf("expected DELETE /api/users/{id} from 10.0.0.2, got %q from %q\n", got.Route, got.Key)
+		}
+	})
+
+	t.Run("TEST: envoy ext_authz path prefix", func(t *testing.T) {
+		w := do("/authz/api/orders", map[string]string{"X-Forwarded-For": "10.0.0.3"})
+
+		if w.Code != http.StatusOK || got.Route != "GET /api/orders" {
+			t.Errorf("expected 200 for GET /api/orders, got %d for %q\n", w.Code, got.Route)
+		}
+	})
+
+	t.Run("TEST: reject status for nginx", func(t *testing.T) {
+		authz.RejectStatus = http.StatusForbidden
+		defer func() { authz.RejectStatus = 0 }()
+
+		w := do("/authz", map[string]string{"X-Real-IP": "10.0.0.1"})
+		if w.Code != http.StatusForbidden {
+			t.Errorf("expected %d, got %d\n", http.StatusForbidden, w.Code)
+		}
+	})
+
+	t.Run("TEST: deny list", func(t *testing.T) {
+		w := do("/authz", map[string]string{"X-Real-IP": "10.9.1.1"})
+		if w.Code != http.StatusForbidden || got.Result != gen.ResultDenied {
+			t.Errorf("expected denied 403, got %d %q\n", w.Code, got.Result)
+		}
+	})
+
+	t.Run("TEST: route label stay bounded", func(t *testing.T) {
+		registry := metrics.NewRegistry()
+		limiterMetrics := metrics.NewLimiterMetrics(registry)
+
+		m := &http_limiter.HttpMiddleware{
+			Limiter: http_limiter.NewHttpRateLimiter(100, 30*time.Second),
+			Observers: []gen.DecisionObserver{limiterMetrics},
+		}
+		bounded := http_limiter.NewAuthzHandler(m, "/authz")
+		bounded.SetRoutes([]string{"GET /users/{id}"})
+
+		for i := range 10 {
+			for _, path := range []string{"/users/%d", "/orders/%d"} {
+				r := httptest.NewRequest(http.MethodGet, "/authz", nil)
+				r.Header.Set("X-Original-URI", fmt.Sprintf(path, i))
+				bounded.ServeHTTP(httptest.NewRecorder(), r)
+			}
+		}
+
+		if n := limiterMetrics.Decisions.Value("http", "default", "GET /users/{id}", "allowed"); n != 10 {
+			t.Errorf("expected 10 request on matched route, got %v\n", n)
+		}
+		if n := limiterMetrics.Decisions.Value("http", "default", http_limiter.AuthzRoute, "allowed"); n != 10 {
+			t.Errorf("expected 10 unmatched request on %q, got %v\n", http_limiter.AuthzRoute, n)
+		}
+
+		var body strings.Builder
+		registry.Write(&body)
+		if n := strings.Count(body.String(), "network_limiter_decisions_total{"); n != 2 {
+			t.Errorf("expected 2 route series, got %d\n", n)
+		}
+	})
+
+	t.Run("TEST: invalid route is rejected", func(t *testing.T) {
+		if err := authz.SetRoutes([]string{"GET /a", "GET /a"}); err == nil {
+			t.Errorf("expected conflicting route error\n")
+		}
+	})
+
+	t.Run("TEST: middleware itself doesn't send header", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Real-IP", "10.0.0.4")
+
+		middleware.Limit(func(w http.ResponseWriter, r *http.Request) {})(w, r)
+		if len(w.Header().Get("X-RateLimit-Limit")) > 0 {
+			t.Errorf("expected no rate limit header, got %v\n", w.Header())
+		}
+	})
+}
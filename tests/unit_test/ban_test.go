@@ -0,0 +1,115 @@
+package unit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+)
+
+func TestUnit_BanBox(t *testing.T) {
+	box := ban.NewBanBox(3, time.Minute, time.Minute, 10, time.Hour)
+
+	t.Run("TEST: escalating duration", func(t *testing.T) {
+		expected := []time.Duration{time.Minute, 10 * time.Minute, time.Hour, time.Hour}
+		for i, d := range expected {
+			if got := box.Duration(i + 1); got != d {
+				t.Errorf("offence #%d: got %v, want %v\n", i+1, got, d)
+			}
+		}
+	})
+
+	t.Run("TEST: ban after threshold", func(t *testing.T) {
+		for i := 1; i <= 2; i++ {
+			if _, banned := box.RecordRejection("10.0.0.1"); banned {
+				t.Fatalf("rejection #%d: should not be banned yet\n", i)
+			}
+		}
+
+		b, banned := box.RecordRejection("10.0.0.1"); if !banned {
+			t.Fatalf("expected ban after 3 rejections\n")
+		}
+		if b.Offence != 1 {
+			t.Errorf("expected first offence, got %d\n", b.Offence)
+		}
+		if _, ok := box.IsBanned("10.0.0.1"); !ok {
+			t.Errorf("expected key to be banned\n")
+		}
+		if _, ok := box.IsBanned("10.0.0.2"); ok {
+			t.Errorf("other key should not be banned\n")
+		}
+	})
+
+	t.Run("TEST: repeat offence escalate", func(t *testing.T) {
+		b := box.Ban("10.0.0.1", 0)
+		if b.Offence != 2 {
+			t.Fatalf("expected second offence, got %d\n", b.Offence)
+		}
+		if remaining := time.Until(b.Until); remaining <= 9*time.Minute {
+			t.Errorf("expected ~10m ban, got %v\n", remaining)
+		}
+	})
+
+	t.Run("TEST: list & lift", func(t *testing.T) {
+		box.Ban("10.0.0.3", time.Minute)
+
+		if bans := box.List(); len(bans) != 2 || bans[0].Key != "10.0.0.1" {
+			t.Fatalf("unexpected ban list: %+v\n", bans)
+		}
+		if !box.Lift("10.0.0.1") {
+			t.Fatalf("expected lift to succeed\n")
+		}
+		if _, ok := box.IsBanned("10.0.0.1"); ok {
+			t.Fatalf("expected ban to be lifted\n")
+		}
+		if b := box.Ban("10.0.0.1", 0); b.Offence != 1 {
+			t.Errorf("lift should forget offence history, got offence %d\n", b.Offence)
+		}
+	})
+}
+
+func TestIntegration_HttpBan(t *testing.T) {
+	rateLimiter := http_limiter.NewHttpRateLimiter(1, 30*time.Second)
+	middleware := &http_limiter.HttpMiddleware{
+		Limiter: rateLimiter,
+		Ban: ban.NewBanBox(2, time.Minute, time.Minute, 10, time.Hour),
+	}
+
+	handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Real-IP", "192.168.7.7")
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	for i, expected := range []int{http.StatusOK, http.StatusTooManyRequests, http.StatusTooManyRequests} {
+		if rec := send(); rec.Code != expected {
+			t.Fatalf("request #%d: got status %d, want %d\n", i+1, rec.Code, expected)
+		}
+	}
+
+	// banned now, even when limiter is reset
+	rateLimiter.ResetIP("192.168.7.7")
+
+	rec := send()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected banned request to get %d, got %d\n", http.StatusTooManyRequests, rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "60" {
+		t.Errorf("expected Retry-After 60, got %q\n", rec.Header().Get("Retry-After"))
+	}
+
+	middleware.Ban.Lift("192.168.7.7")
+
+	if rec := send(); rec.Code != http.StatusOK {
+		t.Fatalf("expected %d after lift, got %d\n", http.StatusOK, rec.Code)
+	}
+}
@@ -0,0 +1,189 @@
+package unit_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnit_BandwidthLimiter(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("TEST: per key bucket", func(t *testing.T) {
+		clock := gen.NewFakeClock(start)
+		limiter := gen.NewBandwidthLimiter(100, 0, gen.WithClock(clock))
+
+		if d := limiter.Reserve("10.0.0.1", 100); d != 0 {
+			t.Fatalf("expected full burst to be free, got %v\n", d)
+		}
+		if d := limiter.Reserve("10.0.0.1", 50); d != 500 * time.Millisecond {
+			t.Fatalf("expected 50 byte over budget to wait 500ms, got %v\n", d)
+		}
+		if d := limiter.Reserve("10.0.0.2", 100); d != 0 {
+			t.Fatalf("expected other key to have its own bucket, got %v\n", d)
+		}
+
+		clock.Advance(time.Second)
+		if d := limiter.Reserve("10.0.0.1", 50); d != 0 {
+			t.Errorf("expected refilled bucket, got %v\n", d)
+		}
+	})
+
+	t.Run("TEST: global bucket", func(t *testing.T) {
+		clock := gen.NewFakeClock(start)
+		limiter := gen.NewBandwidthLimiter(1000, 100, gen.WithClock(clock))
+
+		limiter.Reserve("10.0.0.1", 100)
+		if d := limiter.Reserve("10.0.0.2", 100); d != time.Second {
+			t.Errorf("expected global cap to delay other key 1s, got %v\n", d)
+		}
+	})
+
+	t.Run("TEST: wait is paced by clock", func(t *testing.T) {
+		clock := gen.NewFakeClock(start)
+		limiter := gen.NewBandwidthLimiter(100, 0, gen.WithClock(clock))
+		limiter.Reserve("10.0.0.1", 100)
+
+		done := make(chan error)
+		go func() { done <- limiter.WaitN(context.Background(), "10.0.0.1", 100) }()
+
+		waitTickers(t, clock, 1)
+		clock.Advance(time.Second)
+		if err := <-done; err != nil {
+			t.Fatalf("expected wait to end after 1s, got %v\n", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := limiter.WaitN(ctx, "10.0.0.1", 100); err == nil {
+			t.Errorf("expected cancelled ctx error\n")
+		}
+	})
+
+	t.Run("TEST: idle key is forgotten", func(t *testing.T) {
+		clock := gen.NewFakeClock(start)
+		limiter := gen.NewBandwidthLimiter(100, 0, gen.WithClock(clock))
+		limiter.Reserve("10.0.0.1", 100)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go gen.CleanupIdleBandwidth(ctx, limiter, time.Minute)
+
+		waitTickers(t, clock, 1)
+		clock.Advance(time.Minute)
+
+		deadline := time.Now().Add(time.Second)
+		for limiter.KeyCount() > 0 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if limiter.KeyCount() != 0 {
+			t.Errorf("expected no tracked key, got %d\n", limiter.KeyCount())
+		}
+	})
+}
+
+func TestIntegration_HttpBandwidth(t *testing.T) {
+	// 20000 byte burst, 2000 byte over it take 100ms
+	body := strings.Repeat("x", 22000)
+
+	t.Run("TEST: download is paced", func(t *testing.T) {
+		bandwidth := http_limiter.NewBandwidthMiddleware(nil, gen.NewBandwidthLimiter(20000, 0))
+		handler := bandwidth.Limit(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, body)
+		})
+
+		start := time.Now()
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		if w.Body.Len() != len(body) || time.Since(start) < 80 * time.Millisecond {
+			t.Errorf("expected whole body after ~100ms, got %d byte in %v\n", w.Body.Len(), time.Since(start))
+		}
+	})
+
+	t.Run("TEST: upload is paced", func(t *testing.T) {
+		var read int
+
+		bandwidth := http_limiter.NewBandwidthMiddleware(gen.NewBandwidthLimiter(20000, 0), nil)
+		handler := bandwidth.Limit(func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			read = len(b)
+		})
+
+		start := time.Now()
+		handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+
+		if read != len(body) || time.Since(start) < 80 * time.Millisecond {
+			t.Errorf("expected whole body after ~100ms, got %d byte in %v\n", read, time.Since(start))
+		}
+	})
+
+	t.Run("TEST: every connection of direct client share one budget", func(t *testing.T) {
+		download := gen.NewBandwidthLimiter(1 << 20, 0)
+		bandwidth := http_limiter.NewBandwidthMiddleware(nil, download)
+		server := httptest.NewServer(bandwidth.Limit(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "ok")
+		}))
+		defer server.Close()
+
+		for range 2 {
+			client := &http.Client{Transport: &http.Transport{}}
+			resp, err := client.Get(server.URL); if err != nil {
+				t.Fatalf("can't get: %v\n", err)
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			client.CloseIdleConnections()
+		}
+
+		if download.KeyCount() != 1 {
+			t.Errorf("expected 2 connection from 127.0.0.1 to use 1 key, got %d\n", download.KeyCount())
+		}
+	})
+}
+
+func TestIntegration_GrpcBandwidth(t *testing.T) {
+	called := false
+
+	bandwidth := grpc_limiter.NewGrpcBandwidth(gen.NewBandwidthLimiter(10, 0), nil)
+	interceptor := bandwidth.Limit()
+
+	send := func(ctx context.Context, req *pb.LocationReq) codes.Code {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-real-ip", "10.0.0.1"))
+		_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{
+			FullMethod: pb.LOCATION_SEND_LOCATION_AND_SAVE,
+		}, func(ctx context.Context, req any) (any, error) {
+			called = true
+			return nil, nil
+		})
+
+		return status.Code(err)
+	}
+
+	if code := send(context.Background(), &pb.LocationReq{Message: "hi"}); code != codes.OK || !called {
+		t.Fatalf("expected small message inside burst, got %v\n", code)
+	}
+
+	// 10 byte per second can't move this message before deadline, call is delayed not refused
+	called = false
+	ctx, cancel := context.WithTimeout(context.Background(), 20 * time.Millisecond)
+	defer cancel()
+
+	if code := send(ctx, &pb.LocationReq{Message: strings.Repeat("x", 64)}); code != codes.DeadlineExceeded || called {
+		t.Errorf("expected %v before handler, got %v called %v\n", codes.DeadlineExceeded, code, called)
+	}
+}
@@ -0,0 +1,243 @@
+package unit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+)
+
+func TestUnit_FakeClock(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("TEST: advance & set", func(t *testing.T) {
+		clock := gen.NewFakeClock(start)
+
+		clock.Advance(90 * time.Second)
+		if got := clock.Now(); !got.Equal(start.Add(90 * time.Second)) {
+			t.Fatalf("unexpected now after advance: %v\n", got)
+		}
+
+		clock.Set(start)
+		if got := clock.Now(); !got.Equal(start) {
+			t.Fatalf("unexpected now after set: %v\n", got)
+		}
+	})
+
+	t.Run("TEST: ticker fire on advance", func(t *testing.T) {
+		clock := gen.NewFakeClock(start)
+		ticker := clock.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		clock.Advance(30 * time.Second)
+		select {
+		case <-ticker.Chan():
+			t.Fatalf("ticker fired before interval\n")
+		default:
+		}
+
+		// several missed tick is delivered as one, like time.Ticker
+		clock.Advance(5 * time.Minute)
+		select {
+		case <-ticker.Chan():
+		default:
+			t.Fatalf("ticker didn't fire after interval\n")
+		}
+		select {
+		case <-ticker.Chan():
+			t.Fatalf("missed tick should be dropped\n")
+		default:
+		}
+
+		ticker.Stop()
+		clock.Advance(time.Hour)
+		select {
+		case <-ticker.Chan():
+			t.Fatalf("stopped ticker fired\n")
+		default:
+		}
+	})
+}
+
+func TestUnit_HttpLimiterClock(t *testing.T) {
+	clock := gen.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	rateLimiter := http_limiter.NewHttpRateLimiter(2, time.Minute, gen.WithClock(clock))
+
+	t.Run("TEST: window expiry", func(t *testing.T) {
+		for i := 1; i <= 2; i++ {
+			if !rateLimiter.CheckRequestLimit("10.0.0.1") {
+				t.Fatalf("request #%d should be allowed\n", i)
+			}
+		}
+		if rateLimiter.CheckRequestLimit("10.0.0.1") {
+			t.Fatalf("request #3 should be rejected\n")
+		}
+
+		clock.Advance(59 * time.Second)
+		if rateLimiter.CheckRequestLimit("10.0.0.1") {
+			t.Fatalf("request inside window should still be rejected\n")
+		}
+
+		clock.Advance(2 * time.Second)
+		state, ok := rateLimiter.CheckRequestLimitState("10.0.0.1"); if !ok {
+			t.Fatalf("request after window should be allowed\n")
+		}
+		if state.Count != 1 {
+			t.Errorf("expected count 1 after window, got %d\n", state.Count)
+		}
+		if !state.ResetAt.Equal(clock.Now().Add(time.Minute)) {
+			t.Errorf("unexpected reset at: %v\n", state.ResetAt)
+		}
+	})
+
+	t.Run("TEST: retry after from clock", func(t *testing.T) {
+		var got gen.Decision
+		middleware := &http_limiter.HttpMiddleware{
+			Limiter: http_limiter.NewHttpRateLimiter(1, time.Minute, gen.WithClock(clock)),
+			Observers: []gen.DecisionObserver{observerFunc(func(d gen.Decision) { got = d })},
+		}
+		handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {})
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Real-IP", "10.0.0.9")
+
+		handler(httptest.NewRecorder(), req)
+		clock.Advance(20 * time.Second)
+		handler(httptest.NewRecorder(), req)
+
+		if got.Result != gen.ResultRejected {
+			t.Fatalf("expected rejected, got %s\n", got.Result)
+		}
+		if got.RetryAfter != 40*time.Second {
+			t.Errorf("expected 40s retry after, got %v\n", got.RetryAfter)
+		}
+	})
+
+	t.Run("TEST: cleanup on tick", func(t *testing.T) {
+		cleaned := make(chan struct{}, 1)
+		rateLimiter.OnCleanup = func(time.Duration) { cleaned <- struct{}{} }
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go http_limiter.CleanupOldRequest(ctx, rateLimiter, 10*time.Second)
+		waitTicker(clock)
+
+		clock.Advance(10 * time.Second)
+		<-cleaned
+		if rateLimiter.KeyCount() != 1 {
+			t.Fatalf("key inside window should be kept\n")
+		}
+
+		clock.Advance(time.Minute)
+		<-cleaned
+		if rateLimiter.KeyCount() != 0 {
+			t.Fatalf("expired key should be removed, got %d key\n", rateLimiter.KeyCount())
+		}
+	})
+}
+
+func TestUnit_GrpcLimiterClock(t *testing.T) {
+	clock := gen.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	rateLimiter := grpc_limiter.NewGrpcRateLimiter(1, time.Minute, gen.WithClock(clock))
+
+	t.Run("TEST: window expiry per method", func(t *testing.T) {
+		if !rateLimiter.CheckRequestLimit("10.0.0.1", "/svc/A") {
+			t.Fatalf("first request should be allowed\n")
+		}
+		if rateLimiter.CheckRequestLimit("10.0.0.1", "/svc/A") {
+			t.Fatalf("second request should be rejected\n")
+		}
+		if !rateLimiter.CheckRequestLimit("10.0.0.1", "/svc/B") {
+			t.Fatalf("other method should be allowed\n")
+		}
+
+		clock.Advance(time.Minute + time.Second)
+		if rateLimiter.GetRequestCount("10.0.0.1", "/svc/A") != 0 {
+			t.Fatalf("expired request should not be counted\n")
+		}
+		if !rateLimiter.CheckRequestLimit("10.0.0.1", "/svc/A") {
+			t.Fatalf("request after window should be allowed\n")
+		}
+	})
+
+	t.Run("TEST: reset", func(t *testing.T) {
+		if rateLimiter.ResetPrefix("10.0.") != 1 {
+			t.Fatalf("expected one reset key\n")
+		}
+		if !rateLimiter.CheckRequestLimit("10.0.0.1", "/svc/A") {
+			t.Fatalf("request after reset should be allowed\n")
+		}
+	})
+
+	t.Run("TEST: cleanup on tick", func(t *testing.T) {
+		cleaned := make(chan struct{}, 1)
+		rateLimiter.OnCleanup = func(time.Duration) { cleaned <- struct{}{} }
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go grpc_limiter.CleanupOldRequest(ctx, rateLimiter, time.Minute)
+		waitTicker(clock)
+
+		clock.Advance(2 * time.Minute)
+		<-cleaned
+		if rateLimiter.KeyCount() != 0 {
+			t.Fatalf("expired key should be removed, got %d key\n", rateLimiter.KeyCount())
+		}
+	})
+}
+
+func TestUnit_BanBoxClock(t *testing.T) {
+	clock := gen.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	box := ban.NewBanBox(2, time.Minute, time.Minute, 10, time.Hour, gen.WithClock(clock))
+
+	t.Run("TEST: strike outside window is forgotten", func(t *testing.T) {
+		box.RecordRejection("10.0.0.1")
+		clock.Advance(2 * time.Minute)
+		if _, banned := box.RecordRejection("10.0.0.1"); banned {
+			t.Fatalf("old strike should not count\n")
+		}
+	})
+
+	t.Run("TEST: ban expiry", func(t *testing.T) {
+		b, banned := box.RecordRejection("10.0.0.1"); if !banned {
+			t.Fatalf("expected ban after 2 rejections\n")
+		}
+		if !b.Until.Equal(clock.Now().Add(time.Minute)) {
+			t.Errorf("unexpected ban until: %v\n", b.Until)
+		}
+
+		clock.Advance(59 * time.Second)
+		if _, ok := box.IsBanned("10.0.0.1"); !ok {
+			t.Fatalf("key should still be banned\n")
+		}
+
+		clock.Advance(time.Second)
+		if _, ok := box.IsBanned("10.0.0.1"); ok {
+			t.Fatalf("ban should be expired\n")
+		}
+		if box.Count() != 0 {
+			t.Errorf("expired ban should not be counted\n")
+		}
+	})
+}
+
+// cleanup routine create its ticker in its own goroutine
+func waitTicker(clock *gen.FakeClock) {
+	for clock.TickerCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+type observerFunc func(d gen.Decision)
+
+func (f observerFunc) ObserveDecision(_ context.Context, d gen.Decision) {
+	f(d)
+}
@@ -0,0 +1,357 @@
+package unit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnit_ConcurrencyLimiter(t *testing.T) {
+	t.Run("TEST: per key cap", func(t *testing.T) {
+		c := gen.NewConcurrencyLimiter(2, 0)
+
+		release, _, _ := c.Acquire("10.0.0.1")
+		_, state, ok := c.Acquire("10.0.0.1")
+		if !ok || state.Count != 2 || state.Limit != 2 {
+			t.Fatalf("expected 2nd slot with 2/2, got %v %+v\n", ok, state)
+		}
+
+		if _, _, ok := c.Acquire("10.0.0.1"); ok {
+			t.Fatalf("expected 3rd slot to be rejected\n")
+		}
+		if _, _, ok := c.Acquire("10.0.0.2"); !ok {
+			t.Fatalf("expected other key to be allowed\n")
+		}
+
+		// released once even when called twice
+		release()
+		release()
+		if c.Running("10.0.0.1") != 1 || c.Running("") != 2 {
+			t.Errorf("expected 1 running for key 2 in total, got %d %d\n", c.Running("10.0.0.1"), c.Running(""))
+		}
+		if _, _, ok := c.Acquire("10.0.0.1"); !ok {
+			t.Errorf("expected released slot to be reused\n")
+		}
+	})
+
+	t.Run("TEST: global cap", func(t *testing.T) {
+		c := gen.NewConcurrencyLimiter(5, 2)
+		c.Acquire("10.0.0.1")
+		c.Acquire("10.0.0.2")
+
+		_, state, ok := c.Acquire("10.0.0.3")
+		if ok || state.Count != 2 || state.Limit != 2 {
+			t.Errorf("expected rejection reported on global 2/2, got %v %+v\n", ok, state)
+		}
+
+		c.UpdatePolicy(5, 0)
+		if _, _, ok := c.Acquire("10.0.0.3"); !ok {
+			t.Errorf("expected lifted global cap to allow\n")
+		}
+	})
+}
+
+func TestIntegration_HttpConcurrency(t *testing.T) {
+	var got gen.Decision
+
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+
+	// rate limit is loose, only in-flight cap can reject
+	middleware := &http_limiter.HttpMiddleware{
+		Limiter: http_limiter.NewHttpRateLimiter(100, time.Minute),
+		Concurrency: gen.NewConcurrencyLimiter(1, 0),
+		Observers: []gen.DecisionObserver{observerFunc(func(d gen.Decision) { got = d })},
+	}
+	handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-unblock
+	})
+
+	do := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Real-IP", "10.0.0.1")
+
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		return w
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- do() }()
+	<-entered
+
+	t.Run("TEST: second in-flight request is rejected", func(t *testing.T) {
+		w := do()
+		if w.Code != http.StatusTooManyRequests || got.Result != gen.ResultInFlight {
+			t.Fatalf("expected 429 %s, got %d %s\n", gen.ResultInFlight, w.Code, got.Result)
+		}
+		if state, _ := middleware.Limiter.GetKeyState("10.0.0.1"); state.Count != 1 {
+			t.Errorf("expected rejected request not to use rate quota, got %d\n", state.Count)
+		}
+	})
+
+	t.Run("TEST: slot is released when handler return", func(t *testing.T) {
+		close(unblock)
+		if w := <-done; w.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d\n", http.StatusOK, w.Code)
+		}
+
+		go func() { <-entered }()
+		if w := do(); w.Code != http.StatusOK || middleware.Concurrency.Running("") != 0 {
+			t.Errorf("expected 200 with nothing running, got %d %d\n", w.Code, middleware.Concurrency.Running(""))
+		}
+	})
+
+	t.Run("TEST: concurrency only mode", func(t *testing.T) {
+		m := &http_limiter.HttpMiddleware{Concurrency: gen.NewConcurrencyLimiter(1, 0)}
+
+		w := httptest.NewRecorder()
+		m.Limit(func(w http.ResponseWriter, r *http.Request) {})(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		if w.Code != http.StatusOK {
+			t.Errorf("expected %d without rate limiter, got %d\n", http.StatusOK, w.Code)
+		}
+	})
+}
+
+func TestIntegration_HttpConcurrencyDirectClient(t *testing.T) {
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+
+	middleware := &http_limiter.HttpMiddleware{Concurrency: gen.NewConcurrencyLimiter(1, 0)}
+	server := httptest.NewServer(middleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+		// only first request is awaited, wrongly admitted one return at once
+		select {
+		case entered <- struct{}{}:
+			<-unblock
+		default:
+		}
+	}))
+	defer server.Close()
+
+	// no forwarding header, each client get its own connection & source port
+	get := func() int {
+		client := &http.Client{Transport: &http.Transport{}}
+		defer client.CloseIdleConnections()
+
+		resp, err := client.Get(server.URL); if err != nil {
+			t.Errorf("can't get: %v\n", err)
+			return 0
+		}
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	done := make(chan int)
+	go func() { done <- get() }()
+	<-entered
+
+	if code := get(); code != http.StatusTooManyRequests {
+		t.Errorf("expected %d on second connection of same ip, got %d\n", http.StatusTooManyRequests, code)
+	}
+	if middleware.Concurrency.Running("127.0.0.1") != 1 {
+		t.Errorf("expected slot keyed by host without port, got %d\n", middleware.Concurrency.Running("127.0.0.1"))
+	}
+
+	close(unblock)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("expected %d, got %d\n", http.StatusOK, code)
+	}
+}
+
+func TestIntegration_ConcurrencyQueue(t *testing.T) {
+	// wait until ip is queued, then check it doesn't hold its in-flight slot
+	expectQueuedWithoutSlot := func(t *testing.T, queue *gen.WaitQueue, c *gen.ConcurrencyLimiter) {
+		t.Helper()
+
+		for deadline := time.Now().Add(time.Second); queue.Len("10.0.0.1") <= 0; {
+			if time.Now().After(deadline) {
+				t.Fatalf("request never queued\n")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		if c.Running("10.0.0.1") != 0 {
+			t.Errorf("expected queued request not to hold in-flight slot, got %d\n", c.Running("10.0.0.1"))
+		}
+	}
+
+	t.Run("TEST: http queued request free its slot", func(t *testing.T) {
+		middleware := &http_limiter.HttpMiddleware{
+			Limiter: http_limiter.NewHttpRateLimiter(1, 100 * time.Millisecond),
+			Concurrency: gen.NewConcurrencyLimiter(1, 0),
+			Queue: gen.NewWaitQueue(1, time.Second),
+		}
+		handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {})
+
+		do := func() int {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("X-Real-IP", "10.0.0.1")
+
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			return w.Code
+		}
+
+		do()
+		done := make(chan int)
+		go func() { done <- do() }()
+
+		expectQueuedWithoutSlot(t, middleware.Queue, middleware.Concurrency)
+		if code := <-done; code != http.StatusOK || middleware.Concurrency.Running("") != 0 {
+			t.Errorf("expected queued request served & slot released, got %d %d\n", code, middleware.Concurrency.Running(""))
+		}
+	})
+
+	t.Run("TEST: http queued request rejected by in-flight cap keep no quota", func(t *testing.T) {
+		middleware := &http_limiter.HttpMiddleware{
+			Limiter: http_limiter.NewHttpRateLimiter(1, 200 * time.Millisecond),
+			Concurrency: gen.NewConcurrencyLimiter(1, 0),
+			Queue: gen.NewWaitQueue(1, time.Second),
+		}
+		handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {})
+
+		do := func() int {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("X-Real-IP", "10.0.0.1")
+
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			return w.Code
+		}
+
+		do()
+		done := make(chan int)
+		go func() { done <- do() }()
+
+		// slot is taken by another request while the queued one wait
+		expectQueuedWithoutSlot(t, middleware.Queue, middleware.Concurrency)
+		release, _, _ := middleware.Concurrency.Acquire("10.0.0.1")
+		defer release()
+
+		if code := <-done; code != http.StatusTooManyRequests {
+			t.Fatalf("expected %d, got %d\n", http.StatusTooManyRequests, code)
+		}
+		// first request left window while waiting, rejected one must not replace it
+		if state, _ := middleware.Limiter.GetKeyState("10.0.0.1"); state.Count != 0 {
+			t.Errorf("expected rejected request not to use rate quota, got %d\n", state.Count)
+		}
+	})
+
+	t.Run("TEST: grpc queued call free its slot", func(t *testing.T) {
+		middleware := grpc_limiter.NewGrpcMiddleware(grpc_limiter.NewGrpcRateLimiter(1, 100 * time.Millisecond))
+		middleware.Concurrency = gen.NewConcurrencyLimiter(1, 0)
+		middleware.Queue = gen.NewWaitQueue(1, time.Second)
+		interceptor := middleware.Limit()
+
+		send := func() codes.Code {
+			ctx := metadata.NewIncomingContext(context.Background(),
+				metadata.Pairs("x-real-ip", "10.0.0.1"))
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{
+				FullMethod: pb.LOCATION_SEND_LOCATION_AND_SAVE,
+			}, func(ctx context.Context, req any) (any, error) { return nil, nil })
+
+			return status.Code(err)
+		}
+
+		send()
+		done := make(chan codes.Code)
+		go func() { done <- send() }()
+
+		expectQueuedWithoutSlot(t, middleware.Queue, middleware.Concurrency)
+		if code := <-done; code != codes.OK || middleware.Concurrency.Running("") != 0 {
+			t.Errorf("expected queued call served & slot released, got %v %d\n", code, middleware.Concurrency.Running(""))
+		}
+	})
+
+	t.Run("TEST: grpc queued call rejected by in-flight cap keep no quota", func(t *testing.T) {
+		middleware := grpc_limiter.NewGrpcMiddleware(grpc_limiter.NewGrpcRateLimiter(1, 200 * time.Millisecond))
+		middleware.Concurrency = gen.NewConcurrencyLimiter(1, 0)
+		middleware.Queue = gen.NewWaitQueue(1, time.Second)
+		interceptor := middleware.Limit()
+
+		send := func() codes.Code {
+			ctx := metadata.NewIncomingContext(context.Background(),
+				metadata.Pairs("x-real-ip", "10.0.0.1"))
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{
+				FullMethod: pb.LOCATION_SEND_LOCATION_AND_SAVE,
+			}, func(ctx context.Context, req any) (any, error) { return nil, nil })
+
+			return status.Code(err)
+		}
+
+		send()
+		done := make(chan codes.Code)
+		go func() { done <- send() }()
+
+		expectQueuedWithoutSlot(t, middleware.Queue, middleware.Concurrency)
+		release, _, _ := middleware.Concurrency.Acquire("10.0.0.1")
+		defer release()
+
+		if code := <-done; code != codes.ResourceExhausted {
+			t.Fatalf("expected %v, got %v\n", codes.ResourceExhausted, code)
+		}
+		if n := middleware.Limiter.GetRequestCount("10.0.0.1", pb.LOCATION_SEND_LOCATION_AND_SAVE); n != 0 {
+			t.Errorf("expected rejected call not to use rate quota, got %d\n", n)
+		}
+	})
+}
+
+func TestIntegration_GrpcConcurrency(t *testing.T) {
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+
+	middleware := grpc_limiter.NewGrpcMiddleware(grpc_limiter.NewGrpcRateLimiter(100, time.Minute))
+	middleware.Concurrency = gen.NewConcurrencyLimiter(1, 0)
+	interceptor := middleware.Limit()
+
+	send := func(handler grpc.UnaryHandler) codes.Code {
+		ctx := metadata.NewIncomingContext(context.Background(),
+			metadata.Pairs("x-real-ip", "10.0.0.1"))
+		_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{
+			FullMethod: pb.LOCATION_SEND_LOCATION_AND_SAVE,
+		}, handler)
+
+		return status.Code(err)
+	}
+	slow := func(ctx context.Context, req any) (any, error) {
+		entered <- struct{}{}
+		<-unblock
+		return nil, nil
+	}
+	fast := func(ctx context.Context, req any) (any, error) { return nil, nil }
+
+	done := make(chan codes.Code)
+	go func() { done <- send(slow) }()
+	<-entered
+
+	if code := send(fast); code != codes.ResourceExhausted {
+		t.Fatalf("expected %v while slow call in flight, got %v\n", codes.ResourceExhausted, code)
+	}
+
+	close(unblock)
+	if code := <-done; code != codes.OK {
+		t.Fatalf("expected slow call %v, got %v\n", codes.OK, code)
+	}
+	if code := send(fast); code != codes.OK {
+		t.Errorf("expected %v after slow call return, got %v\n", codes.OK, code)
+	}
+	if n := middleware.Limiter.GetRequestCount("10.0.0.1", pb.LOCATION_SEND_LOCATION_AND_SAVE); n != 2 {
+		t.Errorf("expected 2 counted call, got %d\n", n)
+	}
+}
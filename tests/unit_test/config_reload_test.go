@@ -0,0 +1,139 @@
+package unit_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	config "github.com/prothegee/network-limiter-go/pkg/config"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+)
+
+func TestUnit_LimiterUpdatePolicy(t *testing.T) {
+	rateLimiter := http_limiter.NewHttpRateLimiter(2, time.Minute)
+
+	rateLimiter.CheckRequestLimit("10.0.0.1")
+	rateLimiter.CheckRequestLimit("10.0.0.1")
+	if rateLimiter.CheckRequestLimit("10.0.0.1") {
+		t.Fatalf("request #3 should be rejected with old policy\n")
+	}
+
+	rateLimiter.UpdatePolicy(3, time.Minute)
+
+	state, ok := rateLimiter.GetKeyState("10.0.0.1"); if !ok || state.Count != 2 {
+		t.Fatalf("tracked request should be kept after update, got %+v\n", state)
+	}
+	if !rateLimiter.CheckRequestLimit("10.0.0.1") {
+		t.Fatalf("request #3 should be allowed with new policy\n")
+	}
+	if maxReq, duration := rateLimiter.Policy(); maxReq != 3 || duration != time.Minute {
+		t.Errorf("unexpected policy: %d/%v\n", maxReq, duration)
+	}
+}
+
+func TestUnit_WatchConfig(t *testing.T) {
+	rateLimiter := http_limiter.NewHttpRateLimiter(2, time.Minute)
+	applied := make(chan error, 1)
+
+	// same apply func as server main
+	apply := func(content []byte) error {
+		cfg, err := config.ConfigServerHttpParse(content, config.FormatJson); if err == nil {
+			var maxReq uint
+			var duration time.Duration
+
+			maxReq, duration, err = cfg.Limiter.Policy(); if err == nil {
+				rateLimiter.UpdatePolicy(maxReq, duration)
+			}
+		}
+
+		applied <- err
+		return err
+	}
+
+	waitApplied := func(t *testing.T) error {
+		select {
+		case err := <-applied:
+			return err
+		case <-time.After(time.Second):
+			t.Fatalf("config wasn't reloaded\n")
+			return nil
+		}
+	}
+
+	writeConfig := func(t *testing.T, fp, content string) {
+		if err := os.WriteFile(fp, []byte(content), 0o644); err != nil {
+			t.Fatalf("can't write config: %v\n", err)
+		}
+	}
+
+	t.Run("TEST: reload on file change", func(t *testing.T) {
+		fp := filepath.Join(t.TempDir(), "config.http.json")
+		writeConfig(t, fp, httpConfig(t, 2, 60))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go config.WatchConfig(ctx, fp, 5*time.Millisecond, nil, apply)
+
+		// make sure watcher has seen the initial file before changing it
+		time.Sleep(20 * time.Millisecond)
+
+		writeConfig(t, fp, httpConfig(t, 10, 30))
+		if err := waitApplied(t); err != nil {
+			t.Fatalf("valid config rejected: %v\n", err)
+		}
+		if maxReq, duration := rateLimiter.Policy(); maxReq != 10 || duration != 30*time.Second {
+			t.Errorf("unexpected policy: %d/%v\n", maxReq, duration)
+		}
+
+		writeConfig(t, fp, httpConfig(t, 0, 30))
+		if err := waitApplied(t); err == nil {
+			t.Fatalf("expected zero max_request_per_ip to be rejected\n")
+		}
+
+		writeConfig(t, fp, `{"limiter": `)
+		if err := waitApplied(t); err == nil {
+			t.Fatalf("expected broken json to be rejected\n")
+		}
+
+		if maxReq, duration := rateLimiter.Policy(); maxReq != 10 || duration != 30*time.Second {
+			t.Errorf("old policy should be kept, got %d/%v\n", maxReq, duration)
+		}
+	})
+
+	t.Run("TEST: reload on signal", func(t *testing.T) {
+		fp := filepath.Join(t.TempDir(), "config.http.json")
+		writeConfig(t, fp, httpConfig(t, 7, 15))
+
+		hup := make(chan os.Signal, 1)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		// no polling, only signal reload
+		go config.WatchConfig(ctx, fp, 0, hup, apply)
+
+		hup <- syscall.SIGHUP
+		if err := waitApplied(t); err != nil {
+			t.Fatalf("valid config rejected: %v\n", err)
+		}
+		if maxReq, duration := rateLimiter.Policy(); maxReq != 7 || duration != 15*time.Second {
+			t.Errorf("unexpected policy: %d/%v\n", maxReq, duration)
+		}
+	})
+}
+
+// template config with adjusted limiter policy
+func httpConfig(t *testing.T, maxReq, interval int) string {
+	content, err := os.ReadFile("../../config.http.json.template"); if err != nil {
+		t.Fatalf("can't read config template: %v\n", err)
+	}
+
+	return strings.NewReplacer(
+		`"max_request_per_ip": 3`, fmt.Sprintf(`"max_request_per_ip": %d`, maxReq),
+		`"max_request_interval": 60`, fmt.Sprintf(`"max_request_interval": %d`, interval),
+	).Replace(string(content))
+}
@@ -0,0 +1,469 @@
+package unit_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	config "github.com/prothegee/network-limiter-go/pkg/config"
+)
+
+func TestUnit_ConfigValidation(t *testing.T) {
+	t.Run("TEST: template is valid", func(t *testing.T) {
+		if _, err := config.ConfigServerHttpLoad("../../config.http.json.template"); err != nil {
+			t.Errorf("http template: %v\n", err)
+		}
+		if _, err := config.ConfigServerGrpcLoad("../../config.grpc.json.template"); err != nil {
+			t.Errorf("grpc template: %v\n", err)
+		}
+	})
+
+	t.Run("TEST: enabled rls template is valid", func(t *testing.T) {
+		cfg, err := config.ConfigServerGrpcLoad("../../config.grpc.json.template"); if err != nil {
+			t.Fatalf("grpc template: %v\n", err)
+		}
+
+		cfg.Rls.Enabled = true
+		if err := cfg.Validate(); err != nil {
+			t.Fatalf("enabled rls: %v\n", err)
+		}
+		if len(cfg.Rls.Policies) != 2 {
+			t.Errorf("expected 2 rls policy, got %+v\n", cfg.Rls)
+		}
+	})
+
+	t.Run("TEST: gateway template is valid", func(t *testing.T) {
+		cfg, err := config.ConfigServerGatewayLoad("../../config.gateway.json.template"); if err != nil {
+			t.Fatalf("gateway template: %v\n", err)
+		}
+
+		maxReq, duration, err := cfg.Routes[0].Policy(cfg.Limiter)
+		if err != nil || maxReq != 3 || duration != time.Minute {
+			t.Errorf("expected route rate 3/min, got %d/%s (%v)\n", maxReq, duration, err)
+		}
+
+		maxReq, _, _ = cfg.Routes[1].Policy(cfg.Limiter)
+		if maxReq != 60 {
+			t.Errorf("expected route without rate to use limiter block, got %d\n", maxReq)
+		}
+	})
+
+	t.Run("TEST: unified template is valid and multiplexed", func(t *testing.T) {
+		cfg, err := config.ConfigServerUnifiedLoad("../../config.unified.json.template"); if err != nil {
+			t.Fatalf("unified template: %v\n", err)
+		}
+
+		if !cfg.Multiplexed() || !cfg.ShareLimiter {
+			t.Errorf("expected multiplexed shared limiter, got multiplexed %v share %v\n",
+				cfg.Multiplexed(), cfg.ShareLimiter)
+		}
+	})
+
+	t.Run("TEST: missing file return error", func(t *testing.T) {
+		_, err := config.ConfigServerHttpLoad(filepath.Join(t.TempDir(), "missing.json")); if !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected not exist error, got %v\n", err)
+		}
+	})
+
+	t.Run("TEST: every invalid field is reported", func(t *testing.T) {
+		content := strings.NewReplacer(
+			`"max_request_per_ip": 3`, `"max_request_per_ip": -1`,
+			`"max_request_interval": 60`, `"max_request_interval": 0`,
+			`"deny": []`, `"deny": ["10.0.0.0/8", "not-a-network"]`,
+			`"trusted_proxies": []`, `"trusted_proxies": ["proxy"]`,
+			`"factor": 10`, `"factor": 0.5`,
+			`"format": "text"`, `"format": "xml"`,
+		).Replace(readTemplate(t, "../../config.http.json.template"))
+
+		_, err := config.ConfigServerHttpParse([]byte(content), config.FormatJson)
+
+		var verr *config.ValidationError
+		if !errors.As(err, &verr) {
+			t.Fatalf("expected validation error, got %v\n", err)
+		}
+
+		expected := []string{
+			"limiter.max_request_per_ip",
+			"limiter.max_request_interval",
+			"access.deny[1]",
+			"access.trusted_proxies[0]",
+			"ban.factor",
+			"log.format",
+		}
+		if len(verr.Errors) != len(expected) {
+			t.Fatalf("expected %d field error, got %v\n", len(expected), verr)
+		}
+		for i, field := range expected {
+			if verr.Errors[i].Field != field {
+				t.Errorf("error #%d: got field %q, want %q\n", i, verr.Errors[i].Field, field)
+			}
+		}
+
+		var ferr *config.FieldError
+		if !errors.As(err, &ferr) || ferr.Field != "limiter.max_request_per_ip" {
+			t.Errorf("expected first field error to be reachable with errors.As, got %v\n", ferr)
+		}
+	})
+
+	t.Run("TEST: unknown key is rejected", func(t *testing.T) {
+		content := strings.Replace(readTemplate(t, "../../config.grpc.json.template"),
+			`"max_request_per_ip": 6`, `"max_request_per_ip": 6, "max_request_per_ipp": 6`, 1)
+
+		_, err := config.ConfigServerGrpcParse([]byte(content), config.FormatJson); if err == nil || !strings.Contains(err.Error(), "max_request_per_ipp") {
+			t.Fatalf("expected unknown field error, got %v\n", err)
+		}
+	})
+
+	t.Run("TEST: port range", func(t *testing.T) {
+		template := readTemplate(t, "../../config.http.json.template")
+
+		cfg, err := config.ConfigServerHttpParse([]byte(strings.Replace(template, `"port": 7676`, `"port": 65535`, 1)), config.FormatJson); if err != nil {
+			t.Fatalf("port 65535 should be accepted: %v\n", err)
+		}
+		if cfg.Listener.Port != 65535 {
+			t.Errorf("unexpected port %d\n", cfg.Listener.Port)
+		}
+
+		_, err = config.ConfigServerHttpParse([]byte(strings.Replace(template, `"port": 7676`, `"port": 70000`, 1)), config.FormatJson)
+
+		var verr *config.ValidationError
+		if !errors.As(err, &verr) || verr.Errors[0].Field != "listener.port" {
+			t.Fatalf("expected listener.port error, got %v\n", err)
+		}
+	})
+
+	t.Run("TEST: syntax error position", func(t *testing.T) {
+		_, err := config.ConfigServerHttpParse([]byte("{\n  \"listener\": {,\n}"), config.FormatJson); if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Fatalf("expected line number in error, got %v\n", err)
+		}
+	})
+}
+
+func TestUnit_ConfigFieldValidation(t *testing.T) {
+	const method = "/location.Location/SendLocationAndSave"
+
+	http := func(mutate func(cfg *config.ConfigServerHttp)) func() error {
+		return func() error {
+			cfg := config.DefaultConfigServerHttp()
+			mutate(&cfg)
+			return cfg.Validate()
+		}
+	}
+	grpc := func(mutate func(cfg *config.ConfigServerGrpc)) func() error {
+		return func() error {
+			cfg := config.DefaultConfigServerGrpc()
+			mutate(&cfg)
+			return cfg.Validate()
+		}
+	}
+	unified := func(mutate func(cfg *config.ConfigServerUnified)) func() error {
+		return func() error {
+			cfg := config.DefaultConfigServerUnified()
+			mutate(&cfg)
+			return cfg.Validate()
+		}
+	}
+	gateway := func(mutate func(cfg *config.ConfigServerGateway)) func() error {
+		return func() error {
+			cfg := config.DefaultConfigServerGateway()
+			cfg.Routes = []config.ConfigRoute{
+				{Name: "api", Prefix: "/api", Upstream: "http://127.0.0.1:7676"},
+				{Name: "default", Prefix: "/", Upstream: "http://127.0.0.1:7676"},
+			}
+			mutate(&cfg)
+			return cfg.Validate()
+		}
+	}
+
+	cases := []struct {
+		Name string
+		Validate func() error
+		Field string // empty for valid config
+	}{
+		{"http default", http(func(cfg *config.ConfigServerHttp) {}), ""},
+		{"grpc default", grpc(func(cfg *config.ConfigServerGrpc) {}), ""},
+		{"unified default", unified(func(cfg *config.ConfigServerUnified) {}), ""},
+		{"gateway with route", gateway(func(cfg *config.ConfigServerGateway) {}), ""},
+
+		{"in-flight per ip over global", grpc(func(cfg *config.ConfigServerGrpc) {
+			cfg.Limiter.MaxInFlight, cfg.Limiter.MaxInFlightPerIp = 10, 20
+		}), "limiter.max_in_flight_per_ip"},
+		{"negative queue wait", http(func(cfg *config.ConfigServerHttp) {
+			cfg.Limiter.QueueMaxWait = config.Duration(-time.Second)
+		}), "limiter.queue_max_wait"},
+		{"negative cost", grpc(func(cfg *config.ConfigServerGrpc) {
+			cfg.Limiter.Costs = map[string]int{method: -1}
+		}), `limiter.costs["` + method + `"]`},
+		{"cost over budget", grpc(func(cfg *config.ConfigServerGrpc) {
+			cfg.Limiter.Costs = map[string]int{method: 1000}
+		}), `limiter.costs["` + method + `"]`},
+		{"conn per ip over global", http(func(cfg *config.ConfigServerHttp) {
+			cfg.ConnLimit.MaxConn, cfg.ConnLimit.MaxConnPerIp = 10, 20
+		}), "conn_limit.max_conn_per_ip"},
+		{"negative bandwidth", http(func(cfg *config.ConfigServerHttp) {
+			cfg.Bandwidth.DownloadPerIp = -1
+		}), "bandwidth.download_per_ip"},
+		{"adaptive backoff out of range", http(func(cfg *config.ConfigServerHttp) {
+			cfg.Adaptive.Enabled, cfg.Adaptive.Backoff = true, 1.5
+		}), "adaptive.backoff"},
+		{"fair queue without in-flight", grpc(func(cfg *config.ConfigServerGrpc) {
+			cfg.FairQueue.Enabled, cfg.FairQueue.MaxInFlight = true, 0
+		}), "fair_queue.max_in_flight"},
+		{"fair queue unknown tenant class", grpc(func(cfg *config.ConfigServerGrpc) {
+			cfg.FairQueue.Enabled, cfg.FairQueue.Tenants = true, map[string]string{"acme": "premium"}
+		}), `fair_queue.tenants["acme"]`},
+		{"authz path with trailing slash", http(func(cfg *config.ConfigServerHttp) {
+			cfg.Authz.Enabled, cfg.Authz.Path = true, "/authz/"
+		}), "authz.path"},
+		{"authz non 4xx status", http(func(cfg *config.ConfigServerHttp) {
+			cfg.Authz.Enabled, cfg.Authz.RejectStatus = true, 500
+		}), "authz.reject_status"},
+		{"rls policy without rate", grpc(func(cfg *config.ConfigServerGrpc) {
+			cfg.Rls.Enabled = true
+			cfg.Rls.Policies = []config.ConfigRlsPolicy{
+				{Name: "per_ip_path", Rate: config.Rate{Count: 10, Per: time.Second}},
+				{Name: "per_ip"},
+			}
+		}), "rls.policies[1].rate"},
+		{"duplicate route name", gateway(func(cfg *config.ConfigServerGateway) {
+			cfg.Routes[1].Name = "api"
+		}), "routes[1].name"},
+		{"route upstream without scheme", gateway(func(cfg *config.ConfigServerGateway) {
+			cfg.Routes[1].Upstream = "127.0.0.1:7676"
+		}), "routes[1].upstream"},
+		{"grpc port clashing with http port", unified(func(cfg *config.ConfigServerUnified) {
+			cfg.GrpcListener.Port = cfg.Listener.Port
+		}), "grpc_listener.port"},
+		{"grpc admin service with shared limiter", unified(func(cfg *config.ConfigServerUnified) {
+			cfg.ShareLimiter, cfg.Admin.GrpcService, cfg.Admin.Token = true, true, "secret"
+		}), "admin.grpc_service"},
+	}
+
+	for _, c := range cases {
+		t.Run("TEST: "+c.Name, func(t *testing.T) {
+			err := c.Validate()
+			if len(c.Field) == 0 {
+				if err != nil {
+					t.Fatalf("expected valid config, got %v\n", err)
+				}
+				return
+			}
+
+			var verr *config.ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("expected validation error, got %v\n", err)
+			}
+			if len(verr.Errors) != 1 || verr.Errors[0].Field != c.Field {
+				t.Errorf("expected only %s error, got %v\n", c.Field, verr)
+			}
+		})
+	}
+}
+
+func readTemplate(t *testing.T, fp string) string {
+	content, err := os.ReadFile(fp); if err != nil {
+		t.Fatalf("can't read config template: %v\n", err)
+	}
+
+	return string(content)
+}
+
+func TestUnit_ConfigFormat(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml": `
+listener:
+  port: 8080
+limiter:
+  max_request_per_ip: 12
+access:
+  deny: ["10.66.0.0/16"]
+`,
+		"config.toml": `
+[listener]
+port = 8080
+
+[limiter]
+max_request_per_ip = 12
+
+[access]
+deny = ["10.66.0.0/16"]
+`,
+		"config.json": `{"listener": {"port": 8080}, "limiter": {"max_request_per_ip": 12}, "access": {"deny": ["10.66.0.0/16"]}}`,
+	}
+
+	for name, content := range files {
+		t.Run("TEST: load "+name, func(t *testing.T) {
+			fp := filepath.Join(dir, name)
+			if err := os.WriteFile(fp, []byte(content), 0o644); err != nil {
+				t.Fatalf("can't write config: %v\n", err)
+			}
+
+			cfg, err := config.ConfigServerHttpLoad(fp); if err != nil {
+				t.Fatalf("can't load: %v\n", err)
+			}
+
+			if cfg.Listener.Port != 8080 || cfg.Limiter.MaxRequestPerIp != 12 {
+				t.Errorf("file value not applied: %+v %+v\n", cfg.Listener, cfg.Limiter)
+			}
+			if len(cfg.Access.Deny) != 1 || cfg.Access.Deny[0] != "10.66.0.0/16" {
+				t.Errorf("unexpected deny list: %v\n", cfg.Access.Deny)
+			}
+
+			// missing key fallback to default
+			if cfg.Limiter.MaxRequestInterval != config.Duration(time.Minute) || cfg.Listener.Address != "0.0.0.0" {
+				t.Errorf("default not applied: %+v %+v\n", cfg.Listener, cfg.Limiter)
+			}
+		})
+	}
+
+	t.Run("TEST: unknown key in yaml", func(t *testing.T) {
+		_, err := config.ConfigServerGrpcParse([]byte("limiter:\n  max_request_per_ipp: 3\n"), config.FormatYaml); if err == nil || !strings.Contains(err.Error(), "max_request_per_ipp") {
+			t.Fatalf("expected unknown field error, got %v\n", err)
+		}
+	})
+
+	t.Run("TEST: unsupported extension", func(t *testing.T) {
+		if _, err := config.ConfigServerHttpLoad(filepath.Join(dir, "config.ini")); err == nil {
+			t.Fatalf("expected unsupported extension error\n")
+		}
+	})
+}
+
+func TestUnit_ConfigEnv(t *testing.T) {
+	content := []byte("limiter:\n  max_request_per_ip: 12\n")
+
+	t.Run("TEST: env override file", func(t *testing.T) {
+		t.Setenv("NLG_LIMITER_MAX_REQUEST_PER_IP", "20")
+		t.Setenv("NLG_LISTENER_PORT", "9090")
+		t.Setenv("NLG_ACCESS_DENY", "10.0.0.0/8, 192.168.0.0/16")
+		t.Setenv("NLG_ADMIN_GRPC_SERVICE", "true")
+		t.Setenv("NLG_ADMIN_TOKEN", "secret")
+		t.Setenv("NLG_TRACING_SAMPLE_RATIO", "0.25")
+
+		cfg, err := config.ConfigServerGrpcParse(content, config.FormatYaml); if err != nil {
+			t.Fatalf("can't parse: %v\n", err)
+		}
+
+		if cfg.Limiter.MaxRequestPerIp != 20 || cfg.Listener.Port != 9090 {
+			t.Errorf("env not applied: %+v %+v\n", cfg.Listener, cfg.Limiter)
+		}
+		if len(cfg.Access.Deny) != 2 || cfg.Access.Deny[1] != "192.168.0.0/16" {
+			t.Errorf("unexpected deny list: %v\n", cfg.Access.Deny)
+		}
+		if !cfg.Admin.GrpcService || cfg.Admin.Token != "secret" || cfg.Tracing.SampleRatio != 0.25 {
+			t.Errorf("env not applied: %+v %+v\n", cfg.Admin, cfg.Tracing)
+		}
+	})
+
+	t.Run("TEST: invalid env value", func(t *testing.T) {
+		t.Setenv("NLG_LISTENER_PORT", "70000")
+
+		_, err := config.ConfigServerHttpParse(content, config.FormatYaml)
+
+		var verr *config.ValidationError
+		if !errors.As(err, &verr) || verr.Errors[0].Field != "listener.port" || !strings.Contains(err.Error(), "NLG_LISTENER_PORT") {
+			t.Fatalf("expected listener.port env error, got %v\n", err)
+		}
+	})
+
+	t.Run("TEST: env value is validated", func(t *testing.T) {
+		t.Setenv("NLG_LIMITER_MAX_REQUEST_PER_IP", "-5")
+
+		_, err := config.ConfigServerHttpParse(content, config.FormatYaml); if err == nil || !strings.Contains(err.Error(), "limiter.max_request_per_ip") {
+			t.Fatalf("expected validation error, got %v\n", err)
+		}
+	})
+}
+
+func TestUnit_ConfigDurationRate(t *testing.T) {
+	t.Run("TEST: duration string & integer second", func(t *testing.T) {
+		cfg, err := config.ConfigServerHttpParse([]byte(`{"limiter": {"max_request_interval": "250ms", "cleanup_old_request_interval": 90, "reload_interval": "1h"}}`), config.FormatJson); if err != nil {
+			t.Fatalf("can't parse: %v\n", err)
+		}
+
+		if cfg.Limiter.MaxRequestInterval != config.Duration(250*time.Millisecond) {
+			t.Errorf("unexpected max_request_interval %v\n", cfg.Limiter.MaxRequestInterval)
+		}
+		if cfg.Limiter.CleanupOldRequestInterval != config.Duration(90*time.Second) {
+			t.Errorf("integer should be second, got %v\n", cfg.Limiter.CleanupOldRequestInterval)
+		}
+		if cfg.Limiter.ReloadInterval != config.Duration(time.Hour) {
+			t.Errorf("unexpected reload_interval %v\n", cfg.Limiter.ReloadInterval)
+		}
+	})
+
+	t.Run("TEST: invalid duration has field path", func(t *testing.T) {
+		_, err := config.ConfigServerGrpcParse([]byte("limiter:\n  max_request_interval: 1x\n"), config.FormatYaml)
+
+		var verr *config.ValidationError
+		if !errors.As(err, &verr) || verr.Errors[0].Field != "limiter.max_request_interval" {
+			t.Fatalf("expected limiter.max_request_interval error, got %v\n", err)
+		}
+	})
+
+	t.Run("TEST: rate", func(t *testing.T) {
+		cases := []struct {
+			Rate string
+			MaxReq uint
+			Window time.Duration
+		}{
+			{"100/min", 100, time.Minute},
+			{"10/500ms", 10, 500 * time.Millisecond},
+			{"5/s burst 20", 20, 4 * time.Second},
+			{"3/hour burst 3", 3, time.Hour},
+		}
+
+		for _, c := range cases {
+			cfg, err := config.ConfigServerHttpParse([]byte(`{"limiter": {"rate": "`+c.Rate+`"}}`), config.FormatJson); if err != nil {
+				t.Fatalf("%s: can't parse: %v\n", c.Rate, err)
+			}
+
+			maxReq, window, err := cfg.Limiter.Policy(); if err != nil {
+				t.Fatalf("%s: %v\n", c.Rate, err)
+			}
+			if maxReq != c.MaxReq || window != c.Window {
+				t.Errorf("%s: got %d/%v, want %d/%v\n", c.Rate, maxReq, window, c.MaxReq, c.Window)
+			}
+		}
+	})
+
+	t.Run("TEST: invalid rate", func(t *testing.T) {
+		for _, rate := range []string{"100", "0/s", "5/fortnight", "5/s burst 2", "5/s burst"} {
+			_, err := config.ConfigServerHttpParse([]byte(`{"limiter": {"rate": "`+rate+`"}}`), config.FormatJson)
+
+			var verr *config.ValidationError
+			if !errors.As(err, &verr) || verr.Errors[0].Field != "limiter.rate" {
+				t.Errorf("%s: expected limiter.rate error, got %v\n", rate, err)
+			}
+		}
+	})
+
+	t.Run("TEST: invalid route rate has index", func(t *testing.T) {
+		_, err := config.ConfigServerGatewayParse([]byte(`{"routes": [{"rate": "3/fortnight"}]}`), config.FormatJson)
+
+		var verr *config.ValidationError
+		if !errors.As(err, &verr) || verr.Errors[0].Field != "routes[0].rate" {
+			t.Fatalf("expected routes[0].rate error, got %v\n", err)
+		}
+	})
+
+	t.Run("TEST: env duration & rate", func(t *testing.T) {
+		t.Setenv("NLG_LIMITER_MAX_REQUEST_INTERVAL", "1500ms")
+		t.Setenv("NLG_LIMITER_RATE", "60/min burst 120")
+
+		cfg, err := config.ConfigServerHttpParse([]byte(`{}`), config.FormatJson); if err != nil {
+			t.Fatalf("can't parse: %v\n", err)
+		}
+
+		if cfg.Limiter.MaxRequestInterval != config.Duration(1500*time.Millisecond) {
+			t.Errorf("unexpected max_request_interval %v\n", cfg.Limiter.MaxRequestInterval)
+		}
+		if maxReq, window, _ := cfg.Limiter.Policy(); maxReq != 120 || window != 2*time.Minute {
+			t.Errorf("unexpected policy %d/%v\n", maxReq, window)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package unit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnit_LimiterCost(t *testing.T) {
+	t.Run("TEST: n unit is all or nothing", func(t *testing.T) {
+		limiter := http_limiter.NewHttpRateLimiter(5, time.Minute)
+
+		if state, ok := limiter.CheckRequestLimitStateN("10.0.0.1", 4); !ok || state.Count != 4 {
+			t.Fatalf("expected 4 unit allowed, got %v %d\n", ok, state.Count)
+		}
+		if state, ok := limiter.CheckRequestLimitStateN("10.0.0.1", 2); ok || state.Count != 4 {
+			t.Fatalf("expected 2 more unit rejected without counting, got %v %d\n", ok, state.Count)
+		}
+		if !limiter.CheckRequestLimit("10.0.0.1") {
+			t.Errorf("expected last unit to be allowed\n")
+		}
+	})
+
+	t.Run("TEST: cost over max request is rejected on empty window", func(t *testing.T) {
+		limiter := grpc_limiter.NewGrpcRateLimiter(3, time.Minute)
+
+		if _, ok := limiter.CheckRequestLimitStateN("10.0.0.1", "/bulk", 10); ok {
+			t.Fatalf("expected oversized call to be rejected\n")
+		}
+		if n := limiter.GetRequestCount("10.0.0.1", "/bulk"); n != 0 {
+			t.Errorf("expected nothing to be counted, got %d\n", n)
+		}
+		if limiter.AllowN("10.0.0.1", "/bulk", 10, limiter.Now()) {
+			t.Errorf("expected AllowN to agree\n")
+		}
+	})
+
+	t.Run("TEST: wait on cost over max request fail at once", func(t *testing.T) {
+		limiter := http_limiter.NewHttpRateLimiter(3, time.Minute)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		if _, err := limiter.WaitN(ctx, "10.0.0.1", 4); !errors.Is(err, gen.ErrOverLimit) {
+			t.Errorf("expected %v, got %v\n", gen.ErrOverLimit, err)
+		}
+	})
+
+	t.Run("TEST: cost table", func(t *testing.T) {
+		costs := gen.NewCostTable(map[string]int{"POST /upload": 5, "GET /": 0})
+
+		if costs.Cost("POST /upload") != 5 || costs.Cost("GET /") != 1 || costs.Cost("/other") != 1 {
+			t.Errorf("unexpected cost %d %d %d\n", costs.Cost("POST /upload"), costs.Cost("GET /"), costs.Cost("/other"))
+		}
+
+		costs.Update(nil)
+		if costs.Cost("POST /upload") != 1 {
+			t.Errorf("expected cost 1 after update, got %d\n", costs.Cost("POST /upload"))
+		}
+
+		var none *gen.CostTable
+		if none.Cost("POST /upload") != 1 {
+			t.Errorf("expected nil table to cost 1\n")
+		}
+	})
+}
+
+func TestIntegration_HttpCost(t *testing.T) {
+	var got gen.Decision
+
+	middleware := &http_limiter.HttpMiddleware{
+		Limiter: http_limiter.NewHttpRateLimiter(10, time.Minute),
+		Costs: gen.NewCostTable(map[string]int{"POST /upload": 4}),
+		Observers: []gen.DecisionObserver{observerFunc(func(d gen.Decision) { got = d })},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /upload", middleware.Limit(func(w http.ResponseWriter, r *http.Request) {}))
+	mux.HandleFunc("GET /", middleware.Limit(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(method, path string, body string) int {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("X-Real-IP", "10.0.0.1")
+
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	t.Run("TEST: route cost", func(t *testing.T) {
+		do(http.MethodPost, "/upload", "")
+		do(http.MethodPost, "/upload", "")
+		if got.Cost != 4 || got.Count != 8 || got.Remaining != 2 {
+			t.Fatalf("expected cost 4 with 8 used, got %+v\n", got)
+		}
+
+		if code := do(http.MethodPost, "/upload", ""); code != http.StatusTooManyRequests {
+			t.Fatalf("expected %d, got %d\n", http.StatusTooManyRequests, code)
+		}
+		if code := do(http.MethodGet, "/", ""); code != http.StatusOK || got.Cost != 1 {
+			t.Errorf("expected cheap route to still fit, got %d cost %d\n", code, got.Cost)
+		}
+	})
+
+	t.Run("TEST: cost func win over route cost", func(t *testing.T) {
+		middleware.Limiter.ResetIP("10.0.0.1")
+		middleware.CostFunc = http_limiter.ContentLengthCost(4)
+		defer func() { middleware.CostFunc = nil }()
+
+		do(http.MethodPost, "/upload", "0123456789")
+		if got.Cost != 3 {
+			t.Errorf("expected 10 byte per 4 byte unit to cost 3, got %d\n", got.Cost)
+		}
+
+		// empty body fall back to route cost
+		do(http.MethodPost, "/upload", "")
+		if got.Cost != 4 {
+			t.Errorf("expected empty body to use route cost 4, got %d\n", got.Cost)
+		}
+	})
+}
+
+func TestIntegration_GrpcCost(t *testing.T) {
+	var got gen.Decision
+
+	middleware := grpc_limiter.NewGrpcMiddleware(grpc_limiter.NewGrpcRateLimiter(10, time.Minute))
+	middleware.Costs = gen.NewCostTable(map[string]int{pb.LOCATION_SEND_LOCATION_AND_SAVE: 6})
+	middleware.Observers = []gen.DecisionObserver{observerFunc(func(d gen.Decision) { got = d })}
+	interceptor := middleware.Limit()
+
+	send := func(req any) codes.Code {
+		ctx := metadata.NewIncomingContext(context.Background(),
+			metadata.Pairs("x-real-ip", "10.0.0.1"))
+		_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{
+			FullMethod: pb.LOCATION_SEND_LOCATION_AND_SAVE,
+		}, func(ctx context.Context, req any) (any, error) { return nil, nil })
+
+		return status.Code(err)
+	}
+
+	if code := send(nil); code != codes.OK || got.Cost != 6 {
+		t.Fatalf("expected OK with cost 6, got %v %d\n", code, got.Cost)
+	}
+	if code := send(nil); code != codes.ResourceExhausted {
+		t.Fatalf("expected %v, got %v\n", codes.ResourceExhausted, code)
+	}
+
+	middleware.CostFunc = grpc_limiter.MessageSizeCost(8)
+	if code := send(&pb.LocationReq{Message: "hello"}); code != codes.OK || got.Cost != 1 {
+		t.Errorf("expected small message to cost 1, got %v %d\n", code, got.Cost)
+	}
+	if code := send(&pb.LocationReq{Message: strings.Repeat("x", 64)}); code != codes.ResourceExhausted || got.Cost < 8 {
+		t.Errorf("expected large message to be over budget, got %v %d\n", code, got.Cost)
+	}
+}
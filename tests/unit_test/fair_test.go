@@ -0,0 +1,287 @@
+package unit_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// @brief queue one waiter of tenant and wait until it is in place, admitted name is sent to order
+func enqueueFair(t *testing.T, fair *gen.FairQueue, tenant string, p gen.Priority, order chan<- string) <-chan error {
+	t.Helper()
+
+	before := fair.Len(tenant)
+	result := make(chan error, 1)
+
+	go func() {
+		release, _, err := fair.Acquire(context.Background(), tenant, p)
+		if err == nil {
+			order <- tenant
+			release()
+		}
+		result <- err
+	}()
+
+	for deadline := time.Now().Add(time.Second); fair.Len(tenant) <= before; {
+		if time.Now().After(deadline) {
+			t.Fatalf("waiter of %s never queued\n", tenant)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	return result
+}
+
+func TestUnit_FairQueue(t *testing.T) {
+	t.Run("TEST: high tenant is served before low one", func(t *testing.T) {
+		fair := gen.NewFairQueue(1, 10, 0)
+		hold, _, err := fair.Acquire(context.Background(), "busy", gen.PriorityNormal); if err != nil {
+			t.Fatalf("expected free slot, got %v\n", err)
+		}
+
+		order := make(chan string, 6)
+		for _, tenant := range []string{"free1", "free2", "free3"} {
+			enqueueFair(t, fair, tenant, gen.PriorityLow, order)
+		}
+		for _, tenant := range []string{"premium1", "premium2", "premium3"} {
+			enqueueFair(t, fair, tenant, gen.PriorityHigh, order)
+		}
+		hold()
+
+		got := []string{}
+		for range 6 {
+			got = append(got, <-order)
+		}
+
+		for i, tenant := range got {
+			if premium := strings.HasPrefix(tenant, "premium"); premium != (i < 3) {
+				t.Fatalf("expected premium tenant first, got %v\n", got)
+			}
+		}
+	})
+
+	t.Run("TEST: noisy tenant can't crowd out other", func(t *testing.T) {
+		fair := gen.NewFairQueue(1, 10, 0)
+		hold, _, _ := fair.Acquire(context.Background(), "busy", gen.PriorityNormal)
+
+		order := make(chan string, 5)
+		for range 4 {
+			enqueueFair(t, fair, "noisy", gen.PriorityNormal, order)
+		}
+		enqueueFair(t, fair, "quiet", gen.PriorityNormal, order)
+
+		if fair.Len("noisy") != 4 || fair.Len("quiet") != 1 {
+			t.Fatalf("expected 4 noisy & 1 quiet waiter, got %d %d\n", fair.Len("noisy"), fair.Len("quiet"))
+		}
+		hold()
+
+		got := []string{}
+		for range 5 {
+			got = append(got, <-order)
+		}
+
+		if got[1] != "quiet" {
+			t.Errorf("expected quiet tenant right after first noisy one, got %v\n", got)
+		}
+	})
+
+	t.Run("TEST: full queue reject lowest priority first", func(t *testing.T) {
+		fair := gen.NewFairQueue(1, 1, 0)
+		hold, _, _ := fair.Acquire(context.Background(), "busy", gen.PriorityNormal)
+
+		order := make(chan string, 2)
+		low := enqueueFair(t, fair, "free", gen.PriorityLow, order)
+		high := enqueueFair(t, fair, "premium", gen.PriorityHigh, order)
+
+		if err := <-low; !errors.Is(err, gen.ErrQueueFull) {
+			t.Fatalf("expected low waiter evicted with ErrQueueFull, got %v\n", err)
+		}
+		if _, _, err := fair.Acquire(context.Background(), "free", gen.PriorityLow); !errors.Is(err, gen.ErrQueueFull) {
+			t.Fatalf("expected low arrival rejected with ErrQueueFull, got %v\n", err)
+		}
+
+		hold()
+		if err := <-high; err != nil || <-order != "premium" {
+			t.Errorf("expected premium waiter to be served, got %v\n", err)
+		}
+	})
+
+	t.Run("TEST: waiter give up after max wait", func(t *testing.T) {
+		fair := gen.NewFairQueue(1, 10, 10 * time.Millisecond)
+		hold, _, _ := fair.Acquire(context.Background(), "busy", gen.PriorityNormal)
+		defer hold()
+
+		_, _, err := fair.Acquire(context.Background(), "free", gen.PriorityLow)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected %v, got %v\n", context.DeadlineExceeded, err)
+		}
+		if fair.Len("") != 0 {
+			t.Errorf("expected timed out waiter to leave queue, got %d\n", fair.Len(""))
+		}
+	})
+
+	t.Run("TEST: timed out waiter give its share back", func(t *testing.T) {
+		fair := gen.NewFairQueue(1, 10, 0)
+		hold, _, _ := fair.Acquire(context.Background(), "busy", gen.PriorityNormal)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10 * time.Millisecond)
+		defer cancel()
+		if _, _, err := fair.Acquire(ctx, "retry", gen.PriorityNormal); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected %v, got %v\n", context.DeadlineExceeded, err)
+		}
+
+		// same tag as a fresh tenant, so first come first served
+		order := make(chan string, 2)
+		enqueueFair(t, fair, "retry", gen.PriorityNormal, order)
+		enqueueFair(t, fair, "fresh", gen.PriorityNormal, order)
+		hold()
+
+		if first := <-order; first != "retry" {
+			t.Errorf("expected timed out tenant not to be pushed back, got %q first\n", first)
+		}
+		<-order
+	})
+
+	t.Run("TEST: evicted waiter give its share back", func(t *testing.T) {
+		fair := gen.NewFairQueue(1, 1, 0)
+		hold, _, _ := fair.Acquire(context.Background(), "busy", gen.PriorityNormal)
+
+		order := make(chan string, 3)
+		low := enqueueFair(t, fair, "free", gen.PriorityLow, order)
+		enqueueFair(t, fair, "premium", gen.PriorityHigh, order)
+		if err := <-low; !errors.Is(err, gen.ErrQueueFull) {
+			t.Fatalf("expected low waiter evicted, got %v\n", err)
+		}
+		if fair.TenantCount() != 2 {
+			t.Errorf("expected evicted tenant to drop its tag, got %d tenant\n", fair.TenantCount())
+		}
+
+		hold()
+		<-order
+	})
+
+	t.Run("TEST: tenant tag is forgotten once done", func(t *testing.T) {
+		fair := gen.NewFairQueue(2, 10, 0)
+		hold, _, _ := fair.Acquire(context.Background(), "busy", gen.PriorityNormal)
+		defer hold()
+
+		// server never idle, tenant still come & go
+		for i := range 100 {
+			release, _, err := fair.Acquire(context.Background(), fmt.Sprintf("tenant%d", i), gen.PriorityLow); if err != nil {
+				t.Fatalf("expected free slot, got %v\n", err)
+			}
+			release()
+		}
+
+		if fair.TenantCount() > 1 {
+			t.Errorf("expected only running tenant to be tracked, got %d\n", fair.TenantCount())
+		}
+	})
+
+	t.Run("TEST: raising max in flight admit waiter", func(t *testing.T) {
+		fair := gen.NewFairQueue(1, 10, 0)
+		hold, _, _ := fair.Acquire(context.Background(), "busy", gen.PriorityNormal)
+		defer hold()
+
+		order := make(chan string, 1)
+		result := enqueueFair(t, fair, "free", gen.PriorityLow, order)
+
+		fair.UpdatePolicy(2, 10, 0)
+		if err := <-result; err != nil {
+			t.Fatalf("expected waiter admitted, got %v\n", err)
+		}
+		if fair.InFlight() != 1 {
+			t.Errorf("expected only holder in flight, got %d\n", fair.InFlight())
+		}
+	})
+}
+
+func TestIntegration_HttpFairQueue(t *testing.T) {
+	var got gen.Decision
+
+	middleware := &http_limiter.HttpMiddleware{
+		Fair: gen.NewFairQueue(1, 0, 0),
+		TenantFunc: http_limiter.TenantFromHeader("X-Tenant"),
+		Observers: []gen.DecisionObserver{observerFunc(func(d gen.Decision) { got = d })},
+	}
+	middleware.PriorityFunc = http_limiter.PriorityByTenant(middleware.TenantFunc,
+		map[string]gen.Priority{"acme": gen.PriorityHigh}, nil)
+
+	hold := make(chan struct{})
+	entered := make(chan struct{})
+	handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+		if middleware.PriorityFunc(r) != gen.PriorityHigh {
+			t.Errorf("expected acme to be high priority\n")
+		}
+		close(entered)
+		<-hold
+	})
+
+	do := func(tenant string) int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Real-IP", "10.0.0.1")
+		r.Header.Set("X-Tenant", tenant)
+
+		w := httptest.NewRecorder()
+		handler(w, r)
+
+		return w.Code
+	}
+
+	done := make(chan int)
+	go func() { done <- do("acme") }()
+	<-entered
+
+	if code := do("free"); code != http.StatusServiceUnavailable || got.Result != gen.ResultShed {
+		t.Errorf("expected %d shed, got %d %s\n", http.StatusServiceUnavailable, code, got.Result)
+	}
+
+	close(hold)
+	if code := <-done; code != http.StatusOK {
+		t.Errorf("expected %d, got %d\n", http.StatusOK, code)
+	}
+}
+
+func TestIntegration_GrpcFairQueue(t *testing.T) {
+	middleware := grpc_limiter.NewGrpcMiddleware(nil)
+	middleware.Fair = gen.NewFairQueue(1, 0, 0)
+	middleware.TenantFunc = grpc_limiter.TenantFromMetadata("x-tenant")
+	interceptor := middleware.Limit()
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Call"}
+	call := func(tenant string, handler grpc.UnaryHandler) codes.Code {
+		ctx := metadata.NewIncomingContext(context.Background(),
+			metadata.Pairs("x-real-ip", "10.0.0.1", "x-tenant", tenant))
+		_, err := interceptor(ctx, nil, info, handler)
+
+		return status.Code(err)
+	}
+
+	code := call("acme", func(ctx context.Context, req any) (any, error) {
+		if inner := call("free", nil); inner != codes.Unavailable {
+			t.Errorf("expected %v while slot is busy, got %v\n", codes.Unavailable, inner)
+		}
+
+		return nil, nil
+	})
+	if code != codes.OK {
+		t.Fatalf("expected first call OK, got %v\n", code)
+	}
+	if middleware.Fair.InFlight() != 0 {
+		t.Errorf("expected slot to be released, got %d in flight\n", middleware.Fair.InFlight())
+	}
+}
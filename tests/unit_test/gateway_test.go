@@ -0,0 +1,180 @@
+package unit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	gateway "github.com/prothegee/network-limiter-go/pkg/gateway"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+)
+
+func gatewayRoute(t *testing.T, name, prefix, upstream string, maxReq uint) *gateway.Route {
+	u, err := url.Parse(upstream); if err != nil {
+		t.Fatalf("invalid upstream: %v\n", err)
+	}
+
+	return &gateway.Route{
+		Name: name,
+		Prefix: prefix,
+		Upstream: u,
+		Middleware: &http_limiter.HttpMiddleware{
+			Limiter: http_limiter.NewHttpRateLimiter(maxReq, 30*time.Second),
+		},
+	}
+}
+
+func TestIntegration_Gateway(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "upstream")
+		w.Header().Set("X-Upstream-Path", r.URL.Path)
+		w.Header().Set("X-Upstream-Gateway", r.Header.Get("X-Gateway"))
+		w.Header().Set("X-Upstream-Cookie", r.Header.Get("Cookie"))
+		w.Header().Set("X-Upstream-Forwarded-For", r.Header.Get("X-Forwarded-For"))
+	}))
+	defer upstream.Close()
+
+	api := gatewayRoute(t, "api", "/api", upstream.URL + "/base", 1)
+	api.StripPrefix = true
+	api.RequestHeaders = gateway.HeaderRewrite{
+		Set: map[string]string{"X-Gateway": "yes"},
+		Remove: []string{"Cookie"},
+	}
+	api.ResponseHeaders = gateway.HeaderRewrite{Remove: []string{"Server"}}
+
+	web := gatewayRoute(t, "web", "/web", upstream.URL, 5)
+
+	gw := gateway.NewGateway([]*gateway.Route{web, api}, nil)
+
+	do := func(path, ip string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("X-Real-IP", ip)
+		r.Header.Set("Cookie", "session=secret")
+
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, r)
+
+		return w
+	}
+
+	t.Run("TEST: prefix is stripped and joined with upstream path", func(t *testing.T) {
+		w := do("/api/users", "10.0.0.1")
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected %d, got %d\n", http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("X-Upstream-Path"); got != "/base/users" {
+			t.Errorf("expected upstream path /base/users, got %q\n", got)
+		}
+	})
+
+	t.Run("TEST: header is rewritten both way", func(t *testing.T) {
+		w := do("/api/users", "10.0.0.2")
+
+		if got := w.Header().Get("X-Upstream-Gateway"); got != "yes" {
+			t.Errorf("expected X-Gateway to be set, got %q\n", got)
+		}
+		if got := w.Header().Get("X-Upstream-Cookie"); got != "" {
+			t.Errorf("expected Cookie to be removed, got %q\n", got)
+		}
+		if got := w.Header().Get("Server"); got != "" {
+			t.Errorf("expected Server response header to be removed, got %q\n", got)
+		}
+		if got := w.Header().Get("X-Upstream-Forwarded-For"); len(got) <= 0 {
+			t.Errorf("expected X-Forwarded-For to be set\n")
+		}
+	})
+
+	t.Run("TEST: each route has its own limit", func(t *testing.T) {
+		if w := do("/api/users", "10.0.0.1"); w.Code != http.StatusTooManyRequests {
+			t.Errorf("expected api %d after 1 request, got %d\n", http.StatusTooManyRequests, w.Code)
+		}
+		if w := do("/web/index", "10.0.0.1"); w.Code != http.StatusOK {
+			t.Errorf("expected web %d, got %d\n", http.StatusOK, w.Code)
+		}
+		if got := api.Middleware.Policy; got != "api" {
+			t.Errorf("expected policy named after route, got %q\n", got)
+		}
+	})
+
+	t.Run("TEST: prefix match on path segment only", func(t *testing.T) {
+		if w := do("/apix", "10.0.0.3"); w.Code != http.StatusNotFound {
+			t.Errorf("expected %d, got %d\n", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestIntegration_GatewayHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" && !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer upstream.Close()
+
+	route := gatewayRoute(t, "api", "/", upstream.URL, 10)
+	route.Health = gateway.NewHealthCheck(upstream.URL + "/healthz", time.Second)
+
+	gw := gateway.NewGateway([]*gateway.Route{route}, nil)
+
+	do := func() int {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Real-IP", "10.0.0.1")
+
+		w := httptest.NewRecorder()
+		gw.ServeHTTP(w, r)
+
+		return w.Code
+	}
+
+	t.Run("TEST: healthy before first probe", func(t *testing.T) {
+		if code := do(); code != http.StatusOK {
+			t.Errorf("expected %d, got %d\n", http.StatusOK, code)
+		}
+	})
+
+	t.Run("TEST: unhealthy upstream is 503 and not counted", func(t *testing.T) {
+		healthy.Store(false)
+		if route.Health.Check(context.Background()) {
+			t.Fatalf("expected failed probe\n")
+		}
+
+		if code := do(); code != http.StatusServiceUnavailable {
+			t.Errorf("expected %d, got %d\n", http.StatusServiceUnavailable, code)
+		}
+
+		state, _ := route.Middleware.Limiter.GetKeyState("10.0.0.1")
+		if state.Count != 1 {
+			t.Errorf("expected count 1, got %d\n", state.Count)
+		}
+	})
+
+	t.Run("TEST: recovered upstream", func(t *testing.T) {
+		healthy.Store(true)
+		if !route.Health.Check(context.Background()) {
+			t.Fatalf("expected passed probe\n")
+		}
+
+		if code := do(); code != http.StatusOK {
+			t.Errorf("expected %d, got %d\n", http.StatusOK, code)
+		}
+	})
+
+	t.Run("TEST: unreachable upstream is 502", func(t *testing.T) {
+		down := gatewayRoute(t, "down", "/", "http://127.0.0.1:1", 10)
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		w := httptest.NewRecorder()
+
+		gateway.NewGateway([]*gateway.Route{down}, nil).ServeHTTP(w, r)
+		if w.Code != http.StatusBadGateway {
+			t.Errorf("expected %d, got %d\n", http.StatusBadGateway, w.Code)
+		}
+	})
+}
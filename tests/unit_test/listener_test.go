@@ -0,0 +1,178 @@
+package unit_test
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	listener "github.com/prothegee/network-limiter-go/pkg/listener"
+)
+
+// @brief accept in background, every accepted conn is sent to returned chan
+func acceptLoop(t *testing.T, l *listener.Listener) <-chan net.Conn {
+	t.Helper()
+
+	accepted := make(chan net.Conn, 16)
+	go func() {
+		for {
+			conn, err := l.Accept(); if err != nil {
+				close(accepted)
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	return accepted
+}
+
+// @brief whether server side closed the conn without sending anything
+func closedByServer(t *testing.T, conn net.Conn) bool {
+	t.Helper()
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err := conn.Read(make([]byte, 1))
+
+	return err == io.EOF
+}
+
+func TestIntegration_ListenerMaxConnPerIp(t *testing.T) {
+	// observer run on accept goroutine
+	rejected := make(chan gen.Decision, 4)
+
+	inner, err := net.Listen("tcp", "127.0.0.1:0"); if err != nil {
+		t.Fatalf("can't listen: %v\n", err)
+	}
+	l := listener.NewListener(inner, 0, 2, nil)
+	l.Observers = []gen.DecisionObserver{observerFunc(func(d gen.Decision) {
+		if d.Result == gen.ResultRejected {
+			rejected <- d
+		}
+	})}
+	defer l.Close()
+
+	accepted := acceptLoop(t, l)
+
+	var clients []net.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", inner.Addr().String()); if err != nil {
+			t.Fatalf("can't dial: %v\n", err)
+		}
+		defer conn.Close()
+		clients = append(clients, conn)
+	}
+
+	t.Run("TEST: excess connection is closed", func(t *testing.T) {
+		first, second := <-accepted, <-accepted
+
+		if !closedByServer(t, clients[2]) {
+			t.Fatalf("expected 3rd connection to be closed\n")
+		}
+		var decision gen.Decision
+		select {
+		case decision = <-rejected:
+		case <-time.After(time.Second):
+			t.Fatalf("expected 3rd connection to be reported as rejected\n")
+		}
+		if l.ActiveIp("127.0.0.1") != 2 || len(rejected) != 0 {
+			t.Fatalf("expected 2 active 1 rejected, got %d %d\n", l.ActiveIp("127.0.0.1"), len(rejected) + 1)
+		}
+		if decision.Protocol != "tcp" || decision.Policy != listener.PolicyMaxConn || decision.Key != "127.0.0.1" {
+			t.Errorf("unexpected decision %+v\n", decision)
+		}
+
+		// slot is released once even when closed twice
+		first.Close()
+		first.Close()
+		if l.Active() != 1 {
+			t.Errorf("expected 1 active, got %d\n", l.Active())
+		}
+		second.Close()
+	})
+
+	t.Run("TEST: released slot admit new connection", func(t *testing.T) {
+		conn, err := net.Dial("tcp", inner.Addr().String()); if err != nil {
+			t.Fatalf("can't dial: %v\n", err)
+		}
+		defer conn.Close()
+
+		select {
+		case c := <-accepted:
+			c.Close()
+		case <-time.After(time.Second):
+			t.Fatalf("expected new connection to be accepted\n")
+		}
+	})
+}
+
+func TestIntegration_ListenerRateAndGlobalCap(t *testing.T) {
+	t.Run("TEST: connection rate per ip", func(t *testing.T) {
+		inner, err := net.Listen("tcp", "127.0.0.1:0"); if err != nil {
+			t.Fatalf("can't listen: %v\n", err)
+		}
+		l := listener.NewListener(inner, 0, 0, http_limiter.NewHttpRateLimiter(1, 30*time.Second))
+		defer l.Close()
+
+		accepted := acceptLoop(t, l)
+
+		first, err := net.Dial("tcp", inner.Addr().String()); if err != nil {
+			t.Fatalf("can't dial: %v\n", err)
+		}
+		defer first.Close()
+		(<-accepted).Close()
+
+		second, err := net.Dial("tcp", inner.Addr().String()); if err != nil {
+			t.Fatalf("can't dial: %v\n", err)
+		}
+		defer second.Close()
+
+		if !closedByServer(t, second) {
+			t.Errorf("expected 2nd connection within window to be closed\n")
+		}
+	})
+
+	t.Run("TEST: global cap delay accept", func(t *testing.T) {
+		inner, err := net.Listen("tcp", "127.0.0.1:0"); if err != nil {
+			t.Fatalf("can't listen: %v\n", err)
+		}
+		l := listener.NewListener(inner, 1, 0, nil)
+
+		accepted := acceptLoop(t, l)
+
+		for i := 0; i < 2; i++ {
+			conn, err := net.Dial("tcp", inner.Addr().String()); if err != nil {
+				t.Fatalf("can't dial: %v\n", err)
+			}
+			defer conn.Close()
+		}
+
+		first := <-accepted
+		select {
+		case <-accepted:
+			t.Fatalf("expected 2nd connection to wait for a free slot\n")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		first.Close()
+		select {
+		case c := <-accepted:
+			c.Close()
+		case <-time.After(time.Second):
+			t.Fatalf("expected 2nd connection after 1st is closed\n")
+		}
+
+		// blocked Accept return once listener is closed
+		l.Close()
+		select {
+		case _, ok := <-accepted:
+			if ok {
+				t.Errorf("expected no more connection\n")
+			}
+		case <-time.After(time.Second):
+			t.Errorf("expected Accept to return after Close\n")
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package unit_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	logging "github.com/prothegee/network-limiter-go/pkg/logging"
+)
+
+func TestUnit_NewLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	logger, err := logging.NewLogger(&buf, "warn", "json"); if err != nil {
+		t.Fatalf("can't create logger: %v\n", err)
+	}
+	logger.Info("hidden")
+	logger.Warn("shown", "key", "value")
+
+	if strings.Contains(buf.String(), "hidden") {
+		t.Errorf("info should be filtered at warn level: %s\n", buf.String())
+	}
+
+	var line map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil || line["key"] != "value" {
+		t.Errorf("expected json line with key attribute, got %q\n", buf.String())
+	}
+
+	if _, err := logging.NewLogger(&buf, "loud", "text"); err == nil {
+		t.Errorf("expected error for invalid level\n")
+	}
+	if _, err := logging.NewLogger(&buf, "info", "xml"); err == nil {
+		t.Errorf("expected error for invalid format\n")
+	}
+}
+
+func TestIntegration_HttpRejectionLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := logging.NewLogger(&buf, "info", "json")
+
+	middleware := &http_limiter.HttpMiddleware{
+		Limiter: http_limiter.NewHttpRateLimiter(1, 30*time.Second),
+		Policy: "home",
+		Observers: []gen.DecisionObserver{logging.NewRejectionLogger(logger, time.Minute, 2)},
+	}
+
+	handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	// 1 allowed, 5 rejected, only 2 rejection logged
+	for i := 1; i <= 6; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Real-IP", "192.168.1.100")
+		handler(httptest.NewRecorder(), req)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 sampled line, got %d:\n%s\n", len(lines), buf.String())
+	}
+
+	var line map[string]any
+	json.Unmarshal([]byte(lines[0]), &line)
+
+	if line["key"] != "192.168.1.100" || line["policy"] != "home" || line["result"] != gen.ResultRejected {
+		t.Errorf("unexpected rejection log: %v\n", line)
+	}
+	if line["count"] != float64(1) || line["limit"] != float64(1) {
+		t.Errorf("expected count & limit in rejection log: %v\n", line)
+	}
+}
+
+func TestUnit_RejectionLogWindow(t *testing.T) {
+	var buf bytes.Buffer
+	logger, _ := logging.NewLogger(&buf, "info", "json")
+
+	clock := gen.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	rejection := logging.NewRejectionLogger(logger, time.Minute, 1, gen.WithClock(clock))
+
+	reject := func(key string) {
+		rejection.ObserveDecision(context.Background(), gen.Decision{Key: key, Result: gen.ResultRejected})
+	}
+
+	// 1 logged & 3 suppressed, then next window
+	for range 4 {
+		reject("10.0.0.1")
+	}
+	clock.Advance(time.Minute)
+	reject("10.0.0.2")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 line, got %d:\n%s\n", len(lines), buf.String())
+	}
+
+	var summary map[string]any
+	json.Unmarshal([]byte(lines[1]), &summary)
+	if summary["key"] != "10.0.0.1" || summary["suppressed"] != float64(3) {
+		t.Errorf("expected 3 suppressed of previous window, got %v\n", summary)
+	}
+}
@@ -0,0 +1,141 @@
+package unit_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	metrics "github.com/prothegee/network-limiter-go/pkg/metrics"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnit_MetricsExposition(t *testing.T) {
+	registry := metrics.NewRegistry()
+
+	counter := metrics.NewCounterVec("test_total", "Test counter.", "route")
+	counter.Inc("/a")
+	counter.Add(2, "/b\"quoted\"")
+	counter.Add(-1, "/a") // ignored
+
+	histogram := metrics.NewHistogramVec("test_seconds", "Test histogram.", []float64{0.1, 1})
+	histogram.Observe(0.05)
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+
+	gauge := metrics.NewGaugeVec("test_keys", "Test gauge.")
+	gauge.Func(func() float64 { return 42 })
+
+	registry.Register(counter)
+	registry.Register(histogram)
+	registry.Register(gauge)
+
+	rec := httptest.NewRecorder()
+	registry.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	expected := []string{
+		"# TYPE test_total counter\n",
+		`test_total{route="/a"} 1` + "\n",
+		`test_total{route="/b\"quoted\""} 2` + "\n",
+		"# TYPE test_seconds histogram\n",
+		`test_seconds_bucket{le="0.1"} 1` + "\n",
+		`test_seconds_bucket{le="1"} 2` + "\n",
+		`test_seconds_bucket{le="+Inf"} 3` + "\n",
+		"test_seconds_sum 5.55\n",
+		"test_seconds_count 3\n",
+		"test_keys 42\n",
+	}
+	for _, line := range expected {
+		if !strings.Contains(body, line) {
+			t.Errorf("missing %q in exposition:\n%s\n", line, body)
+		}
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q\n", rec.Header().Get("Content-Type"))
+	}
+}
+
+func TestIntegration_LimiterMetrics(t *testing.T) {
+	registry := metrics.NewRegistry()
+	limiterMetrics := metrics.NewLimiterMetrics(registry)
+
+	t.Run("TEST: http decisions", func(t *testing.T) {
+		rateLimiter := http_limiter.NewHttpRateLimiter(2, 30*time.Second)
+		middleware := &http_limiter.HttpMiddleware{
+			Limiter: rateLimiter,
+			Observers: []gen.DecisionObserver{limiterMetrics},
+		}
+		limiterMetrics.TrackKeys("http", rateLimiter.KeyCount)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/home", middleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		for i := 1; i <= 5; i++ {
+			req := httptest.NewRequest("GET", "/home", nil)
+			req.Header.Set("X-Real-IP", "192.168.1.100")
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+		}
+
+		if got := limiterMetrics.Decisions.Value("http", "default", "/home", "allowed"); got != 2 {
+			t.Errorf("expected 2 allowed, got %v\n", got)
+		}
+		if got := limiterMetrics.Decisions.Value("http", "default", "/home", "rejected"); got != 3 {
+			t.Errorf("expected 3 rejected, got %v\n", got)
+		}
+		if got := limiterMetrics.DecisionLatency.Count("http", "default"); got != 5 {
+			t.Errorf("expected 5 latency observation, got %v\n", got)
+		}
+	})
+
+	t.Run("TEST: grpc decisions", func(t *testing.T) {
+		middleware := grpc_limiter.NewGrpcMiddleware(grpc_limiter.NewGrpcRateLimiter(1, 30*time.Second))
+		middleware.Policy = "location"
+		middleware.Observers = append(middleware.Observers, limiterMetrics)
+
+		interceptor := middleware.Limit()
+		for i := 1; i <= 3; i++ {
+			ctx := metadata.NewIncomingContext(context.Background(),
+				metadata.Pairs("x-real-ip", "192.168.1.100"))
+			interceptor(ctx, nil, &grpc.UnaryServerInfo{
+				FullMethod: pb.LOCATION_SEND_LOCATION_AND_SAVE,
+			}, func(ctx context.Context, req any) (any, error) { return nil, nil })
+		}
+
+		if got := limiterMetrics.Decisions.Value("grpc", "location", pb.LOCATION_SEND_LOCATION_AND_SAVE, "rejected"); got != 2 {
+			t.Errorf("expected 2 rejected, got %v\n", got)
+		}
+	})
+
+	t.Run("TEST: scrape", func(t *testing.T) {
+		ts := httptest.NewServer(registry.Handler())
+		defer ts.Close()
+
+		resp, err := http.Get(ts.URL); if err != nil {
+			t.Fatalf("scrape failed: %v\n", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		for _, line := range []string{
+			`network_limiter_decisions_total{protocol="http",policy="default",route="/home",result="rejected"} 3`,
+			`network_limiter_tracked_keys{protocol="http"} 1`,
+			`network_limiter_decision_duration_seconds_count{protocol="grpc",policy="location"} 3`,
+		} {
+			if !strings.Contains(string(body), line) {
+				t.Errorf("missing %q in scrape:\n%s\n", line, body)
+			}
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package unit_test
+
+import (
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+)
+
+func TestUnit_HttpReservation(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("TEST: allow n is all or nothing", func(t *testing.T) {
+		clock := gen.NewFakeClock(start)
+		limiter := http_limiter.NewHttpRateLimiter(5, time.Minute, gen.WithClock(clock))
+
+		if !limiter.AllowN("10.0.0.1", 3, clock.Now()) {
+			t.Fatalf("expected 3 of 5 to be allowed\n")
+		}
+		if limiter.AllowN("10.0.0.1", 3, clock.Now()) {
+			t.Fatalf("expected 3 more to be rejected\n")
+		}
+		if state, _ := limiter.GetKeyState("10.0.0.1"); state.Count != 3 {
+			t.Fatalf("expected rejected batch not to be counted, got %d\n", state.Count)
+		}
+		if !limiter.Allow("10.0.0.1") || !limiter.Allow("10.0.0.1") || limiter.Allow("10.0.0.1") {
+			t.Errorf("expected exactly 2 more single request\n")
+		}
+		if limiter.AllowN("10.0.0.2", 6, clock.Now()) {
+			t.Errorf("expected batch over max request to never fit\n")
+		}
+	})
+
+	t.Run("TEST: reserve future slot", func(t *testing.T) {
+		clock := gen.NewFakeClock(start)
+		limiter := http_limiter.NewHttpRateLimiter(1, time.Minute, gen.WithClock(clock))
+
+		first := limiter.Reserve("10.0.0.1")
+		if !first.OK() || first.Delay() != 0 {
+			t.Fatalf("expected first slot now, got %v %v\n", first.OK(), first.Delay())
+		}
+
+		second := limiter.Reserve("10.0.0.1")
+		if second.Delay() <= time.Minute - time.Second || second.Delay() > time.Minute + time.Second {
+			t.Fatalf("expected second slot after 1 window, got %v\n", second.Delay())
+		}
+		if third := limiter.Reserve("10.0.0.1"); third.Delay() <= 2 * time.Minute - time.Second {
+			t.Fatalf("expected third slot after 2 window, got %v\n", third.Delay())
+		}
+
+		// reserved slot is seen by normal check
+		if limiter.CheckRequestLimit("10.0.0.1") {
+			t.Errorf("expected check to be rejected while slot is reserved\n")
+		}
+
+		clock.Advance(time.Minute + time.Second)
+		if second.Delay() != 0 {
+			t.Errorf("expected second slot due, got %v\n", second.Delay())
+		}
+	})
+
+	t.Run("TEST: cancel refund the slot", func(t *testing.T) {
+		clock := gen.NewFakeClock(start)
+		limiter := http_limiter.NewHttpRateLimiter(1, time.Minute, gen.WithClock(clock))
+
+		r := limiter.Reserve("10.0.0.1")
+		r.Cancel()
+		r.Cancel()
+
+		if state, ok := limiter.GetKeyState("10.0.0.1"); ok && state.Count != 0 {
+			t.Fatalf("expected refunded slot, got %d\n", state.Count)
+		}
+		if next := limiter.Reserve("10.0.0.1"); next.Delay() != 0 {
+			t.Errorf("expected refunded slot to be free now, got %v\n", next.Delay())
+		}
+	})
+
+	t.Run("TEST: cancel after slot time give nothing back", func(t *testing.T) {
+		clock := gen.NewFakeClock(start)
+		limiter := http_limiter.NewHttpRateLimiter(1, time.Minute, gen.WithClock(clock))
+
+		limiter.Allow("10.0.0.1")
+		r := limiter.Reserve("10.0.0.1")
+		if r.Delay() <= 0 {
+			t.Fatalf("expected future slot, got %v\n", r.Delay())
+		}
+
+		clock.Advance(r.Delay() + time.Second)
+		r.Cancel()
+
+		if state, _ := limiter.GetKeyState("10.0.0.1"); state.Count != 1 {
+			t.Errorf("expected used slot to stay counted, got %d\n", state.Count)
+		}
+		if limiter.Allow("10.0.0.1") {
+			t.Errorf("expected no quota back after late cancel\n")
+		}
+	})
+
+	t.Run("TEST: zero max request never reserve", func(t *testing.T) {
+		limiter := http_limiter.NewHttpRateLimiter(0, time.Minute)
+		if r := limiter.Reserve("10.0.0.1"); r.OK() {
+			t.Errorf("expected reservation not ok\n")
+		}
+	})
+}
+
+func TestUnit_GrpcReservation(t *testing.T) {
+	clock := gen.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	limiter := grpc_limiter.NewGrpcRateLimiter(2, time.Minute, gen.WithClock(clock))
+
+	if !limiter.AllowN("10.0.0.1", "/job", 2, clock.Now()) || limiter.Allow("10.0.0.1", "/job") {
+		t.Fatalf("expected 2 call then rejection\n")
+	}
+	if !limiter.Allow("10.0.0.1", "/other") {
+		t.Fatalf("expected other method to have its own quota\n")
+	}
+
+	r := limiter.Reserve("10.0.0.1", "/job")
+	if !r.OK() || r.Delay() <= 0 {
+		t.Fatalf("expected future slot, got %v %v\n", r.OK(), r.Delay())
+	}
+	if n := limiter.GetRequestCount("10.0.0.1", "/job"); n != 3 {
+		t.Errorf("expected reserved slot to be counted, got %d\n", n)
+	}
+
+	r.Cancel()
+	if n := limiter.GetRequestCount("10.0.0.1", "/job"); n != 2 {
+		t.Errorf("expected refunded slot, got %d\n", n)
+	}
+}
@@ -0,0 +1,182 @@
+package unit_test
+
+import (
+	"context"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func rlsDescriptor(pairs ...string) *pb.RateLimitDescriptor {
+	d := &pb.RateLimitDescriptor{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		d.Entries = append(d.Entries, &pb.RateLimitDescriptor_Entry{Key: pairs[i], Value: pairs[i+1]})
+	}
+
+	return d
+}
+
+func rlsHeader(resp *pb.RateLimitResponse, key string) string {
+	for _, h := range resp.GetResponseHeadersToAdd() {
+		if h.GetKey() == key {
+			return h.GetValue()
+		}
+	}
+
+	return ""
+}
+
+func TestIntegration_RlsServer(t *testing.T) {
+	// main middleware allow 1 call per ip, rls call must not count
+	middleware := grpc_limiter.NewGrpcMiddleware(grpc_limiter.NewGrpcRateLimiter(1, 30*time.Second))
+	middleware.Exempt = []string{pb.RATE_LIMIT_SERVICE}
+
+	rls := grpc_limiter.NewRlsServer([]*grpc_limiter.RlsPolicy{
+		{
+			Name: "per_ip_path",
+			Domain: "edge",
+			Match: map[string]string{"remote_address": "", "path": "/login"},
+			Limiter: grpc_limiter.NewGrpcRateLimiter(10, 500*time.Millisecond),
+		},
+		{
+			Name: "per_ip",
+			Domain: "edge",
+			Match: map[string]string{"remote_address": ""},
+			Limiter: grpc_limiter.NewGrpcRateLimiter(2, time.Minute),
+		},
+	})
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(middleware.Limit()))
+	pb.RegisterRateLimitServiceServer(server, rls)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0"); if err != nil {
+		t.Fatalf("can't listen: %v\n", err)
+	}
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials())); if err != nil {
+			t.Fatalf("can't create grpc client: %v\n", err)
+		}
+	defer conn.Close()
+	client := pb.NewRateLimitServiceClient(conn)
+
+	call := func(req *pb.RateLimitRequest) *pb.RateLimitResponse {
+		resp, err := client.ShouldRateLimit(context.Background(), req); if err != nil {
+			t.Fatalf("ShouldRateLimit failed: %v\n", err)
+		}
+
+		return resp
+	}
+
+	t.Run("TEST: descriptor over limit after policy quota", func(t *testing.T) {
+		req := &pb.RateLimitRequest{
+			Domain: "edge",
+			Descriptors: []*pb.RateLimitDescriptor{rlsDescriptor("remote_address", "10.0.0.1")},
+		}
+
+		for i := 1; i <= 2; i++ {
+			if resp := call(req); resp.GetOverallCode() != pb.RateLimitResponse_OK {
+				t.Fatalf("call %d: expected OK, got %v\n", i, resp.GetOverallCode())
+			}
+		}
+
+		resp := call(req)
+		if resp.GetOverallCode() != pb.RateLimitResponse_OVER_LIMIT {
+			t.Fatalf("expected OVER_LIMIT, got %v\n", resp.GetOverallCode())
+		}
+
+		status := resp.GetStatuses()[0]
+		if status.GetCode() != pb.RateLimitResponse_OVER_LIMIT || status.GetLimitRemaining() != 0 {
+			t.Errorf("expected OVER_LIMIT status with 0 remaining, got %v\n", status)
+		}
+		if limit := status.GetCurrentLimit(); limit.GetName() != "per_ip" ||
+			limit.GetRequestsPerUnit() != 2 || limit.GetUnit() != pb.RateLimitUnit_MINUTE {
+			t.Errorf("expected per_ip 2/MINUTE, got %v\n", limit)
+		}
+		if len(rlsHeader(resp, "retry-after")) <= 0 || rlsHeader(resp, "x-ratelimit-limit") != "2" {
+			t.Errorf("expected retry-after & x-ratelimit-limit header, got %v\n", resp.GetResponseHeadersToAdd())
+		}
+	})
+
+	t.Run("TEST: first matching policy win", func(t *testing.T) {
+		resp := call(&pb.RateLimitRequest{
+			Domain: "edge",
+			Descriptors: []*pb.RateLimitDescriptor{rlsDescriptor("remote_address", "10.0.0.1", "path", "/login")},
+		})
+
+		// sub-second window reported per second
+		limit := resp.GetStatuses()[0].GetCurrentLimit()
+		if limit.GetName() != "per_ip_path" || limit.GetRequestsPerUnit() != 20 || limit.GetUnit() != pb.RateLimitUnit_SECOND {
+			t.Errorf("expected per_ip_path 20/SECOND, got %v\n", limit)
+		}
+	})
+
+	t.Run("TEST: unmatched descriptor and domain is OK without limit", func(t *testing.T) {
+		resp := call(&pb.RateLimitRequest{
+			Domain: "internal",
+			Descriptors: []*pb.RateLimitDescriptor{
+				rlsDescriptor("remote_address", "10.0.0.1"),
+				rlsDescriptor("user", "alice"),
+			},
+		})
+
+		if resp.GetOverallCode() != pb.RateLimitResponse_OK || len(resp.GetStatuses()) != 2 {
+			t.Fatalf("expected OK with 2 status, got %v\n", resp)
+		}
+		for _, status := range resp.GetStatuses() {
+			if status.GetCurrentLimit() != nil {
+				t.Errorf("expected no current limit, got %v\n", status.GetCurrentLimit())
+			}
+		}
+	})
+
+	t.Run("TEST: hits addend count as many request", func(t *testing.T) {
+		descriptor := rlsDescriptor("remote_address", "10.0.0.2")
+		descriptor.HitsAddend = wrapperspb.UInt64(3)
+
+		resp := call(&pb.RateLimitRequest{Domain: "edge", Descriptors: []*pb.RateLimitDescriptor{descriptor}})
+		if resp.GetOverallCode() != pb.RateLimitResponse_OVER_LIMIT {
+			t.Errorf("expected 3 hits over 2/min to be OVER_LIMIT, got %v\n", resp.GetOverallCode())
+		}
+
+		resp = call(&pb.RateLimitRequest{
+			Domain: "edge",
+			HitsAddend: 2,
+			Descriptors: []*pb.RateLimitDescriptor{rlsDescriptor("remote_address", "10.0.0.3")},
+		})
+		if resp.GetOverallCode() != pb.RateLimitResponse_OK || resp.GetStatuses()[0].GetLimitRemaining() != 0 {
+			t.Errorf("expected 2 hits to use whole quota, got %v\n", resp.GetStatuses()[0])
+		}
+	})
+
+	t.Run("TEST: rejected hits addend is not charged", func(t *testing.T) {
+		descriptor := rlsDescriptor("remote_address", "10.0.0.4")
+		descriptor.HitsAddend = wrapperspb.UInt64(math.MaxUint64)
+
+		resp := call(&pb.RateLimitRequest{Domain: "edge", Descriptors: []*pb.RateLimitDescriptor{descriptor}})
+		if resp.GetOverallCode() != pb.RateLimitResponse_OVER_LIMIT {
+			t.Fatalf("expected huge hits addend to be OVER_LIMIT, got %v\n", resp.GetOverallCode())
+		}
+
+		// 1 hit, then 2 hits over the 1 left
+		call(&pb.RateLimitRequest{Domain: "edge", Descriptors: []*pb.RateLimitDescriptor{rlsDescriptor("remote_address", "10.0.0.4")}})
+		resp = call(&pb.RateLimitRequest{
+			Domain: "edge",
+			HitsAddend: 2,
+			Descriptors: []*pb.RateLimitDescriptor{rlsDescriptor("remote_address", "10.0.0.4")},
+		})
+		if resp.GetOverallCode() != pb.RateLimitResponse_OVER_LIMIT || resp.GetStatuses()[0].GetLimitRemaining() != 1 {
+			t.Errorf("expected OVER_LIMIT with 1 remaining, got %v\n", resp.GetStatuses()[0])
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package unit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ban "github.com/prothegee/network-limiter-go/pkg/ban"
+	cidr "github.com/prothegee/network-limiter-go/pkg/cidr"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+)
+
+func TestUnit_BackgroundLoopStop(t *testing.T) {
+	access, _ := cidr.NewAccessList(nil, nil, "", "")
+
+	loops := map[string]func(ctx context.Context){
+		"http cleanup": func(ctx context.Context) {
+			http_limiter.CleanupOldRequest(ctx, http_limiter.NewHttpRateLimiter(3, time.Second), time.Millisecond)
+		},
+		"grpc cleanup": func(ctx context.Context) {
+			grpc_limiter.CleanupOldRequest(ctx, grpc_limiter.NewGrpcRateLimiter(3, time.Second), time.Millisecond)
+		},
+		"ban cleanup": func(ctx context.Context) {
+			ban.CleanupExpiredBan(ctx, ban.NewBanBox(3, time.Second, time.Second, 2, time.Minute), time.Millisecond)
+		},
+		"access list watcher": func(ctx context.Context) {
+			cidr.WatchAccessList(ctx, access, time.Millisecond)
+		},
+	}
+
+	for name, loop := range loops {
+		t.Run("TEST: "+name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+
+			go func() {
+				loop(ctx)
+				close(done)
+			}()
+
+			// let it tick a few times before stopping
+			time.Sleep(5 * time.Millisecond)
+			cancel()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatalf("%s didn't stop after context cancel\n", name)
+			}
+		})
+	}
+}
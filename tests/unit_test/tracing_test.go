@@ -0,0 +1,130 @@
+package unit_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	tracing "github.com/prothegee/network-limiter-go/pkg/tracing"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func spanAttr(span tracetest.SpanStub, key string) (attribute.Value, bool) {
+	for _, kv := range span.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestIntegration_HttpTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(context.Background())
+
+	middleware := &http_limiter.HttpMiddleware{
+		Limiter: http_limiter.NewHttpRateLimiter(1, 30*time.Second),
+		Observers: []gen.DecisionObserver{tracing.NewDecisionTracer()},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/home", middleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	handler := tracing.HttpServerSpan(tp, mux)
+
+	for i := 1; i <= 2; i++ {
+		req := httptest.NewRequest("GET", "/home", nil)
+		req.Header.Set("X-Real-IP", "192.168.1.100")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 span, got %d\n", len(spans))
+	}
+
+	rejected := spans[1]
+	if rejected.Name != "GET /home" {
+		t.Errorf("unexpected span name %q\n", rejected.Name)
+	}
+	if v, _ := spanAttr(rejected, "ratelimit.result"); v.AsString() != gen.ResultRejected {
+		t.Errorf("expected rejected result attribute, got %q\n", v.AsString())
+	}
+	if v, _ := spanAttr(rejected, "ratelimit.remaining"); v.AsInt64() != 0 {
+		t.Errorf("expected 0 remaining, got %d\n", v.AsInt64())
+	}
+	if v, ok := spanAttr(rejected, "ratelimit.retry_after"); !ok || v.AsFloat64() <= 0 {
+		t.Errorf("expected positive retry after, got %v\n", v.AsFloat64())
+	}
+	if v, _ := spanAttr(rejected, "ratelimit.key_hash"); v.AsString() != tracing.HashKey("192.168.1.100") {
+		t.Errorf("expected hashed key, got %q\n", v.AsString())
+	}
+	if plain := sha256.Sum256([]byte("192.168.1.100")); tracing.HashKey("192.168.1.100") == hex.EncodeToString(plain[:8]) {
+		t.Errorf("expected keyed hash, plain sha256 of ip can be enumerated\n")
+	}
+	if v, _ := spanAttr(rejected, "http.response.status_code"); v.AsInt64() != http.StatusTooManyRequests {
+		t.Errorf("expected status %d, got %d\n", http.StatusTooManyRequests, v.AsInt64())
+	}
+	if len(rejected.Events) != 1 || rejected.Events[0].Name != "ratelimit.decision" {
+		t.Errorf("expected ratelimit.decision event, got %+v\n", rejected.Events)
+	}
+}
+
+func TestIntegration_GrpcTracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(context.Background())
+
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	middleware := grpc_limiter.NewGrpcMiddleware(grpc_limiter.NewGrpcRateLimiter(3, 30*time.Second))
+	middleware.Policy = "location"
+	middleware.Observers = append(middleware.Observers, tracing.NewDecisionTracer())
+
+	spanInterceptor := tracing.UnaryServerSpan(tp)
+	limitInterceptor := middleware.Limit()
+	info := &grpc.UnaryServerInfo{FullMethod: pb.LOCATION_SEND_LOCATION_AND_SAVE}
+
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs("x-real-ip", "192.168.1.100",
+			"traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"))
+
+	spanInterceptor(ctx, nil, info, func(ctx context.Context, req any) (any, error) {
+		return limitInterceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			return "ok", nil
+		})
+	})
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d\n", len(spans))
+	}
+
+	span := spans[0]
+	if span.SpanContext.TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected trace id from traceparent, got %s\n", span.SpanContext.TraceID())
+	}
+	if v, _ := spanAttr(span, "ratelimit.policy"); v.AsString() != "location" {
+		t.Errorf("expected policy attribute, got %q\n", v.AsString())
+	}
+	if v, _ := spanAttr(span, "ratelimit.remaining"); v.AsInt64() != 2 {
+		t.Errorf("expected 2 remaining, got %d\n", v.AsInt64())
+	}
+}
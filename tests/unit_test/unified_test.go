@@ -0,0 +1,160 @@
+package unit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	unified "github.com/prothegee/network-limiter-go/pkg/unified"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type locationServer struct {
+	pb.UnimplementedLocationServer
+}
+
+func (s *locationServer) SendLocationAndSave(ctx context.Context,
+											 req *pb.LocationReq) (*pb.LocationResp, error) {
+	return &pb.LocationResp{Ok: true, Message: req.GetMessage()}, nil
+}
+
+func TestUnit_UnifiedIsGrpcRequest(t *testing.T) {
+	cases := []struct {
+		name string
+		major int
+		contentType string
+		expected bool
+	}{
+		{"http/2 grpc", 2, "application/grpc", true},
+		{"http/2 grpc+proto", 2, "application/grpc+proto", true},
+		{"http/2 json", 2, "application/json", false},
+		{"http/1.1 grpc", 1, "application/grpc", false},
+	}
+
+	for _, c := range cases {
+		t.Run("TEST: " + c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.ProtoMajor = c.major
+			r.Header.Set("Content-Type", c.contentType)
+
+			if got := unified.IsGrpcRequest(r); got != c.expected {
+				t.Errorf("expected %v, got %v\n", c.expected, got)
+			}
+		})
+	}
+}
+
+func TestIntegration_UnifiedSharedLimiter(t *testing.T) {
+	httpLimiter := http_limiter.NewHttpRateLimiter(3, 30*time.Second)
+	httpMiddleware := &http_limiter.HttpMiddleware{Limiter: httpLimiter}
+
+	grpcMiddleware := grpc_limiter.NewGrpcMiddleware(nil)
+	grpcMiddleware.Shared = httpLimiter
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(grpcMiddleware.Limit()))
+	pb.RegisterLocationServer(grpcServer, &locationServer{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", httpMiddleware.Limit(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	server := httptest.NewUnstartedServer(unified.Handler(grpcServer, mux))
+	server.Config.Protocols = unified.Protocols()
+	server.Start()
+	defer server.Close()
+
+	conn, err := grpc.NewClient(strings.TrimPrefix(server.URL, "http://"),
+		grpc.WithTransportCredentials(insecure.NewCredentials())); if err != nil {
+			t.Fatalf("can't create grpc client: %v\n", err)
+		}
+	defer conn.Close()
+	client := pb.NewLocationClient(conn)
+
+	getHttp := func(ip string) int {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		if len(ip) > 0 {
+			req.Header.Set("X-Real-IP", ip)
+		}
+
+		resp, err := http.DefaultClient.Do(req); if err != nil {
+			t.Fatalf("http request failed: %v\n", err)
+		}
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	callGrpc := func(ip string) codes.Code {
+		ctx := context.Background()
+		if len(ip) > 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, "x-real-ip", ip)
+		}
+		_, err := client.SendLocationAndSave(ctx, &pb.LocationReq{Message: "good"})
+
+		return status.Code(err)
+	}
+
+	t.Run("TEST: http and grpc share one port and one quota", func(t *testing.T) {
+		if code := getHttp("10.0.0.1"); code != http.StatusOK {
+			t.Fatalf("expected http %d, got %d\n", http.StatusOK, code)
+		}
+		if code := callGrpc("10.0.0.1"); code != codes.OK {
+			t.Fatalf("expected grpc %v, got %v\n", codes.OK, code)
+		}
+		if code := getHttp("10.0.0.1"); code != http.StatusOK {
+			t.Fatalf("expected http %d, got %d\n", http.StatusOK, code)
+		}
+
+		// 4th request of same ip, whichever protocol
+		if code := callGrpc("10.0.0.1"); code != codes.ResourceExhausted {
+			t.Errorf("expected grpc %v, got %v\n", codes.ResourceExhausted, code)
+		}
+		if code := getHttp("10.0.0.1"); code != http.StatusTooManyRequests {
+			t.Errorf("expected http %d, got %d\n", http.StatusTooManyRequests, code)
+		}
+	})
+
+	t.Run("TEST: other ip is not affected", func(t *testing.T) {
+		if code := callGrpc("10.0.0.2"); code != codes.OK {
+			t.Errorf("expected grpc %v, got %v\n", codes.OK, code)
+		}
+	})
+
+	t.Run("TEST: shared limiter count per ip, not per method", func(t *testing.T) {
+		state, _ := httpLimiter.GetKeyState("10.0.0.2")
+		if state.Count != 1 {
+			t.Errorf("expected count 1 on shared limiter, got %d\n", state.Count)
+		}
+	})
+
+	t.Run("TEST: direct client share quota without forwarding header", func(t *testing.T) {
+		if code := getHttp(""); code != http.StatusOK {
+			t.Fatalf("expected http %d, got %d\n", http.StatusOK, code)
+		}
+		if code := callGrpc(""); code != codes.OK {
+			t.Fatalf("expected grpc %v, got %v\n", codes.OK, code)
+		}
+		if code := getHttp(""); code != http.StatusOK {
+			t.Fatalf("expected http %d, got %d\n", http.StatusOK, code)
+		}
+
+		if code := callGrpc(""); code != codes.ResourceExhausted {
+			t.Errorf("expected grpc %v, got %v\n", codes.ResourceExhausted, code)
+		}
+		if state, _ := httpLimiter.GetKeyState("127.0.0.1"); state.Count != 3 {
+			t.Errorf("expected 3 request counted on 127.0.0.1, got %d\n", state.Count)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package unit_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gen "github.com/prothegee/network-limiter-go/pkg"
+	grpc_limiter "github.com/prothegee/network-limiter-go/pkg/grpc"
+	http_limiter "github.com/prothegee/network-limiter-go/pkg/http"
+	pb "github.com/prothegee/network-limiter-go/protobuf"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// @brief block until n ticker is running on clock, i.e. waiter is sleeping
+func waitTickers(t *testing.T, clock *gen.FakeClock, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for clock.TickerCount() < n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d ticker, got %d\n", n, clock.TickerCount())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestUnit_LimiterWait(t *testing.T) {
+	t.Run("TEST: wait until oldest request leave window", func(t *testing.T) {
+		clock := gen.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+		limiter := http_limiter.NewHttpRateLimiter(1, time.Minute, gen.WithClock(clock))
+		limiter.CheckRequestLimit("10.0.0.1")
+
+		type result struct {
+			state gen.KeyState
+			err error
+		}
+		done := make(chan result)
+		go func() {
+			state, err := limiter.Wait(context.Background(), "10.0.0.1")
+			done <- result{state, err}
+		}()
+
+		waitTickers(t, clock, 1)
+		clock.Advance(time.Minute + time.Second)
+
+		got := <-done
+		if got.err != nil || got.state.Count != 1 {
+			t.Fatalf("expected allowed with 1 request in window, got %+v %v\n", got.state, got.err)
+		}
+	})
+
+	t.Run("TEST: context deadline", func(t *testing.T) {
+		limiter := grpc_limiter.NewGrpcRateLimiter(1, time.Minute)
+		limiter.CheckRequestLimit("10.0.0.1", "/a")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		state, err := limiter.Wait(ctx, "10.0.0.1", "/a")
+		if !errors.Is(err, context.DeadlineExceeded) || state.Count != 1 {
+			t.Fatalf("expected deadline with last rejected state, got %+v %v\n", state, err)
+		}
+		if n := limiter.GetRequestCount("10.0.0.1", "/a"); n != 1 {
+			t.Errorf("expected timed out wait not to be counted, got %d\n", n)
+		}
+	})
+}
+
+func TestUnit_WaitQueue(t *testing.T) {
+	clock := gen.NewFakeClock(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	limiter := http_limiter.NewHttpRateLimiter(1, time.Minute, gen.WithClock(clock))
+	limiter.CheckRequestLimit("10.0.0.1")
+
+	queue := gen.NewWaitQueue(1, 0)
+
+	done := make(chan error)
+	go func() {
+		_, err := queue.Wait(context.Background(), limiter, "10.0.0.1")
+		done <- err
+	}()
+	waitTickers(t, clock, 1)
+
+	t.Run("TEST: full key queue reject at once", func(t *testing.T) {
+		if _, err := queue.Wait(context.Background(), limiter, "10.0.0.1"); !errors.Is(err, gen.ErrQueueFull) {
+			t.Fatalf("expected %v, got %v\n", gen.ErrQueueFull, err)
+		}
+		if queue.Len("10.0.0.1") != 1 || queue.Len("") != 1 {
+			t.Errorf("expected 1 waiting, got %d\n", queue.Len(""))
+		}
+	})
+
+	t.Run("TEST: waiter leave queue once allowed", func(t *testing.T) {
+		clock.Advance(time.Minute + time.Second)
+		if err := <-done; err != nil {
+			t.Fatalf("expected waiter to be allowed, got %v\n", err)
+		}
+		if queue.Len("") != 0 {
+			t.Errorf("expected empty queue, got %d\n", queue.Len(""))
+		}
+	})
+
+	t.Run("TEST: max wait", func(t *testing.T) {
+		queue.UpdatePolicy(1, 20*time.Millisecond)
+
+		if _, err := queue.Wait(context.Background(), limiter, "10.0.0.1"); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected %v, got %v\n", context.DeadlineExceeded, err)
+		}
+	})
+}
+
+func TestIntegration_MiddlewareQueue(t *testing.T) {
+	t.Run("TEST: http request wait instead of 429", func(t *testing.T) {
+		var got gen.Decision
+
+		middleware := &http_limiter.HttpMiddleware{
+			Limiter: http_limiter.NewHttpRateLimiter(1, 50*time.Millisecond),
+			Queue: gen.NewWaitQueue(1, time.Second),
+			Observers: []gen.DecisionObserver{observerFunc(func(d gen.Decision) { got = d })},
+		}
+		handler := middleware.Limit(func(w http.ResponseWriter, r *http.Request) {})
+
+		do := func() int {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("X-Real-IP", "10.0.0.1")
+
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			return w.Code
+		}
+
+		do()
+		if code := do(); code != http.StatusOK || got.Result != gen.ResultAllowed || got.Latency < 20*time.Millisecond {
+			t.Fatalf("expected queued request allowed after wait, got %d %s %v\n", code, got.Result, got.Latency)
+		}
+
+		// queue disabled, reject at once
+		middleware.Queue.UpdatePolicy(0, time.Second)
+		if code := do(); code != http.StatusTooManyRequests {
+			t.Errorf("expected %d, got %d\n", http.StatusTooManyRequests, code)
+		}
+	})
+
+	t.Run("TEST: grpc call rejected after max wait", func(t *testing.T) {
+		middleware := grpc_limiter.NewGrpcMiddleware(grpc_limiter.NewGrpcRateLimiter(1, time.Minute))
+		middleware.Queue = gen.NewWaitQueue(1, 20*time.Millisecond)
+		interceptor := middleware.Limit()
+
+		send := func() codes.Code {
+			ctx := metadata.NewIncomingContext(context.Background(),
+				metadata.Pairs("x-real-ip", "10.0.0.1"))
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{
+				FullMethod: pb.LOCATION_SEND_LOCATION_AND_SAVE,
+			}, func(ctx context.Context, req any) (any, error) { return nil, nil })
+
+			return status.Code(err)
+		}
+
+		send()
+		start := time.Now()
+		if code := send(); code != codes.ResourceExhausted || time.Since(start) < 20*time.Millisecond {
+			t.Errorf("expected %v after waiting, got %v in %v\n", codes.ResourceExhausted, code, time.Since(start))
+		}
+	})
+}